@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/yaxigin/mto/pkg/engine"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/projectdiscovery/gologger"
+)
+
+// accountResult 一个引擎的账号信息，查询失败时 Error 不为空
+type accountResult struct {
+	engine.Account
+	Error string `json:"error,omitempty"`
+
+	err error // 查询失败的原因，用于区分密钥无效和网络错误
+}
+
+// executeAccountCommand 查询各引擎已配置的API密钥对应的会员等级和剩余额度
+func executeAccountCommand(ctx context.Context, options *Tian) {
+	if _, err := loadConfig(); err != nil {
+		gologger.Fatal().Msgf("%v", err)
+	}
+
+	names := engine.Names()
+	if len(options.Args) > 0 {
+		names = options.Args
+	}
+
+	var results []accountResult
+	failed := 0
+	for _, name := range names {
+		if !engine.Has(name) {
+			gologger.Fatal().Msgf("未知引擎 %s，可选: %s", name, strings.Join(engine.Names(), "、"))
+		}
+		for _, r := range queryAccounts(ctx, options, name) {
+			if r.Error != "" {
+				failed++
+			}
+			results = append(results, r)
+		}
+	}
+
+	if options.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		enc.Encode(results)
+	} else {
+		printAccounts(results)
+	}
+
+	if failed == len(results) {
+		os.Exit(1)
+	}
+}
+
+// queryAccounts 查询一个引擎每个API密钥的账号信息
+func queryAccounts(ctx context.Context, options *Tian, name string) []accountResult {
+	var (
+		keys []string
+		opts engine.ClientOptions
+	)
+	cfg, err := loadConfig()
+	if err == nil {
+		keys, _, err = cfg.Keys(name)
+	}
+	if err == nil {
+		opts, err = clientOptions(cfg, options, name)
+	}
+	if err != nil {
+		return []accountResult{{Account: engine.Account{Engine: name, Quota: -1, QueryLeft: -1}, Error: err.Error(), err: err}}
+	}
+
+	var results []accountResult
+	for _, key := range keys {
+		r := accountResult{Account: engine.Account{Engine: name, Key: engine.MaskKey(key), Quota: -1, QueryLeft: -1}}
+		eng, err := engine.New(name, key, opts)
+		if err != nil {
+			r.Error, r.err = err.Error(), err
+			results = append(results, r)
+			continue
+		}
+		info, ok := eng.(engine.AccountInfo)
+		if !ok {
+			r.err = fmt.Errorf("该引擎不支持查询账号信息")
+			r.Error = r.err.Error()
+			results = append(results, r)
+			continue
+		}
+
+		account, err := info.Account(ctx)
+		if err != nil {
+			r.Error, r.err = err.Error(), err
+			if hint := errorHint(err); hint != "" {
+				gologger.Info().Msgf("[%s] %s: %s", name, r.Key, hint)
+			}
+		} else {
+			r.Account = account
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// printAccounts 以表格形式输出账号信息
+func printAccounts(results []accountResult) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Engine", "Key", "User", "Level", "Quota", "Queries Left", "Status"})
+	table.SetAutoWrapText(false)
+	for _, r := range results {
+		quota := formatCount(r.Quota)
+		if r.Quota >= 0 && r.QuotaUnit != "" {
+			quota += " " + r.QuotaUnit
+		}
+		status := "OK"
+		if r.Error != "" {
+			status = r.Error
+		}
+		table.Append([]string{r.Engine, r.Key, r.User, r.Level, quota, formatCount(r.QueryLeft), status})
+	}
+	table.Render()
+}
+
+// formatCount 格式化数量，负数表示引擎不提供该信息
+func formatCount(n int) string {
+	if n < 0 {
+		return "-"
+	}
+	return strconv.Itoa(n)
+}
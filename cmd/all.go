@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/yaxigin/mto/pkg/asset"
+	"github.com/yaxigin/mto/pkg/engine"
+	"github.com/yaxigin/mto/pkg/output"
+	"github.com/yaxigin/mto/pkg/query"
+
+	"github.com/projectdiscovery/gologger"
+)
+
+// portableQuery 将通用查询(FOFA写法)转换为指定引擎的查询语句
+func portableQuery(name, src string) (string, error) {
+	d, err := query.ParseDialect(name)
+	if err != nil {
+		return "", err
+	}
+	t, err := query.Translate(src, query.FOFA, d)
+	if err != nil {
+		return "", err
+	}
+	for _, w := range t.Warnings {
+		gologger.Warning().Msgf("[%s] %s", name, w)
+	}
+	return t.Query, nil
+}
+
+// allQueries 确定每个引擎使用的查询语句，单独指定的查询优先于通用查询
+func allQueries(options *Tian) map[string]string {
+	queries := make(map[string]string)
+	for _, name := range engine.Names() {
+		if q := options.EngineQueries[name]; q != nil && *q != "" {
+			queries[name] = *q
+			continue
+		}
+		if options.Query == "" {
+			continue
+		}
+		q, err := portableQuery(name, options.Query)
+		if err != nil {
+			gologger.Warning().Msgf("跳过 %s: %v", name, err)
+			continue
+		}
+		queries[name] = q
+	}
+	return queries
+}
+
+// executeAllCommand 在所有引擎上并发执行查询，合并去重后输出
+func executeAllCommand(ctx context.Context, options *Tian) {
+	queries := allQueries(options)
+	if len(queries) == 0 {
+		gologger.Fatal().Msgf("请使用 -s 指定通用查询，或使用 -fofa/-hunter/-quake 指定各引擎的查询")
+	}
+
+	opts := engine.Options{
+		Months:     options.Months,
+		MaxResults: options.MaxResults,
+		UseNext:    options.UseNext,
+		NoLint:     options.NoLint,
+	}
+
+	if options.DryRun {
+		var rows []dryRunRow
+		for _, name := range engine.Names() {
+			q, ok := queries[name]
+			if !ok {
+				continue
+			}
+			eng, err := newEngine(options, name)
+			if err != nil {
+				gologger.Warning().Msgf("跳过 %s: %v", name, err)
+				continue
+			}
+			rows = append(rows, dryRun(ctx, eng, []string{q}, opts)...)
+		}
+		printDryRun(rows, options)
+		return
+	}
+
+	db := openStore(options)
+	if db != nil {
+		defer db.Close()
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results = make(map[string][]asset.Asset)
+	)
+	for name, query := range queries {
+		eng, err := newEngine(options, name)
+		if err != nil {
+			gologger.Warning().Msgf("跳过 %s: %v", name, err)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			gologger.Info().Msgf("[%s] 查询语句: %s", name, query)
+			assets, err := eng.Search(ctx, query, opts)
+			if err != nil {
+				gologger.Warning().Msgf("[%s] 查询失败: %v", name, err)
+				if hint := errorHint(err); hint != "" {
+					gologger.Info().Msgf("[%s] %s", name, hint)
+				}
+			}
+			gologger.Info().Msgf("[%s] 获取到 %d 条结果", name, len(assets))
+			saveToStore(db, assets, query)
+
+			mu.Lock()
+			results[name] = assets
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	// 按引擎名称顺序合并，保证输出稳定
+	var groups [][]asset.Asset
+	for _, name := range engine.Names() {
+		groups = append(groups, results[name])
+	}
+	merged := asset.Merge(groups...)
+	gologger.Info().Msgf("合并去重后共 %d 条资产", len(merged))
+
+	output.Print(os.Stdout, merged, printMode(options))
+
+	if options.Output != "" {
+		if err := writeAll(options.Output, outputFormat(options), merged, queryLabel(options, queries)); err != nil {
+			gologger.Error().Msgf("写入文件失败: %v", err)
+			return
+		}
+		gologger.Info().Msgf("结果已保存到: %s", options.Output)
+	}
+}
+
+// queryLabel 返回写入输出文件查询列的内容，使用通用查询时为通用查询语句，否则列出各引擎的查询
+func queryLabel(options *Tian, queries map[string]string) string {
+	if options.Query != "" {
+		return options.Query
+	}
+	var parts []string
+	for _, name := range engine.Names() {
+		if q, ok := queries[name]; ok {
+			parts = append(parts, name+": "+q)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// writeAll 将合并后的结果写入输出文件，覆盖上次的结果
+func writeAll(outputFile string, format output.Format, assets []asset.Asset, query string) error {
+	w, err := output.NewWriter(format, outputFile, false)
+	if err != nil {
+		return err
+	}
+	if err := w.Write(assets, query); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
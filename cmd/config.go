@@ -0,0 +1,329 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yaxigin/mto/pkg/config"
+	"github.com/yaxigin/mto/pkg/engine"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/projectdiscovery/gologger"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+)
+
+// init 配置文件中的API密钥已加密时，从终端读取密码
+func init() {
+	config.Prompt = readPassphrase
+}
+
+// executeConfigCommand 初始化、查看、修改配置文件，验证和加密API密钥
+func executeConfigCommand(ctx context.Context, options *Tian) {
+	if len(options.Args) == 0 {
+		showConfigHelp()
+		return
+	}
+
+	args := options.Args[1:]
+	switch options.Args[0] {
+	case "init":
+		configInit()
+	case "get":
+		configGet(args)
+	case "set":
+		configSet(args)
+	case "test":
+		configTest(ctx, options, args)
+	case "path":
+		fmt.Println(config.GetConfigPath())
+	case "encrypt":
+		configEncrypt(options)
+	case "decrypt":
+		configDecrypt()
+	default:
+		gologger.Fatal().Msgf("未知的 config 子命令: %s，可选: init、get、set、test、path、encrypt、decrypt", options.Args[0])
+	}
+}
+
+// configInit 依次提示输入各引擎的API密钥并写入配置文件
+func configInit() {
+	gologger.Info().Msgf("配置文件: %s", config.GetConfigPath())
+	gologger.Info().Msgf("依次输入各引擎的API密钥，输入内容不会显示，直接回车跳过或保留当前的密钥")
+
+	changed := 0
+	for _, name := range engine.Names() {
+		prompt := fmt.Sprintf("%s API密钥", name)
+		if v, ok, err := config.Get(name + ".key"); err != nil {
+			gologger.Fatal().Msgf("%v", err)
+		} else if key, _ := v.(string); ok && key != "" {
+			prompt += fmt.Sprintf("(当前 %s)", maskValue(key))
+		}
+
+		key, err := readSecret(prompt + ": ")
+		if err != nil {
+			gologger.Fatal().Msgf("读取输入失败: %v", err)
+		}
+		if key == "" {
+			continue
+		}
+		if err := config.Set(name+".key", key); err != nil {
+			gologger.Fatal().Msgf("%v", err)
+		}
+		gologger.Info().Msgf("已保存 %s API密钥 %s", name, engine.MaskKey(key))
+		changed++
+	}
+
+	if changed > 0 {
+		gologger.Info().Msgf("可以使用 mto config test 验证API密钥是否有效")
+	}
+}
+
+// configGet 输出配置项的值，不指定配置项时输出整个配置文件，密钥只显示首尾几位
+func configGet(args []string) {
+	path := ""
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	v, ok, err := config.Get(path)
+	if err != nil {
+		gologger.Fatal().Msgf("%v", err)
+	}
+	if !ok {
+		gologger.Fatal().Msgf("配置项 %s 不存在", path)
+	}
+
+	v = maskSecrets(path, v)
+	if s, isScalar := scalarString(v); isScalar {
+		fmt.Println(s)
+		return
+	}
+	// 与配置文件使用相同的缩进
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		gologger.Fatal().Msgf("%v", err)
+	}
+}
+
+// configSet 修改配置项，修改密钥且没有给出值时从终端读取，避免密钥留在命令历史中
+func configSet(args []string) {
+	if len(args) == 0 {
+		gologger.Fatal().Msgf("请指定配置项，如: mto config set hunter.key 或 mto config set http.proxy socks5://127.0.0.1:1080")
+	}
+	path := args[0]
+
+	var value string
+	if len(args) > 1 {
+		value = strings.Join(args[1:], " ")
+	} else if isSecret(path) {
+		var err error
+		if value, err = readSecret(path + ": "); err != nil {
+			gologger.Fatal().Msgf("读取输入失败: %v", err)
+		}
+	} else {
+		gologger.Fatal().Msgf("请指定 %s 的值", path)
+	}
+
+	if err := config.Set(path, value); err != nil {
+		gologger.Fatal().Msgf("%v", err)
+	}
+
+	// 输出保存后的值，列表中的每个密钥分别隐藏
+	if v, ok, err := config.Get(path); err == nil && ok {
+		v = maskSecrets(path, v)
+		if s, isScalar := scalarString(v); isScalar {
+			value = s
+		} else {
+			value = fmt.Sprint(v)
+		}
+	}
+	gologger.Info().Msgf("已设置 %s = %s", path, value)
+}
+
+// keyStatus 一个API密钥的验证结果
+type keyStatus struct {
+	accountResult
+	valid string
+}
+
+// configTest 使用每个API密钥查询一次账号信息，验证密钥是否有效并显示会员等级
+func configTest(ctx context.Context, options *Tian, args []string) {
+	cfg, err := loadConfig()
+	if err != nil {
+		gologger.Fatal().Msgf("%v", err)
+	}
+
+	names := engine.Names()
+	if len(args) > 0 {
+		names = args
+	}
+
+	var results []keyStatus
+	invalid := 0
+	for _, name := range names {
+		if !engine.Has(name) {
+			gologger.Fatal().Msgf("未知引擎 %s，可选: %s", name, strings.Join(engine.Names(), "、"))
+		}
+		if _, _, err := cfg.Keys(name); err != nil {
+			results = append(results, keyStatus{accountResult{Account: engine.Account{Engine: name}}, "未配置"})
+			continue
+		}
+
+		// 网络不通时每个引擎最多等待 30 秒
+		tctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		for _, r := range queryAccounts(tctx, options, name) {
+			s := keyStatus{accountResult: r, valid: "有效"}
+			switch {
+			case r.err == nil:
+			case errors.Is(r.err, engine.ErrAuth):
+				s.valid = "无效"
+				invalid++
+			default:
+				s.valid = "未知"
+				invalid++
+			}
+			results = append(results, s)
+		}
+		cancel()
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Engine", "Key", "Valid", "Level", "Quota", "Message"})
+	table.SetAutoWrapText(false)
+	for _, s := range results {
+		quota := formatCount(s.Quota)
+		if s.Quota >= 0 && s.QuotaUnit != "" {
+			quota += " " + s.QuotaUnit
+		}
+		if s.Key == "" {
+			quota = "-"
+		}
+		table.Append([]string{s.Engine, s.Key, s.valid, s.Level, quota, s.Error})
+	}
+	table.Render()
+
+	if invalid > 0 {
+		os.Exit(1)
+	}
+}
+
+// configEncrypt 加密配置文件中的API密钥和通知配置中的密钥，已加密的配置文件只加密之后以明文添加的密钥
+func configEncrypt(options *Tian) {
+	n, err := config.Encrypt(options.Keyfile)
+	if err != nil {
+		gologger.Fatal().Msgf("加密失败: %v", err)
+	}
+	gologger.Info().Msgf("已加密 %d 个API密钥和通知配置中的密钥，配置文件 %s 的权限已设置为 0600", n, config.GetConfigPath())
+	if options.Keyfile != "" {
+		gologger.Info().Msgf("请妥善保管密钥文件 %s，丢失后无法解密", options.Keyfile)
+	} else {
+		gologger.Info().Msgf("之后使用时需要输入密码，也可以设置环境变量 MTO_PASSPHRASE")
+	}
+}
+
+// configDecrypt 将加密的API密钥和通知配置恢复为明文
+func configDecrypt() {
+	n, err := config.Decrypt()
+	if err != nil {
+		gologger.Fatal().Msgf("解密失败: %v", err)
+	}
+	gologger.Info().Msgf("已解密 %d 个值，配置文件 %s 中的密钥恢复为明文", n, config.GetConfigPath())
+}
+
+// secretFields 需要隐藏的配置项名称
+var secretFields = map[string]bool{"key": true, "keys": true, "secret": true, "token": true}
+
+// isSecret 判断配置项是否为密钥
+func isSecret(path string) bool {
+	names := strings.Split(path, ".")
+	return secretFields[names[len(names)-1]]
+}
+
+// maskSecrets 隐藏值中名称为 key、keys、secret、token 的内容
+func maskSecrets(path string, v any) any {
+	if isSecret(path) {
+		return maskValue(v)
+	}
+	switch t := v.(type) {
+	case string:
+		// 通知目标的地址等加密保存的值
+		if config.IsEncrypted(t) {
+			return "(已加密)"
+		}
+	case map[string]any:
+		for k, item := range t {
+			t[k] = maskSecrets(k, item)
+		}
+	case []any:
+		for i, item := range t {
+			t[i] = maskSecrets("", item)
+		}
+	}
+	return v
+}
+
+// maskValue 隐藏密钥，列表中的每一项分别隐藏，加密的密钥显示为 (已加密)
+func maskValue(v any) any {
+	switch t := v.(type) {
+	case string:
+		if config.IsEncrypted(t) {
+			return "(已加密)"
+		}
+		return engine.MaskKey(t)
+	case []any:
+		for i, item := range t {
+			t[i] = maskValue(item)
+		}
+	}
+	return v
+}
+
+// scalarString 将单个值格式化为字符串，映射和列表返回 false
+func scalarString(v any) (string, bool) {
+	switch v.(type) {
+	case map[string]any, []any:
+		return "", false
+	case nil:
+		return "", true
+	}
+	return fmt.Sprint(v), true
+}
+
+// readSecret 从终端读取密钥，输入内容不显示；标准输入不是终端时读取一行
+func readSecret(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	fd := int(os.Stdin.Fd())
+	if term.IsTerminal(fd) {
+		b, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		return strings.TrimSpace(string(b)), err
+	}
+
+	line, err := stdin.ReadString('\n')
+	if err != nil && line == "" {
+		if errors.Is(err, io.EOF) {
+			return "", nil
+		}
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// readPassphrase 从终端读取解锁配置文件的密码，标准输入不是终端时返回错误，避免读取到查询内容
+func readPassphrase(prompt string) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("配置文件中的API密钥已加密，标准输入不是终端，请设置环境变量 MTO_PASSPHRASE 或 MTO_KEYFILE")
+	}
+	return readSecret(prompt)
+}
+
+// stdin 非终端输入时按行读取，多次提示共用同一个缓冲
+var stdin = bufio.NewReader(os.Stdin)
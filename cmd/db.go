@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yaxigin/mto/pkg/asset"
+	"github.com/yaxigin/mto/pkg/config"
+	"github.com/yaxigin/mto/pkg/store"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/projectdiscovery/gologger"
+)
+
+// defaultDBPath db命令未指定 -db 时使用的资产库
+func defaultDBPath() string {
+	return filepath.Join(config.ConfigDir, "assets.db")
+}
+
+// openStore 指定了 -db 时打开资产库，查询结果会同时写入资产库
+func openStore(options *Tian) *store.Store {
+	if options.DB == "" {
+		return nil
+	}
+	s, err := store.Open(options.DB)
+	if err != nil {
+		gologger.Fatal().Msgf("%v", err)
+	}
+	gologger.Info().Msgf("查询结果将写入资产库: %s", options.DB)
+	return s
+}
+
+// saveToStore 将单次查询的结果写入资产库
+func saveToStore(s *store.Store, assets []asset.Asset, query string) {
+	if s == nil {
+		return
+	}
+	if err := s.Upsert(assets, query); err != nil {
+		gologger.Warning().Msgf("写入资产库失败: %v", err)
+	}
+}
+
+// executeDBCommand 资产库相关的子命令
+func executeDBCommand(_ context.Context, options *Tian) {
+	if len(options.Args) == 0 || options.Args[0] != "query" {
+		showDBHelp()
+		os.Exit(1)
+	}
+
+	sql := options.Query
+	if sql == "" {
+		sql = strings.Join(options.Args[1:], " ")
+	}
+	if sql == "" {
+		gologger.Fatal().Msgf("请指定SQL语句，如: mto db query 'SELECT ip, port, title FROM assets LIMIT 10'")
+	}
+
+	path := options.DB
+	if path == "" {
+		path = defaultDBPath()
+	}
+	if _, err := os.Stat(path); err != nil {
+		gologger.Fatal().Msgf("资产库不存在: %s，请先使用 -db 参数执行查询", path)
+	}
+
+	s, err := store.Open(path)
+	if err != nil {
+		gologger.Fatal().Msgf("%v", err)
+	}
+	defer s.Close()
+
+	result, err := s.Query(sql)
+	if err != nil {
+		gologger.Fatal().Msgf("%v", err)
+	}
+
+	if options.JSON || options.JSONL {
+		printRowsJSON(result, options.JSONL)
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(result.Columns)
+	table.SetAutoFormatHeaders(false)
+	table.AppendBulk(result.Rows)
+	table.Render()
+	gologger.Info().Msgf("共 %d 行", len(result.Rows))
+}
+
+// printRowsJSON 以列名为键输出每一行
+func printRowsJSON(result *store.Result, lines bool) {
+	objects := make([]map[string]string, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		obj := make(map[string]string, len(row))
+		for i, v := range row {
+			obj[result.Columns[i]] = v
+		}
+		objects = append(objects, obj)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	if lines {
+		for _, obj := range objects {
+			enc.Encode(obj)
+		}
+		return
+	}
+	enc.SetIndent("", "  ")
+	enc.Encode(objects)
+}
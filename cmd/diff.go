@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/yaxigin/mto/pkg/diff"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/projectdiscovery/gologger"
+)
+
+// executeDiffCommand 比较两次运行的输出文件
+func executeDiffCommand(_ context.Context, options *Tian) {
+	if len(options.Args) != 2 {
+		gologger.Fatal().Msgf("请指定两个输出文件，如: mto diff old.csv new.csv")
+	}
+
+	old, err := diff.Load(options.Args[0])
+	if err != nil {
+		gologger.Fatal().Msgf("%v", err)
+	}
+	cur, err := diff.Load(options.Args[1])
+	if err != nil {
+		gologger.Fatal().Msgf("%v", err)
+	}
+	gologger.Info().Msgf("%s: %d 条记录, %s: %d 条记录", options.Args[0], len(old.Assets), options.Args[1], len(cur.Assets))
+
+	printDiff(diff.Compare(old, cur), options)
+}
+
+// loadDiffBase 读取 --diff-against 指定的上次结果，未指定时返回 nil
+func loadDiffBase(options *Tian) *diff.Snapshot {
+	if options.DiffAgainst == "" {
+		return nil
+	}
+	old, err := diff.Load(options.DiffAgainst)
+	if err != nil {
+		gologger.Fatal().Msgf("%v", err)
+	}
+	return old
+}
+
+// printDiff 输出差异，-json/-jsonl 时输出机器可读的格式
+func printDiff(report *diff.Report, options *Tian) {
+	gologger.Info().Msgf("新增 %d 条, 消失 %d 条, 变化 %d 条", len(report.New), len(report.Gone), len(report.Changed))
+
+	switch {
+	case options.JSON:
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+		return
+	case options.JSONL:
+		enc := json.NewEncoder(os.Stdout)
+		for _, entries := range [][]diff.Entry{report.New, report.Gone, report.Changed} {
+			for _, e := range entries {
+				enc.Encode(e)
+			}
+		}
+		return
+	}
+
+	if report.Empty() {
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Kind", "Key", "URL", "Title", "Changes"})
+	table.SetAutoWrapText(false)
+	for _, entries := range [][]diff.Entry{report.New, report.Gone, report.Changed} {
+		for _, e := range entries {
+			var changes []string
+			for _, c := range e.Changes {
+				changes = append(changes, c.Field+": "+c.Old+" -> "+c.New)
+			}
+			table.Append([]string{string(e.Kind), e.Key, e.Asset.URL, e.Asset.Title, strings.Join(changes, "; ")})
+		}
+	}
+	table.Render()
+}
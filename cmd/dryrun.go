@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strconv"
+
+	"github.com/yaxigin/mto/pkg/engine"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/projectdiscovery/gologger"
+)
+
+// dryRunRow 一条查询的估算结果，获取总数失败时 Error 不为空
+type dryRunRow struct {
+	Engine string `json:"engine"`
+	Query  string `json:"query"`
+	engine.Estimate
+	Error string `json:"error,omitempty"`
+}
+
+// dryRun 只获取每条查询的结果总数并估算获取数据的开销，不下载数据
+func dryRun(ctx context.Context, eng engine.Engine, queries []string, opts engine.Options) []dryRunRow {
+	var rows []dryRunRow
+	for i, q := range queries {
+		if ctx.Err() != nil {
+			break
+		}
+		if len(queries) > 1 {
+			gologger.Info().Msgf("[%s] [%d/%d] 获取结果总数: %s", eng.Name(), i+1, len(queries), q)
+		}
+
+		row := dryRunRow{Engine: eng.Name(), Query: q}
+		total, err := eng.Count(ctx, q, opts)
+		if err != nil {
+			gologger.Warning().Msgf("[%s] 获取结果总数失败: %v", eng.Name(), err)
+			row.Error = err.Error()
+			rows = append(rows, row)
+			continue
+		}
+
+		if e, ok := eng.(engine.Estimator); ok {
+			row.Estimate = e.Estimate(total, opts)
+		} else {
+			row.Estimate = engine.Estimate{Total: total, Fetch: total, Pages: -1, Cost: -1}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// printDryRun 输出每条查询的估算结果和各引擎的合计
+func printDryRun(rows []dryRunRow, options *Tian) {
+	if options.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		enc.Encode(rows)
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Engine", "Query", "Total", "Fetch", "Pages", "Cost"})
+	table.SetAutoWrapText(false)
+
+	// 按引擎合计，保持引擎出现的顺序
+	var order []string
+	sums := make(map[string]*engine.Estimate)
+	for _, r := range rows {
+		if r.Error != "" {
+			table.Append([]string{r.Engine, r.Query, "-", "-", "-", r.Error})
+			continue
+		}
+		table.Append([]string{r.Engine, r.Query, strconv.Itoa(r.Total), strconv.Itoa(r.Fetch), formatCount(r.Pages), formatCost(r.Estimate)})
+
+		sum, ok := sums[r.Engine]
+		if !ok {
+			sum = &engine.Estimate{Unit: r.Unit}
+			sums[r.Engine] = sum
+			order = append(order, r.Engine)
+		}
+		sum.Total += r.Total
+		sum.Fetch += r.Fetch
+		sum.Pages = addKnown(sum.Pages, r.Pages)
+		sum.Cost = addKnown(sum.Cost, r.Cost)
+	}
+	if len(rows) > 1 {
+		for _, name := range order {
+			s := sums[name]
+			table.Append([]string{name, "合计", strconv.Itoa(s.Total), strconv.Itoa(s.Fetch), formatCount(s.Pages), formatCost(*s)})
+		}
+	}
+	table.Render()
+	gologger.Info().Msgf("以上为预估值，未下载任何数据。获取总数本身计入API查询次数，hunter/quake 每条查询可能消耗 1 积分")
+}
+
+// addKnown 累加数量，任意一个为负数（未知）时结果也是未知
+func addKnown(a, b int) int {
+	if a < 0 || b < 0 {
+		return -1
+	}
+	return a + b
+}
+
+// formatCost 格式化额度消耗，未知时显示 -
+func formatCost(e engine.Estimate) string {
+	if e.Cost < 0 {
+		return "-"
+	}
+	if e.Unit == "" {
+		return strconv.Itoa(e.Cost)
+	}
+	return strconv.Itoa(e.Cost) + " " + e.Unit
+}
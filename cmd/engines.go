@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/yaxigin/mto/pkg/config"
+	"github.com/yaxigin/mto/pkg/engine"
+	"github.com/yaxigin/mto/pkg/httpclient"
+
+	"github.com/projectdiscovery/gologger"
+
+	// 导入即注册各搜索引擎，新增引擎只需在此处添加一行
+	_ "github.com/yaxigin/mto/pkg/fofa"
+	_ "github.com/yaxigin/mto/pkg/hunter"
+	_ "github.com/yaxigin/mto/pkg/quake"
+)
+
+// cliLogger 将引擎客户端的日志输出到终端
+type cliLogger struct{}
+
+func (cliLogger) Infof(format string, args ...any) {
+	gologger.Info().Msgf(format, args...)
+}
+
+func (cliLogger) Warningf(format string, args ...any) {
+	gologger.Warning().Msgf(format, args...)
+}
+
+// configWarnings 保证配置文件的警告只输出一次
+var configWarnings sync.Once
+
+// loadConfig 读取配置文件，第一次读取时输出配置中可以忽略的问题
+func loadConfig() (*config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	configWarnings.Do(func() {
+		for _, w := range cfg.Warnings() {
+			gologger.Warning().Msgf("配置文件 %s: %s", config.GetConfigPath(), w)
+		}
+	})
+	return cfg, nil
+}
+
+// engines 已创建的引擎客户端，同一进程中复用，多个密钥的轮换和失效状态在各次查询之间共享
+var (
+	enginesMu sync.Mutex
+	engines   = make(map[string]engine.Engine)
+)
+
+// newEngine 使用配置文件中的API密钥和HTTP配置创建引擎客户端，--proxy、--user-agent 优先于配置文件
+// 配置了多个密钥时按 key_policy 选择，密钥额度不足或无效时自动切换
+func newEngine(options *Tian, name string) (engine.Engine, error) {
+	enginesMu.Lock()
+	defer enginesMu.Unlock()
+	if eng, ok := engines[name]; ok {
+		return eng, nil
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	keys, policy, err := cfg.Keys(name)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := clientOptions(cfg, options, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var eng engine.Engine
+	if len(keys) == 1 {
+		eng, err = engine.New(name, keys[0], opts)
+	} else {
+		var p engine.KeyPolicy
+		if p, err = engine.ParseKeyPolicy(policy); err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		eng, err = engine.NewMultiKey(name, keys, p, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	engines[name] = eng
+	return eng, nil
+}
+
+// clientOptions 使用配置文件中的HTTP和限速配置创建客户端参数，--proxy、--user-agent 优先于配置文件
+func clientOptions(cfg *config.Config, options *Tian, name string) (engine.ClientOptions, error) {
+	conf := cfg.HTTPConfig(name)
+	if options.Proxy != "" {
+		conf.Proxy = options.Proxy
+	}
+	if options.UserAgent != "" {
+		conf.UserAgent = options.UserAgent
+	}
+	client, err := httpclient.Get(conf)
+	if err != nil {
+		return engine.ClientOptions{}, fmt.Errorf("%s: %v", name, err)
+	}
+	return engine.ClientOptions{HTTPClient: client, Logger: cliLogger{}, Rate: cfg.Engine(name).Rate}, nil
+}
+
+// errorHint 根据引擎返回的错误类型给出处理建议，没有建议时返回空字符串
+func errorHint(err error) string {
+	switch {
+	case errors.Is(err, engine.ErrAuth):
+		return fmt.Sprintf("请检查配置文件 %s 中的API密钥和账号权限", config.GetConfigPath())
+	case errors.Is(err, engine.ErrQuota):
+		return "账号额度不足，请等待额度恢复或更换API密钥"
+	case errors.Is(err, engine.ErrField):
+		return "当前会员等级不能使用查询中的部分字段，请修改查询语句或升级会员"
+	case errors.Is(err, engine.ErrSyntax):
+		return "请使用 mto lint 检查查询语句"
+	case errors.Is(err, engine.ErrRateLimit):
+		return "请求过于频繁，请稍后再试"
+	}
+	return ""
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yaxigin/mto/pkg/fileutil"
+	"github.com/yaxigin/mto/pkg/query"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/projectdiscovery/gologger"
+)
+
+// executeLintCommand 离线检查查询语句，存在错误时以状态码1退出
+func executeLintCommand(_ context.Context, options *Tian) {
+	d, err := query.ParseDialect(options.Engine)
+	if err != nil {
+		gologger.Fatal().Msgf("请使用 -e 指定查询语法(fofa/hunter/quake)")
+	}
+
+	if options.Fields {
+		printFields(d)
+		return
+	}
+
+	var queries []string
+	switch {
+	case options.Local != "":
+		queries, err = fileutil.ReadQueries(options.Local)
+		if err != nil {
+			gologger.Fatal().Msgf("%v", err)
+		}
+	case options.Query != "":
+		queries = []string{options.Query}
+	case len(options.Args) > 0:
+		queries = []string{strings.Join(options.Args, " ")}
+	default:
+		gologger.Fatal().Msgf("请指定需要检查的查询语句，如: mto lint -e hunter 'web.title=\"login\"'")
+	}
+
+	failed := 0
+	for i, q := range queries {
+		issues := query.Lint(d, q)
+		if len(queries) > 1 {
+			gologger.Info().Msgf("[%d/%d] %s", i+1, len(queries), q)
+		}
+
+		hasError := false
+		for _, issue := range issues {
+			switch issue.Severity {
+			case query.SeverityError:
+				hasError = true
+				gologger.Error().Msgf("%s", issue)
+			case query.SeverityWarning:
+				gologger.Warning().Msgf("%s", issue)
+			default:
+				gologger.Info().Msgf("%s", issue)
+			}
+		}
+		if hasError {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		gologger.Error().Msgf("检查完成: 共 %d 条查询, %d 条存在错误", len(queries), failed)
+		os.Exit(1)
+	}
+	gologger.Info().Msgf("检查完成: 共 %d 条查询, 未发现错误", len(queries))
+}
+
+// printFields 以表格形式输出方言的字段目录
+func printFields(d query.Dialect) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Field", "Type", "Operators", "Level", "Description"})
+	table.SetAutoWrapText(false)
+	for _, f := range query.Catalog(d) {
+		table.Append([]string{f.Name, f.Type.String(), strings.Join(f.Ops, " "), f.Level, f.Desc})
+	}
+	table.Render()
+	fmt.Printf("共 %d 个字段\n", len(query.Catalog(d)))
+}
@@ -0,0 +1,16 @@
+package cmd
+
+import (
+	"github.com/yaxigin/mto/pkg/notify"
+
+	"github.com/projectdiscovery/gologger"
+)
+
+// openNotifier 配置文件中配置了通知目标时创建通知器，批量查询和监控发现资产后发送通知
+func openNotifier() *notify.Notifier {
+	n, err := notify.Load()
+	if err != nil {
+		gologger.Fatal().Msgf("%v", err)
+	}
+	return n
+}
@@ -1,8 +1,14 @@
 package cmd
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"os"
+	"strings"
+	"time"
+
+	"github.com/yaxigin/mto/pkg/engine"
 
 	"github.com/projectdiscovery/gologger"
 )
@@ -12,16 +18,50 @@ type Tian struct {
 	Command string
 
 	// 通用参数
-	Query      string // 查询语句
-	Local      string // 本地文件
-	Output     string // 输出文件
-	OnlyIP     bool   // 只输出ip
-	onlylink   bool   // 输出link
-	OnlyHost   bool   // 只输出host
-	YUfa       bool   // 只输出url
-	Months     int    // 查询月份范围
-	MaxResults int    // 最大结果数量
-	UseNext    bool   // 使用连续翻页接口
+	Query       string // 查询语句
+	Local       string // 本地文件
+	Output      string // 输出文件
+	OnlyIP      bool   // 只输出ip
+	onlylink    bool   // 输出link
+	OnlyHost    bool   // 只输出host
+	YUfa        bool   // 只输出url
+	JSON        bool   // 以JSON数组输出
+	JSONL       bool   // 以JSON Lines输出
+	Months      int    // 查询月份范围
+	MaxResults  int    // 最大结果数量
+	UseNext     bool   // 使用连续翻页接口
+	NoLint      bool   // 跳过查询前的离线检查
+	Threads     int    // 批量查询并发数
+	Resume      bool   // 从检查点继续批量查询
+	DB          string // SQLite资产库路径
+	Proxy       string // HTTP/SOCKS5代理
+	UserAgent   string // 请求使用的User-Agent
+	DiffAgainst string // 与上次的输出文件比较
+	DryRun      bool   // 只估算结果数量和额度消耗
+	Config      string // 配置文件路径
+	Keyfile     string // config encrypt 使用的密钥文件
+
+	// watch 命令参数
+	Every time.Duration // 两轮查询之间的间隔
+	State string        // 已发现资产的状态文件
+
+	// serve 命令参数
+	Listen   string // 监听地址
+	Token    string // 客户端访问令牌
+	MaxLimit int    // 单次请求最多返回的资产数量
+
+	// all 命令参数
+	EngineQueries map[string]*string // 各引擎单独指定的查询语句
+
+	// translate 命令参数
+	From string // 源查询语法
+	To   string // 目标查询语法
+
+	// lint 命令参数
+	Engine string // 检查使用的查询语法
+	Fields bool   // 输出字段目录
+
+	Args []string // 参数之后的位置参数
 }
 
 func ROO(Info *Tian) {
@@ -34,16 +74,12 @@ func ROO(Info *Tian) {
 	cmdFlags := flag.NewFlagSet(Info.Command, flag.ExitOnError)
 
 	// 根据命令设置默认输出文件
-	var defaultOutput string
-	switch Info.Command {
-	case "fofa":
-		defaultOutput = "fofa.csv"
-	case "hunter":
-		defaultOutput = "hunter.csv"
-	case "quake":
-		defaultOutput = "quake.csv"
-	default:
-		defaultOutput = "output.csv"
+	defaultOutput := "output.csv"
+	if engine.Has(Info.Command) {
+		defaultOutput = Info.Command + ".csv"
+	} else if Info.Command == "all" || Info.Command == "watch" {
+		// all/watch 命令只有指定 -o 时才写文件
+		defaultOutput = ""
 	}
 
 	// 定义参数
@@ -53,14 +89,63 @@ func ROO(Info *Tian) {
 	cmdFlags.BoolVar(&Info.onlylink, "u", false, "只过滤-s参数输出url信息")
 	cmdFlags.BoolVar(&Info.OnlyIP, "ip", false, "只过滤-s参数输出ip信息")
 	cmdFlags.BoolVar(&Info.YUfa, "k", false, "查询fofa语法")
+	cmdFlags.BoolVar(&Info.JSON, "json", false, "以JSON数组输出全部字段，-f参数时输出文件也使用JSON格式")
+	cmdFlags.BoolVar(&Info.JSONL, "jsonl", false, "以JSON Lines输出全部字段(每行一个资产)，-f参数时输出文件也使用该格式")
 	cmdFlags.BoolVar(&Info.OnlyHost, "h", false, "显示帮助信息")
 	cmdFlags.IntVar(&Info.Months, "m", 0, "查询月份范围(0:不限制, 1:一个月, 2:两个月)")
-	cmdFlags.IntVar(&Info.MaxResults, "d", 0, "最大结果数量，默认为1000，最大支持获取10000条结果（仅在传统查询时有效）")
+	cmdFlags.IntVar(&Info.MaxResults, "d", 0, "最大结果数量，fofa默认为1000（仅在传统查询时有效），hunter和quake默认获取全部结果，最大支持获取10000条结果")
 	cmdFlags.BoolVar(&Info.UseNext, "n", false, "使用连续翻页接口，避免数据错位问题")
+	cmdFlags.BoolVar(&Info.NoLint, "nolint", false, "跳过查询前的离线检查")
+	cmdFlags.IntVar(&Info.Threads, "c", 1, "-f批量查询的并发数，请求速率仍受各引擎限速控制")
+	cmdFlags.BoolVar(&Info.Resume, "resume", false, "从检查点继续上次中断的-f批量查询，结果追加到同一输出文件")
+	cmdFlags.StringVar(&Info.Config, "config", "", "使用指定的配置文件，默认为 ~/.mto/config.yml，也可以使用环境变量 MTO_CONFIG")
+	cmdFlags.StringVar(&Info.Keyfile, "keyfile", "", "config encrypt命令使用密钥文件代替密码，文件不存在时自动生成")
+	cmdFlags.StringVar(&Info.Proxy, "proxy", "", "请求引擎API使用的代理，如 http://127.0.0.1:8080、socks5://127.0.0.1:1080，优先于配置文件")
+	cmdFlags.StringVar(&Info.UserAgent, "user-agent", "", "请求引擎API使用的User-Agent，优先于配置文件")
+	cmdFlags.StringVar(&Info.DB, "db", "", "将查询结果写入SQLite资产库，按ip/port/host合并")
+	cmdFlags.BoolVar(&Info.DryRun, "dry-run", false, "只获取每条查询的结果总数，估算结果数量、请求数和额度消耗后退出，不下载数据")
+	cmdFlags.StringVar(&Info.DiffAgainst, "diff-against", "", "-f批量查询完成后与上次的输出文件比较，输出新增、消失和变化的资产")
+
+	// all 命令中各引擎单独指定的查询语句，如 -fofa 'title="x"'
+	Info.EngineQueries = make(map[string]*string)
+	for _, name := range engine.Names() {
+		Info.EngineQueries[name] = cmdFlags.String(name, "", fmt.Sprintf("all命令中%s使用的查询语句", name))
+	}
+
+	// translate 命令参数
+	cmdFlags.StringVar(&Info.From, "from", "fofa", "translate命令的源查询语法(fofa/hunter/quake)")
+	cmdFlags.StringVar(&Info.To, "to", "", "translate命令的目标查询语法(fofa/hunter/quake)")
+
+	// watch 命令参数
+	cmdFlags.DurationVar(&Info.Every, "every", 6*time.Hour, "watch命令两轮查询之间的间隔，0表示只执行一轮")
+	cmdFlags.StringVar(&Info.State, "state", "", "watch命令的状态文件，默认为查询文件名加 .state.json")
+
+	// serve 命令参数
+	cmdFlags.StringVar(&Info.Listen, "listen", "127.0.0.1:8080", "serve命令的监听地址")
+	cmdFlags.StringVar(&Info.Token, "token", os.Getenv("MTO_SERVE_TOKEN"), "serve命令要求客户端携带的访问令牌(Authorization: Bearer)，默认读取环境变量 MTO_SERVE_TOKEN")
+	cmdFlags.IntVar(&Info.MaxLimit, "max-limit", 10000, "serve命令单次请求最多返回的资产数量，0表示不限制")
 
-	// 解析命令后的参数
+	// lint 命令参数
+	cmdFlags.StringVar(&Info.Engine, "e", "", "lint命令检查的查询语法(fofa/hunter/quake)")
+	cmdFlags.BoolVar(&Info.Fields, "fields", false, "lint命令输出字段目录")
+
+	// 解析命令后的参数，允许参数和位置参数交替出现，如 mto db query -json 'SELECT ...'
 	if len(os.Args) > 2 {
-		cmdFlags.Parse(os.Args[2:])
+		args := os.Args[2:]
+		for len(args) > 0 {
+			cmdFlags.Parse(args)
+			args = cmdFlags.Args()
+			if len(args) == 0 {
+				break
+			}
+			Info.Args = append(Info.Args, args[0])
+			args = args[1:]
+		}
+	}
+
+	// 使用JSON格式且未指定输出文件时，默认文件名使用对应的扩展名
+	if Info.Output == defaultOutput && defaultOutput != "" {
+		Info.Output = strings.TrimSuffix(defaultOutput, ".csv") + outputFormat(Info).Ext()
 	}
 
 	// 调试输出
@@ -69,7 +154,8 @@ func ROO(Info *Tian) {
 	//gologger.Info().Msgf("Local: %s", Info.Local)
 }
 
-func Parse(options *Tian) {
+// Parse 执行命令，ctx 在收到中断信号时取消
+func Parse(ctx context.Context, options *Tian) {
 	// 如果没有指定命令，只显示主帮助信息
 	if options.Command == "" || options.Command == "help" {
 		ShowBanner()
@@ -77,35 +163,72 @@ func Parse(options *Tian) {
 		os.Exit(0)
 	}
 
-	// 检查是否需要显示帮助信息
-	for _, arg := range os.Args[2:] {
-		if arg == "-h" || arg == "--help" {
+	if c, ok := commands[options.Command]; ok {
+		if hasHelpFlag() {
 			ShowBanner()
-			switch options.Command {
-			case "hunter":
-				showHunterHelp()
-				os.Exit(0)
-			case "fofa":
-				showFofaHelp()
-				os.Exit(0)
-			case "quake":
-				showQuakeHelp()
-				os.Exit(0)
-			}
+			c.help()
+			os.Exit(0)
+		}
+		c.run(ctx, options)
+		return
+	}
+
+	ok := engine.Has(options.Command)
+
+	// 检查是否需要显示帮助信息
+	if hasHelpFlag() {
+		ShowBanner()
+		if help, found := engineHelp[options.Command]; found {
+			help()
+			os.Exit(0)
+		}
+		if ok {
+			showEngineHelp(options.Command)
+			os.Exit(0)
 		}
 	}
 
-	// 执行相应的命令
-	switch options.Command {
-	case "hunter":
-		executeHunterCommand(options)
-	case "fofa":
-		executeFofaExtCommand(options)
-	case "quake":
-		executeQuakeCommand(options)
-	default:
+	// 通过注册表分发到相应的引擎
+	if !ok {
 		gologger.Fatal().Msgf("Unknown command: %s", options.Command)
 	}
+	executeEngineCommand(ctx, options.Command, options)
+}
+
+// command 引擎以外的子命令
+type command struct {
+	run  func(ctx context.Context, options *Tian)
+	help func()
+}
+
+// commands 引擎以外的子命令，引擎子命令通过注册表分发
+var commands = map[string]command{
+	"all":       {executeAllCommand, showAllHelp},
+	"translate": {executeTranslateCommand, showTranslateHelp},
+	"lint":      {executeLintCommand, showLintHelp},
+	"db":        {executeDBCommand, showDBHelp},
+	"diff":      {executeDiffCommand, showDiffHelp},
+	"watch":     {executeWatchCommand, showWatchHelp},
+	"serve":     {executeServeCommand, showServeHelp},
+	"account":   {executeAccountCommand, showAccountHelp},
+	"config":    {executeConfigCommand, showConfigHelp},
+}
+
+// hasHelpFlag 检查命令行参数中是否包含 -h/--help
+func hasHelpFlag() bool {
+	for _, arg := range os.Args[2:] {
+		if arg == "-h" || arg == "--help" {
+			return true
+		}
+	}
+	return false
+}
+
+// engineHelp 各引擎专用的帮助信息，未列出的引擎使用通用帮助
+var engineHelp = map[string]func(){
+	"hunter": showHunterHelp,
+	"fofa":   showFofaHelp,
+	"quake":  showQuakeHelp,
 }
 
 // 主帮助信息
@@ -117,9 +240,243 @@ func showMainHelp() {
 	gologger.Print().Msgf("  hunter         mto的hunter模块")
 	gologger.Print().Msgf("  fofa           mto的fofa提取模块")
 	gologger.Print().Msgf("  quake          mto的quake提取模块")
+	gologger.Print().Msgf("  all            同时查询所有引擎并合并结果")
+	gologger.Print().Msgf("  translate      在fofa/hunter/quake语法之间转换查询语句")
+	gologger.Print().Msgf("  lint           离线检查查询语句的字段、运算符和值格式")
+	gologger.Print().Msgf("  db             使用SQL查询累积的资产库")
+	gologger.Print().Msgf("  diff           比较两次运行的输出，列出新增、消失和变化的资产")
+	gologger.Print().Msgf("  watch          定时重复执行查询文件，只输出新发现的资产")
+	gologger.Print().Msgf("  serve          启动HTTP API服务，供其他工具调用")
+	gologger.Print().Msgf("  account        查看各引擎API密钥的会员等级和剩余额度")
+	gologger.Print().Msgf("  config         初始化、查看、修改配置文件，验证和加密API密钥")
 	gologger.Print().Msgf("  help           Help about any command")
 }
 
+// all命令的帮助信息
+func showAllHelp() {
+	gologger.Print().Msgf("同时从fofa、hunter、quake查询资产，合并去重后输出。")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Usage:")
+	gologger.Print().Msgf("  mto all [flags]")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Flags:")
+	gologger.Print().Msgf("  -s, --search string    通用查询语法(FOFA写法)，自动转换为各引擎语法")
+	gologger.Print().Msgf("  -fofa string           fofa使用的查询语句，优先于 -s")
+	gologger.Print().Msgf("  -hunter string         hunter使用的查询语句，优先于 -s")
+	gologger.Print().Msgf("  -quake string          quake使用的查询语句，优先于 -s")
+	gologger.Print().Msgf("  -o, --output string    将合并后的结果输出到csv文件")
+	gologger.Print().Msgf("  -db string             将各引擎的结果写入SQLite资产库")
+	gologger.Print().Msgf("  --dry-run              只估算各引擎的结果数量、请求数和额度消耗，不下载数据")
+	gologger.Print().Msgf("  --proxy string         请求使用的代理，支持 http:// 和 socks5://")
+	gologger.Print().Msgf("  --user-agent string    请求使用的User-Agent")
+	gologger.Print().Msgf("  -u, --url              过滤输出url信息")
+	gologger.Print().Msgf("  -ip                    过滤输出ip信息")
+	gologger.Print().Msgf("  -json                  以JSON数组输出全部字段")
+	gologger.Print().Msgf("  -jsonl                 以JSON Lines输出全部字段(每行一个资产)")
+	gologger.Print().Msgf("  -m, --month int        查询月份范围(hunter/quake)")
+	gologger.Print().Msgf("  -d int                 fofa最大结果数量")
+	gologger.Print().Msgf("  -h, --help             显示帮助信息")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("通用查询按FOFA语法书写，转换规则与 mto translate 相同")
+}
+
+// account命令的帮助信息
+func showAccountHelp() {
+	gologger.Print().Msgf("查询各引擎API密钥对应的会员等级和剩余额度，不消耗额度。")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Usage:")
+	gologger.Print().Msgf("  mto account [engine...] [flags]")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Flags:")
+	gologger.Print().Msgf("  -json                  以JSON数组输出")
+	gologger.Print().Msgf("  --proxy string         请求使用的代理，支持 http:// 和 socks5://")
+	gologger.Print().Msgf("  --user-agent string    请求使用的User-Agent")
+	gologger.Print().Msgf("  -h, --help             显示帮助信息")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Quota 为剩余额度(fofa: F点，hunter/quake: 积分)，Queries Left 为剩余API查询次数，- 表示引擎不提供")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Examples:")
+	gologger.Print().Msgf("  mto account")
+	gologger.Print().Msgf("  mto account fofa hunter -json")
+}
+
+// config命令的帮助信息
+func showConfigHelp() {
+	gologger.Print().Msgf("初始化、查看、修改配置文件，验证和加密API密钥。密钥只显示首尾各4位。")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Usage:")
+	gologger.Print().Msgf("  mto config init                 依次输入各引擎的API密钥(输入内容不显示)")
+	gologger.Print().Msgf("  mto config get [path]           查看配置项，不指定时输出整个配置文件")
+	gologger.Print().Msgf("  mto config set <path> [value]   修改配置项，修改密钥且不给出值时从终端读取")
+	gologger.Print().Msgf("  mto config test [engine...]     使用每个密钥查询一次账号信息，验证是否有效并显示会员等级")
+	gologger.Print().Msgf("  mto config path                 输出使用的配置文件路径")
+	gologger.Print().Msgf("  mto config encrypt              加密配置文件中的API密钥和通知目标的地址、加签密钥，使用密码或密钥文件解锁")
+	gologger.Print().Msgf("  mto config decrypt              将加密的内容恢复为明文")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Flags:")
+	gologger.Print().Msgf("  --config string        使用指定的配置文件")
+	gologger.Print().Msgf("  --keyfile string       encrypt 使用密钥文件代替密码，文件不存在时自动生成")
+	gologger.Print().Msgf("  --proxy string         test 请求使用的代理，支持 http:// 和 socks5://")
+	gologger.Print().Msgf("  --user-agent string    test 请求使用的User-Agent")
+	gologger.Print().Msgf("  -h, --help             显示帮助信息")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Examples:")
+	gologger.Print().Msgf("  mto config set hunter.key")
+	gologger.Print().Msgf("  mto config set fofa.keys '[key1, key2]'")
+	gologger.Print().Msgf("  mto config set http.proxy socks5://127.0.0.1:1080")
+	gologger.Print().Msgf("  mto config get fofa")
+	gologger.Print().Msgf("  mto config test hunter")
+	gologger.Print().Msgf("  mto config encrypt --keyfile ~/.mto/master.key")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("加密后依次使用环境变量 MTO_KEYFILE、配置中的密钥文件、环境变量 MTO_PASSPHRASE 解锁，都没有时在终端输入密码。")
+}
+
+// translate命令的帮助信息
+func showTranslateHelp() {
+	gologger.Print().Msgf("在fofa、hunter、quake语法之间转换查询语句，字段名、运算符和日期格式会自动转换。")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Usage:")
+	gologger.Print().Msgf("  mto translate --from fofa --to quake 'title=\"login\" && country=\"CN\"'")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Flags:")
+	gologger.Print().Msgf("  --from string          源查询语法(fofa/hunter/quake)，默认fofa")
+	gologger.Print().Msgf("  --to string            目标查询语法(fofa/hunter/quake)")
+	gologger.Print().Msgf("  -s, --search string    需要转换的查询语句，也可以直接写在参数最后")
+	gologger.Print().Msgf("  -h, --help             显示帮助信息")
+}
+
+// lint命令的帮助信息
+func showLintHelp() {
+	gologger.Print().Msgf("离线检查查询语句，不发送请求、不消耗额度。检查语法、未知字段、字段不支持的运算符和值格式(如日期)。")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Usage:")
+	gologger.Print().Msgf("  mto lint -e hunter 'web.title=\"login\" && after=\"20230101\"'")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Flags:")
+	gologger.Print().Msgf("  -e string              查询语法(fofa/hunter/quake)")
+	gologger.Print().Msgf("  -s, --search string    需要检查的查询语句，也可以直接写在参数最后")
+	gologger.Print().Msgf("  -f, --file string      检查文件中的每一条查询语句")
+	gologger.Print().Msgf("  -fields                输出该语法的字段目录")
+	gologger.Print().Msgf("  -h, --help             显示帮助信息")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("各引擎查询前会自动执行同样的检查，存在错误时不会发送请求，可使用 -nolint 跳过")
+}
+
+// db命令的帮助信息
+func showDBHelp() {
+	gologger.Print().Msgf("使用SQL查询累积的资产库。查询时加上 -db 参数即可把结果写入资产库。")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Usage:")
+	gologger.Print().Msgf("  mto db query [flags] 'SELECT ip, port, title FROM assets WHERE title LIKE \"%%登录%%\"'")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Flags:")
+	gologger.Print().Msgf("  -db string             资产库路径，默认 %s", defaultDBPath())
+	gologger.Print().Msgf("  -json                  以JSON数组输出")
+	gologger.Print().Msgf("  -jsonl                 以JSON Lines输出")
+	gologger.Print().Msgf("  -h, --help             显示帮助信息")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Tables:")
+	gologger.Print().Msgf("  assets     每个 ip/port/host 一行，记录最近一次返回它的引擎(engine)和查询(query)，以及 first_seen_at/last_seen_at")
+	gologger.Print().Msgf("  sightings  每次查询命中的历史记录(ip, port, host, engine, query, seen_at)")
+}
+
+// diff命令的帮助信息
+func showDiffHelp() {
+	gologger.Print().Msgf("比较两次运行的输出文件，列出新增(new)、消失(gone)和变化(changed)的资产。")
+	gologger.Print().Msgf("变化包括标题、Server 以及同一IP开放端口的变化。")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Usage:")
+	gologger.Print().Msgf("  mto diff [flags] old.csv new.csv")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Flags:")
+	gologger.Print().Msgf("  -json                  以JSON输出差异")
+	gologger.Print().Msgf("  -jsonl                 每行输出一条差异")
+	gologger.Print().Msgf("  -h, --help             显示帮助信息")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("支持当前的 CSV/JSON/JSONL 输出，以及旧版本 fofa、hunter、quake 输出的 CSV 文件")
+}
+
+// serve命令的帮助信息
+func showServeHelp() {
+	gologger.Print().Msgf("启动HTTP API服务，API密钥只在服务端从配置文件读取，不会返回给客户端。")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Usage:")
+	gologger.Print().Msgf("  mto serve -listen 127.0.0.1:8080 -token xxx")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Flags:")
+	gologger.Print().Msgf("  -listen string         监听地址，默认 127.0.0.1:8080")
+	gologger.Print().Msgf("  -token string          要求客户端携带 Authorization: Bearer <token>，默认读取环境变量 MTO_SERVE_TOKEN")
+	gologger.Print().Msgf("  -max-limit int         单次请求最多返回的资产数量，默认10000，0表示不限制")
+	gologger.Print().Msgf("  --proxy string         请求使用的代理，支持 http:// 和 socks5://")
+	gologger.Print().Msgf("  --user-agent string    请求使用的User-Agent")
+	gologger.Print().Msgf("  -h, --help             显示帮助信息")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Endpoints:")
+	gologger.Print().Msgf("  GET  /engines          列出可用的引擎")
+	gologger.Print().Msgf("  POST /search           执行查询，请求体如:")
+	gologger.Print().Msgf("                         {\"engine\": \"fofa\", \"query\": \"title=\\\"login\\\"\", \"limit\": 100}")
+	gologger.Print().Msgf("                         engine 为 all 时将 FOFA 写法的查询转换后在全部引擎上执行")
+	gologger.Print().Msgf("                         可选字段: months、use_next、no_lint")
+}
+
+// watch命令的帮助信息
+func showWatchHelp() {
+	gologger.Print().Msgf("按固定间隔重复执行查询文件，记住已经发现过的资产，每轮只输出新发现的资产。")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Usage:")
+	gologger.Print().Msgf("  mto watch -f queries.yml --every 6h")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Flags:")
+	gologger.Print().Msgf("  -f, --file string      查询文件(.yml/.yaml，或每行一条FOFA写法查询的文本文件)")
+	gologger.Print().Msgf("  --every duration       两轮查询之间的间隔，如 30m、6h，默认6h，0表示只执行一轮")
+	gologger.Print().Msgf("  --state string         状态文件，默认为查询文件名加 .state.json")
+	gologger.Print().Msgf("  -o, --output string    将新资产追加写入文件，默认只输出到终端")
+	gologger.Print().Msgf("  -json                  以JSON数组输出")
+	gologger.Print().Msgf("  -jsonl                 以JSON Lines输出")
+	gologger.Print().Msgf("  -db string             将每轮的全部结果写入SQLite资产库")
+	gologger.Print().Msgf("  --proxy string         请求使用的代理，支持 http:// 和 socks5://")
+	gologger.Print().Msgf("  --user-agent string    请求使用的User-Agent")
+	gologger.Print().Msgf("  -h, --help             显示帮助信息")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("查询文件格式:")
+	gologger.Print().Msgf("  queries:")
+	gologger.Print().Msgf("    - name: 登录页                # 可选，日志中显示的名称")
+	gologger.Print().Msgf("      query: title=\"login\"       # FOFA写法，自动转换到各引擎")
+	gologger.Print().Msgf("      engines: [fofa, quake]     # 可选，默认全部引擎")
+	gologger.Print().Msgf("    - query: web.title=\"后台\"")
+	gologger.Print().Msgf("      engine: hunter             # 指定引擎时使用该引擎的原生语法")
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("按 Ctrl-C 会中断当前查询，输出已获取的新资产并保存状态后退出")
+}
+
+// 通用的引擎帮助信息
+func showEngineHelp(name string) {
+	if d := engine.Describe(name); d != nil {
+		gologger.Print().Msgf("%s", d.Description())
+		gologger.Print().Msgf("")
+	}
+	gologger.Print().Msgf("Usage:")
+	gologger.Print().Msgf("  mto %s [flags]", name)
+	gologger.Print().Msgf("")
+	gologger.Print().Msgf("Flags:")
+	gologger.Print().Msgf("  -s, --search string    单个查询语法")
+	gologger.Print().Msgf("  -f, --file string      从本地文件读取查询语法")
+	gologger.Print().Msgf("  -o, --output string    输出-f参数结果到csv文件,默认输出%s.csv", name)
+	gologger.Print().Msgf("  -c int                 -f参数的并发数，默认为1")
+	gologger.Print().Msgf("  --resume               从检查点继续上次中断的-f批量查询")
+	gologger.Print().Msgf("  -db string             将结果写入SQLite资产库，如 ~/.mto/assets.db")
+	gologger.Print().Msgf("  --proxy string         请求使用的代理，支持 http:// 和 socks5://")
+	gologger.Print().Msgf("  --user-agent string    请求使用的User-Agent")
+	gologger.Print().Msgf("  --diff-against string  -f批量查询完成后与上次的输出文件比较")
+	gologger.Print().Msgf("  --dry-run              只估算每条查询的结果数量、请求数和额度消耗，不下载数据")
+	gologger.Print().Msgf("  -u, --url              过滤输出url信息")
+	gologger.Print().Msgf("  -ip                    过滤输出ip信息")
+	gologger.Print().Msgf("  -json                  以JSON数组输出全部字段")
+	gologger.Print().Msgf("  -jsonl                 以JSON Lines输出全部字段(每行一个资产)")
+	gologger.Print().Msgf("  -k, --k                查询语法参考")
+	gologger.Print().Msgf("  -h, --help             显示帮助信息")
+}
+
 // hunter模块的帮助信息
 func showHunterHelp() {
 	gologger.Print().Msgf("从hunter获取资产信息。")
@@ -131,8 +488,17 @@ func showHunterHelp() {
 	gologger.Print().Msgf("  -s, --search string    单个hunter语法")
 	gologger.Print().Msgf("  -f, --file string      从本地文件读取hunter语法")
 	gologger.Print().Msgf("  -o, --output string    输出-f参数结果到csv文件,默认输出hunter.csv")
+	gologger.Print().Msgf("  -c int                 -f参数的并发数，默认为1")
+	gologger.Print().Msgf("  --resume               从检查点继续上次中断的-f批量查询")
+	gologger.Print().Msgf("  -db string             将结果写入SQLite资产库，如 ~/.mto/assets.db")
+	gologger.Print().Msgf("  --proxy string         请求使用的代理，支持 http:// 和 socks5://")
+	gologger.Print().Msgf("  --user-agent string    请求使用的User-Agent")
+	gologger.Print().Msgf("  --diff-against string  -f批量查询完成后与上次的输出文件比较")
+	gologger.Print().Msgf("  --dry-run              只估算每条查询的结果数量、请求数和额度消耗，不下载数据")
 	gologger.Print().Msgf("  -u, --url              过滤输出url信息")
 	gologger.Print().Msgf("  -ip                    过滤输出ip信息")
+	gologger.Print().Msgf("  -json                  以JSON数组输出全部字段")
+	gologger.Print().Msgf("  -jsonl                 以JSON Lines输出全部字段(每行一个资产)")
 	gologger.Print().Msgf("  -m, --month int        查询月份范围(0:不限制（默认）, 1:一个月, 2:两个月, 3:三月")
 	gologger.Print().Msgf("  -k, --k                查询hunter语法")
 	gologger.Print().Msgf("  -h, --help             显示帮助信息")
@@ -149,8 +515,17 @@ func showFofaHelp() {
 	gologger.Print().Msgf("  -s, --search string    单个fofa语法")
 	gologger.Print().Msgf("  -f, --file string      从本地文件读取fofa语法")
 	gologger.Print().Msgf("  -o, --output string    输出-f参数结果到csv文件,默认输出fofa.csv")
+	gologger.Print().Msgf("  -c int                 -f参数的并发数，默认为1")
+	gologger.Print().Msgf("  --resume               从检查点继续上次中断的-f批量查询")
+	gologger.Print().Msgf("  -db string             将结果写入SQLite资产库，如 ~/.mto/assets.db")
+	gologger.Print().Msgf("  --proxy string         请求使用的代理，支持 http:// 和 socks5://")
+	gologger.Print().Msgf("  --user-agent string    请求使用的User-Agent")
+	gologger.Print().Msgf("  --diff-against string  -f批量查询完成后与上次的输出文件比较")
+	gologger.Print().Msgf("  --dry-run              只估算每条查询的结果数量、请求数和额度消耗，不下载数据")
 	gologger.Print().Msgf("  -u, --url              过滤输出url信息")
 	gologger.Print().Msgf("  -ip                    过滤输出ip信息")
+	gologger.Print().Msgf("  -json                  以JSON数组输出全部字段")
+	gologger.Print().Msgf("  -jsonl                 以JSON Lines输出全部字段(每行一个资产)")
 	gologger.Print().Msgf("  -k, --k                查询fofa语法")
 	gologger.Print().Msgf("  -d int               最大结果数量，默认为1000，单次查询最大支持获取10000条结果")
 	gologger.Print().Msgf("  -n                使用连续翻页接口，避免数据错位问题，会自动获取所有可用结果，需要额外消耗F币（不受 -d 参数限制）")
@@ -168,8 +543,17 @@ func showQuakeHelp() {
 	gologger.Print().Msgf("  -s, --search string    单个quake语法")
 	gologger.Print().Msgf("  -f, --file string      从本地文件读取quake语法")
 	gologger.Print().Msgf("  -o, --output string    输出-f参数结果到csv文件,默认输出quake.csv")
+	gologger.Print().Msgf("  -c int                 -f参数的并发数，默认为1")
+	gologger.Print().Msgf("  --resume               从检查点继续上次中断的-f批量查询")
+	gologger.Print().Msgf("  -db string             将结果写入SQLite资产库，如 ~/.mto/assets.db")
+	gologger.Print().Msgf("  --proxy string         请求使用的代理，支持 http:// 和 socks5://")
+	gologger.Print().Msgf("  --user-agent string    请求使用的User-Agent")
+	gologger.Print().Msgf("  --diff-against string  -f批量查询完成后与上次的输出文件比较")
+	gologger.Print().Msgf("  --dry-run              只估算每条查询的结果数量、请求数和额度消耗，不下载数据")
 	gologger.Print().Msgf("  -u, --url              过滤输出url信息")
 	gologger.Print().Msgf("  -ip                    过滤输出ip信息")
+	gologger.Print().Msgf("  -json                  以JSON数组输出全部字段")
+	gologger.Print().Msgf("  -jsonl                 以JSON Lines输出全部字段(每行一个资产)")
 	gologger.Print().Msgf("  -m, --month int        查询月份范围(0:近一年的, 1:一个月, 2:两个月, 3:三月（默认）)")
 	gologger.Print().Msgf("  -k, --k                查询quake语法")
 	gologger.Print().Msgf("  -h, --help             显示帮助信息")
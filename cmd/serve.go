@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/yaxigin/mto/pkg/engine"
+	"github.com/yaxigin/mto/pkg/server"
+
+	"github.com/projectdiscovery/gologger"
+)
+
+// executeServeCommand 启动HTTP API服务，收到退出信号后等待进行中的请求完成
+func executeServeCommand(ctx context.Context, options *Tian) {
+	if options.Token == "" {
+		gologger.Warning().Msgf("未设置 -token，任何能访问 %s 的客户端都可以使用配置的API密钥查询", options.Listen)
+	}
+
+	srv := &http.Server{
+		Addr: options.Listen,
+		Handler: server.New(server.Config{
+			Token:    options.Token,
+			MaxLimit: options.MaxLimit,
+			NewEngine: func(name string) (engine.Engine, error) {
+				return newEngine(options, name)
+			},
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		gologger.Info().Msgf("正在关闭服务，等待进行中的请求完成")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			gologger.Warning().Msgf("关闭服务失败: %v", err)
+		}
+	}()
+
+	gologger.Info().Msgf("HTTP API 服务已启动: http://%s", options.Listen)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		gologger.Fatal().Msgf("启动服务失败: %v", err)
+	}
+	// Shutdown 调用后 ListenAndServe 立即返回，需要等待进行中的请求完成
+	<-done
+	gologger.Info().Msgf("服务已退出")
+}
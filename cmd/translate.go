@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/yaxigin/mto/pkg/query"
+
+	"github.com/projectdiscovery/gologger"
+)
+
+// executeTranslateCommand 在不同引擎的查询语法之间转换
+func executeTranslateCommand(_ context.Context, options *Tian) {
+	src := options.Query
+	if src == "" {
+		src = strings.Join(options.Args, " ")
+	}
+	if src == "" {
+		gologger.Fatal().Msgf("请指定需要转换的查询语句，如: mto translate --from fofa --to quake 'title=\"login\"'")
+	}
+
+	from, err := query.ParseDialect(options.From)
+	if err != nil {
+		gologger.Fatal().Msgf("%v", err)
+	}
+	to, err := query.ParseDialect(options.To)
+	if err != nil {
+		gologger.Fatal().Msgf("请使用 --to 指定目标语法(fofa/hunter/quake)")
+	}
+
+	t, err := query.Translate(src, from, to)
+	if err != nil {
+		var unsupported *query.UnsupportedError
+		if errors.As(err, &unsupported) {
+			gologger.Error().Msgf("以下条件无法转换到 %s:", to)
+			for _, item := range unsupported.Items {
+				gologger.Error().Msgf("  %s", item)
+			}
+			os.Exit(1)
+		}
+		gologger.Fatal().Msgf("%v", err)
+	}
+
+	for _, w := range t.Warnings {
+		gologger.Warning().Msgf("%s", w)
+	}
+	fmt.Println(t.Query)
+}
@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/yaxigin/mto/pkg/asset"
+	"github.com/yaxigin/mto/pkg/engine"
+	"github.com/yaxigin/mto/pkg/notify"
+	"github.com/yaxigin/mto/pkg/output"
+	"github.com/yaxigin/mto/pkg/store"
+	"github.com/yaxigin/mto/pkg/watch"
+
+	"github.com/projectdiscovery/gologger"
+)
+
+// watchJob 一个引擎上的一条查询
+type watchJob struct {
+	label string
+	eng   engine.Engine
+	query string
+}
+
+// watchJobs 将查询文件展开为各引擎上的查询
+func watchJobs(entries []watch.Entry, options *Tian) []watchJob {
+	var jobs []watchJob
+	for _, e := range entries {
+		if e.Engine != "" {
+			if eng := watchEngine(options, e, e.Engine); eng != nil {
+				jobs = append(jobs, watchJob{label: e.Label(), eng: eng, query: e.Query})
+			}
+			continue
+		}
+
+		names := e.Engines
+		if len(names) == 0 {
+			names = engine.Names()
+		}
+		for _, name := range names {
+			eng := watchEngine(options, e, name)
+			if eng == nil {
+				continue
+			}
+			q, err := portableQuery(name, e.Query)
+			if err != nil {
+				gologger.Warning().Msgf("%s: 跳过 %s: %v", e.Label(), name, err)
+				continue
+			}
+			jobs = append(jobs, watchJob{label: e.Label(), eng: eng, query: q})
+		}
+	}
+	return jobs
+}
+
+// watchEngine 创建查询使用的引擎，引擎不存在时退出，未配置API密钥时跳过
+func watchEngine(options *Tian, e watch.Entry, name string) engine.Engine {
+	if !engine.Has(name) {
+		gologger.Fatal().Msgf("%s: 未知引擎 %s", e.Label(), name)
+	}
+	eng, err := newEngine(options, name)
+	if err != nil {
+		gologger.Warning().Msgf("%s: 跳过 %s: %v", e.Label(), name, err)
+		return nil
+	}
+	return eng
+}
+
+// executeWatchCommand 按固定间隔重复执行查询文件，只输出新发现的资产
+func executeWatchCommand(ctx context.Context, options *Tian) {
+	if options.Local == "" {
+		gologger.Fatal().Msgf("请使用 -f 指定查询文件，如: mto watch -f queries.yml --every 6h")
+	}
+
+	entries, err := watch.Load(options.Local)
+	if err != nil {
+		gologger.Fatal().Msgf("%v", err)
+	}
+	jobs := watchJobs(entries, options)
+	if len(jobs) == 0 {
+		gologger.Fatal().Msgf("没有可以执行的查询")
+	}
+
+	statePath := options.State
+	if statePath == "" {
+		statePath = watch.StatePath(options.Local)
+	}
+	state, err := watch.LoadState(statePath)
+	if err != nil {
+		gologger.Fatal().Msgf("%v", err)
+	}
+	gologger.Info().Msgf("状态文件: %s，已记录 %d 条资产", statePath, len(state.Seen))
+
+	var writer output.Writer
+	if options.Output != "" {
+		// 状态文件保存了已输出的资产，输出文件同样跨多次运行追加
+		writer, err = output.NewWriter(outputFormat(options), options.Output, true)
+		if err != nil {
+			gologger.Fatal().Msgf("%v", err)
+		}
+		defer writer.Close()
+	}
+
+	db := openStore(options)
+	if db != nil {
+		defer db.Close()
+	}
+
+	notifier := openNotifier()
+
+	for cycle := 1; ; cycle++ {
+		gologger.Info().Msgf("第 %d 轮监控开始，共 %d 条查询", cycle, len(jobs))
+		found := runWatchCycle(ctx, jobs, state, writer, db, options)
+		gologger.Info().Msgf("第 %d 轮监控结束，发现 %d 条新资产", cycle, len(found))
+
+		notifyCtx, cancel := notify.SendContext(ctx)
+		err := notifier.Notify(notifyCtx, notify.Event{
+			Title:   fmt.Sprintf("mto watch 第 %d 轮发现新资产", cycle),
+			Source:  options.Local,
+			Summary: []string{fmt.Sprintf("查询 %d 条，新资产 %d 条", len(jobs), len(found))},
+			Assets:  found,
+		})
+		cancel()
+		if err != nil {
+			gologger.Warning().Msgf("发送通知失败: %v", err)
+		}
+
+		if ctx.Err() != nil || options.Every <= 0 {
+			break
+		}
+
+		next := time.Now().Add(options.Every)
+		gologger.Info().Msgf("下一轮开始时间: %s", next.Format(time.DateTime))
+		timer := time.NewTimer(options.Every)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	gologger.Info().Msgf("监控已退出，状态已保存到 %s", statePath)
+}
+
+// runWatchCycle 执行一轮查询，返回新发现的资产
+func runWatchCycle(ctx context.Context, jobs []watchJob, state *watch.State, writer output.Writer, db *store.Store, options *Tian) []asset.Asset {
+	opts := engine.Options{
+		Months:     options.Months,
+		MaxResults: options.MaxResults,
+		UseNext:    options.UseNext,
+		NoLint:     options.NoLint,
+	}
+
+	// 密钥无效或额度不足的引擎在本轮剩余的查询中跳过
+	disabled := make(map[string]error)
+
+	var found []asset.Asset
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+		if err, ok := disabled[job.eng.Name()]; ok {
+			gologger.Warning().Msgf("[%s] %s: 跳过: %v", job.eng.Name(), job.label, err)
+			continue
+		}
+
+		gologger.Info().Msgf("[%s] %s: %s", job.eng.Name(), job.label, job.query)
+		assets, err := job.eng.Search(ctx, job.query, opts)
+		if err != nil {
+			// 中途失败或被中断时仍然处理已获取的结果
+			gologger.Warning().Msgf("[%s] %s 查询失败: %v", job.eng.Name(), job.label, err)
+			if errors.Is(err, engine.ErrAuth) || errors.Is(err, engine.ErrQuota) {
+				disabled[job.eng.Name()] = err
+			}
+			if len(assets) == 0 {
+				continue
+			}
+		}
+		saveToStore(db, assets, job.query)
+
+		fresh := state.Filter(assets)
+		gologger.Info().Msgf("[%s] %s: 获取到 %d 条结果，其中 %d 条为新资产", job.eng.Name(), job.label, len(assets), len(fresh))
+		if len(fresh) == 0 {
+			continue
+		}
+		found = append(found, fresh...)
+
+		output.Print(os.Stdout, fresh, printMode(options))
+		if writer != nil {
+			if err := writer.Write(fresh, job.query); err != nil {
+				gologger.Warning().Msgf("写入数据失败: %v", err)
+			}
+		}
+		// 每条查询后保存状态，中途退出时已输出的资产不会在下次重复输出
+		if err := state.Save(); err != nil {
+			gologger.Warning().Msgf("%v", err)
+		}
+	}
+
+	now := time.Now()
+	state.LastRun = &now
+	if err := state.Save(); err != nil {
+		gologger.Warning().Msgf("%v", err)
+	}
+	return found
+}
@@ -1,7 +1,13 @@
 package main
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/yaxigin/mto/cmd"
+	"github.com/yaxigin/mto/pkg/config"
 
 	"github.com/projectdiscovery/gologger"
 
@@ -12,7 +18,9 @@ func main() {
 
 	// 初始化日志配置
 
-	gologger.DefaultLogger.SetMaxLevel(levels.LevelInfo)
+	// gologger 中 Warning 的级别低于 Info，设置为 LevelWarning 才会输出警告（如中断位置、查询检查）
+
+	gologger.DefaultLogger.SetMaxLevel(levels.LevelWarning)
 
 	// 创建配置实例
 
@@ -22,8 +30,31 @@ func main() {
 
 	cmd.ROO(info)
 
+	// 确保配置文件存在，--config 优先于环境变量 MTO_CONFIG 和默认路径
+
+	if info.Config != "" {
+		config.SetPath(info.Config)
+	}
+	created, err := config.EnsureConfig()
+	if err != nil {
+		gologger.Fatal().Msgf("初始化配置失败: %v", err)
+	}
+	if created {
+		gologger.Info().Msgf("已创建默认配置文件 %s，请在其中设置各引擎的API密钥", config.GetConfigPath())
+	}
+
+	// 收到 Ctrl-C 时取消查询，写入已获取的结果后退出，再次按 Ctrl-C 立即退出
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+		gologger.Warning().Msgf("收到中断信号，正在保存已获取的结果，再次按 Ctrl-C 立即退出")
+	}()
+
 	// 处理命令行参数
 
-	cmd.Parse(info)
+	cmd.Parse(ctx, info)
 
 }
@@ -0,0 +1,173 @@
+package asset
+
+import (
+	"encoding/json"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Asset 统一的资产结构，各引擎的响应都会映射到该结构
+type Asset struct {
+	IP         string          `json:"ip"`
+	Port       int             `json:"port,omitempty"`
+	Domain     string          `json:"domain,omitempty"`
+	Host       string          `json:"host,omitempty"` // 主机地址（域名或IP加端口）
+	URL        string          `json:"url,omitempty"`
+	Title      string          `json:"title,omitempty"`
+	Server     string          `json:"server,omitempty"`
+	Protocol   string          `json:"protocol,omitempty"`
+	ICP        string          `json:"icp,omitempty"`
+	Org        string          `json:"org,omitempty"` // 所属组织或备案单位
+	ISP        string          `json:"isp,omitempty"`
+	Country    string          `json:"country,omitempty"`
+	Components []string        `json:"components,omitempty"`
+	Source     string          `json:"source"`            // 返回该资产的引擎
+	Sources    []string        `json:"sources,omitempty"` // 合并多个引擎结果时，返回该资产的全部引擎
+	FirstSeen  *time.Time      `json:"first_seen,omitempty"`
+	LastSeen   *time.Time      `json:"last_seen,omitempty"`
+	Raw        json.RawMessage `json:"raw,omitempty"` // 引擎返回的原始数据
+}
+
+// Key 返回资产的唯一标识，与资产库的 ip/port/host 主键一致
+// 同一 ip:port 上的不同虚拟主机是不同的资产，形如 1.1.1.1:443/a.com；没有 ip:port 时使用主机地址或URL
+func (a Asset) Key() string {
+	if a.IP != "" && a.Port != 0 {
+		key := net.JoinHostPort(a.IP, strconv.Itoa(a.Port))
+		if host := a.vhost(); host != "" {
+			key += "/" + host
+		}
+		return key
+	}
+	if a.Host != "" {
+		return a.Host
+	}
+	if a.URL != "" {
+		return a.URL
+	}
+	return a.IP
+}
+
+// vhost 返回小写的主机名，不含端口，没有主机地址时从URL中获取；主机名就是IP时返回空字符串
+func (a Asset) vhost() string {
+	host := a.Host
+	if host == "" {
+		host = HostFromURL(a.URL)
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	if host == a.IP {
+		return ""
+	}
+	return host
+}
+
+// Engines 返回该资产的全部来源引擎
+func (a Asset) Engines() []string {
+	if len(a.Sources) > 0 {
+		return a.Sources
+	}
+	if a.Source != "" {
+		return []string{a.Source}
+	}
+	return nil
+}
+
+// PortString 返回字符串形式的端口，端口未知时返回空字符串
+func (a Asset) PortString() string {
+	if a.Port == 0 {
+		return ""
+	}
+	return strconv.Itoa(a.Port)
+}
+
+// Dedupe 按 Key 去重，保留第一次出现的资产
+func Dedupe(assets []Asset) []Asset {
+	seen := make(map[string]bool)
+	var result []Asset
+
+	for _, a := range assets {
+		key := a.Key()
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, a)
+	}
+	return result
+}
+
+// UniqueURLs 返回去重后的URL列表
+func UniqueURLs(assets []Asset) []string {
+	seen := make(map[string]bool)
+	var urls []string
+
+	for _, a := range assets {
+		if a.URL != "" && !seen[a.URL] {
+			seen[a.URL] = true
+			urls = append(urls, a.URL)
+		}
+	}
+	return urls
+}
+
+// UniqueIPs 返回去重后的IP列表
+func UniqueIPs(assets []Asset) []string {
+	seen := make(map[string]bool)
+	var ips []string
+
+	for _, a := range assets {
+		if a.IP != "" && !seen[a.IP] {
+			seen[a.IP] = true
+			ips = append(ips, a.IP)
+		}
+	}
+	return ips
+}
+
+// ParsePort 解析端口字符串，无法解析时返回0
+func ParsePort(s string) int {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0
+	}
+	return port
+}
+
+// HostFromURL 从URL中提取主机地址（包含端口）
+func HostFromURL(rawURL string) string {
+	if rawURL == "" {
+		return ""
+	}
+	if !strings.Contains(rawURL, "://") {
+		return rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+// 引擎返回的常见时间格式
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05.000Z",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// ParseTime 按常见格式解析时间，无法解析时返回 nil
+func ParseTime(s string) *time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package asset
+
+import "testing"
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name string
+		a    Asset
+		want string
+	}{
+		{"只有ip和端口", Asset{IP: "1.1.1.1", Port: 80}, "1.1.1.1:80"},
+		{"主机就是ip", Asset{IP: "1.1.1.1", Port: 80, Host: "1.1.1.1:80"}, "1.1.1.1:80"},
+		{"虚拟主机", Asset{IP: "1.1.1.1", Port: 443, Host: "a.com"}, "1.1.1.1:443/a.com"},
+		{"主机带端口", Asset{IP: "1.1.1.1", Port: 8443, Host: "a.com:8443"}, "1.1.1.1:8443/a.com"},
+		{"主机大小写", Asset{IP: "1.1.1.1", Port: 443, Host: "A.com"}, "1.1.1.1:443/a.com"},
+		{"从URL获取主机", Asset{IP: "1.1.1.1", Port: 443, URL: "https://b.com"}, "1.1.1.1:443/b.com"},
+		{"ipv6", Asset{IP: "::1", Port: 80, Host: "[::1]:80"}, "[::1]:80"},
+		{"没有端口", Asset{IP: "1.1.1.1", Host: "a.com"}, "a.com"},
+		{"只有URL", Asset{URL: "https://a.com/x"}, "https://a.com/x"},
+		{"只有ip", Asset{IP: "1.1.1.1"}, "1.1.1.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Key(); got != tt.want {
+				t.Errorf("Key() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDedupeVhosts(t *testing.T) {
+	assets := []Asset{
+		{IP: "1.1.1.1", Port: 443, Host: "a.com"},
+		{IP: "1.1.1.1", Port: 443, Host: "b.com"},
+		{IP: "1.1.1.1", Port: 443, Host: "a.com:443"},
+	}
+	if got := Dedupe(assets); len(got) != 2 {
+		t.Errorf("Dedupe() = %d assets, want 2", len(got))
+	}
+	if got := Merge(assets); len(got) != 2 {
+		t.Errorf("Merge() = %d assets, want 2", len(got))
+	}
+}
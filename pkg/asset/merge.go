@@ -0,0 +1,66 @@
+package asset
+
+import "slices"
+
+// Merge 合并多个引擎的结果，按 Key 去重
+// 同一资产被多个引擎返回时，空字段由后出现的结果补全，Sources 记录全部来源引擎
+func Merge(groups ...[]Asset) []Asset {
+	index := make(map[string]int)
+	var merged []Asset
+
+	for _, group := range groups {
+		for _, a := range group {
+			key := a.Key()
+			if key == "" {
+				continue
+			}
+
+			i, ok := index[key]
+			if !ok {
+				a.Sources = slices.Clone(a.Engines())
+				index[key] = len(merged)
+				merged = append(merged, a)
+				continue
+			}
+
+			fillEmpty(&merged[i], a)
+			for _, source := range a.Engines() {
+				if !slices.Contains(merged[i].Sources, source) {
+					merged[i].Sources = append(merged[i].Sources, source)
+				}
+			}
+		}
+	}
+	return merged
+}
+
+// fillEmpty 用 src 中的非空字段补全 dst 的空字段
+func fillEmpty(dst *Asset, src Asset) {
+	fill := func(d *string, s string) {
+		if *d == "" {
+			*d = s
+		}
+	}
+	fill(&dst.Domain, src.Domain)
+	fill(&dst.Host, src.Host)
+	fill(&dst.URL, src.URL)
+	fill(&dst.Title, src.Title)
+	fill(&dst.Server, src.Server)
+	fill(&dst.Protocol, src.Protocol)
+	fill(&dst.ICP, src.ICP)
+	fill(&dst.Org, src.Org)
+	fill(&dst.ISP, src.ISP)
+	fill(&dst.Country, src.Country)
+
+	for _, c := range src.Components {
+		if !slices.Contains(dst.Components, c) {
+			dst.Components = append(dst.Components, c)
+		}
+	}
+	if src.FirstSeen != nil && (dst.FirstSeen == nil || src.FirstSeen.Before(*dst.FirstSeen)) {
+		dst.FirstSeen = src.FirstSeen
+	}
+	if src.LastSeen != nil && (dst.LastSeen == nil || src.LastSeen.After(*dst.LastSeen)) {
+		dst.LastSeen = src.LastSeen
+	}
+}
@@ -4,58 +4,380 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/yaxigin/mto/pkg/engine"
+	"github.com/yaxigin/mto/pkg/httpclient"
+	"github.com/yaxigin/mto/pkg/ratelimit"
+
+	"gopkg.in/yaml.v3"
 )
 
 var (
-	ConfigDir  string
-	ConfigPath string
+	ConfigDir  string // 默认配置目录 ~/.mto，资产库等数据文件也放在这里
+	ConfigPath string // 使用的配置文件，默认为 ~/.mto/config.yml
 )
 
+// init 只计算配置路径，不创建任何文件，创建默认配置由命令行调用 EnsureConfig 完成
 func init() {
-	// 获取用户主目录
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		panic(fmt.Sprintf("无法获取用户主目录: %v", err))
+	// 获取用户主目录，失败时默认路径为空，可以通过 MTO_CONFIG 或 --config 指定配置文件
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		ConfigDir = filepath.Join(homeDir, ".mto")
+		ConfigPath = filepath.Join(ConfigDir, "config.yml")
 	}
-
-	// 设置配置目录和文件路径
-	ConfigDir = filepath.Join(homeDir, ".mto")
-	ConfigPath = filepath.Join(ConfigDir, "config.yml")
-
-	// 确保配置目录和文件存在
-	if err := EnsureConfig(); err != nil {
-		panic(fmt.Sprintf("初始化配置失败: %v", err))
+	if path := os.Getenv("MTO_CONFIG"); path != "" {
+		ConfigPath = path
 	}
 }
 
-// EnsureConfig 确保配置目录和文件存在
-func EnsureConfig() error {
-	// 创建配置目录（如果不存在）
-	if err := os.MkdirAll(ConfigDir, 0755); err != nil {
-		return fmt.Errorf("创建配置目录失败: %w", err)
-	}
-
-	// 检查配置文件是否存在
-	if _, err := os.Stat(ConfigPath); os.IsNotExist(err) {
-		// 创建默认配置文件
-		defaultConfig := []byte(`# MTO默认配置文件
+// defaultConfig 默认配置文件的内容
+const defaultConfig = `# MTO默认配置文件
+# 环境变量优先于配置文件，如 MTO_FOFA_KEY、MTO_HUNTER_KEY（多个密钥用逗号分隔）、MTO_HTTP_PROXY
 fofa:
-  email: ""
   key: ""
 hunter:
   key: ""
 quake:
   key: ""
-`)
-		if err := os.WriteFile(ConfigPath, defaultConfig, 0644); err != nil {
-			return fmt.Errorf("创建默认配置文件失败: %w", err)
-		}
+# 同一引擎有多个账号时使用 keys，额度不足或密钥无效时自动切换到下一个
+# key_policy 可选 round-robin(依次轮换) 或 most-quota(优先使用剩余额度最多的密钥)
+# hunter:
+#   keys: ["key1", "key2"]
+#   key_policy: most-quota
+# 请求限速，interval 为同一密钥两次请求的最小间隔，engine_interval 为全部密钥合计的最小间隔（默认按密钥数量计算）
+# hunter:
+#   rate:
+#     interval: 2s
+#     burst: 1
+#     engine_interval: 1s
+# 请求引擎API使用的HTTP配置，各引擎下也可以单独配置 http 覆盖全局配置
+# http:
+#   proxy: socks5://127.0.0.1:1080
+#   timeout: 60s
+#   ca_cert: ""
+#   insecure: false
+#   user_agent: ""
+# 批量查询和 watch 发现资产后发送通知，type 可选 webhook、dingtalk、feishu、wecom
+# notify:
+#   batch_size: 20
+#   max_messages: 5
+#   targets:
+#     - type: dingtalk
+#       url: https://oapi.dingtalk.com/robot/send?access_token=xxx
+#       secret: SECxxx
+# 使用 mto config encrypt 加密配置文件中的API密钥和通知目标的地址、加签密钥，之后通过密码或密钥文件解锁
+`
+
+// EnsureConfig 确保配置文件存在，不存在时创建默认配置文件并返回 true
+func EnsureConfig() (bool, error) {
+	if ConfigPath == "" {
+		return false, fmt.Errorf("无法获取用户主目录，请使用 --config 或环境变量 MTO_CONFIG 指定配置文件")
 	}
 
-	return nil
+	// 检查配置文件是否存在
+	if _, err := os.Stat(ConfigPath); !os.IsNotExist(err) {
+		return false, nil
+	}
+
+	// 创建配置目录（如果不存在），配置文件中有API密钥，只允许当前用户读写
+	if err := os.MkdirAll(filepath.Dir(ConfigPath), 0700); err != nil {
+		return false, fmt.Errorf("创建配置目录失败: %w", err)
+	}
+	if err := os.WriteFile(ConfigPath, []byte(defaultConfig), 0600); err != nil {
+		return false, fmt.Errorf("创建默认配置文件失败: %w", err)
+	}
+	return true, nil
 }
 
 // GetConfigPath 获取配置文件路径
 func GetConfigPath() string {
 	return ConfigPath
-} 
+}
+
+// SetPath 使用指定的配置文件，之后的 Load 重新读取
+func SetPath(path string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	ConfigPath = path
+	loaded = nil
+}
+
+// engineNames 配置文件中可以配置的引擎，与各引擎包注册的名称一致
+// 配置包不依赖引擎的注册顺序，没有导入引擎包时同样读取环境变量和校验配置
+var engineNames = []string{"fofa", "hunter", "quake"}
+
+// isEngine 判断配置项是否为引擎
+func isEngine(name string) bool {
+	return slices.Contains(engineNames, name)
+}
+
+// EngineConfig 单个引擎的配置
+type EngineConfig struct {
+	Key       string            `yaml:"key"`
+	Keys      []string          `yaml:"keys"`       // 多个密钥，与 key 合并使用
+	KeyPolicy string            `yaml:"key_policy"` // 多个密钥时的选择策略
+	HTTP      httpclient.Config `yaml:"http"`       // 覆盖全局 http 配置
+	Rate      ratelimit.Config  `yaml:"rate"`       // 请求限速，未配置时使用引擎的默认值
+
+	// Unknown 未知的字段，只用于提示
+	Unknown map[string]any `yaml:",inline"`
+}
+
+// Config 配置文件的内容，环境变量已经覆盖到对应字段
+type Config struct {
+	HTTP    httpclient.Config        `yaml:"http"`
+	Engines map[string]*EngineConfig `yaml:",inline"`
+
+	// Notify 由 notify 包通过 Unmarshal 解析，这里只用于避免被当作引擎配置
+	Notify any `yaml:"notify"`
+	// Encryption 加密API密钥的参数，未加密时为空
+	Encryption *Encryption `yaml:"encryption"`
+
+	raw       []byte
+	warnings  []string
+	plainKeys bool // 配置文件中有未加密的API密钥
+}
+
+var (
+	mu     sync.Mutex
+	loaded *Config
+)
+
+// Load 读取配置文件并校验，同一进程中只读取一次
+func Load() (*Config, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if loaded != nil {
+		return loaded, nil
+	}
+	if ConfigPath == "" {
+		return nil, fmt.Errorf("无法获取用户主目录，请使用 --config 或环境变量 MTO_CONFIG 指定配置文件")
+	}
+
+	content, err := os.ReadFile(ConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("配置文件读取错误: %v", err)
+	}
+	c, err := Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("配置文件 %s: %v", ConfigPath, err)
+	}
+
+	// Windows 的文件权限不使用 Unix 权限位，不检查
+	if info, err := os.Stat(ConfigPath); err == nil && runtime.GOOS != "windows" && c.plainKeys && info.Mode().Perm()&0077 != 0 {
+		c.warnings = append(c.warnings, fmt.Sprintf("权限为 %04o，其他用户可以读取其中的API密钥，建议执行 chmod 600 %s 或使用 mto config encrypt 加密", info.Mode().Perm(), ConfigPath))
+	}
+	loaded = c
+	return c, nil
+}
+
+// Parse 解析配置内容，使用环境变量覆盖后校验
+func Parse(content []byte) (*Config, error) {
+	c := &Config{raw: content}
+	if err := yaml.Unmarshal(content, c); err != nil {
+		return nil, fmt.Errorf("解析出错: %v", err)
+	}
+	if c.Engines == nil {
+		c.Engines = make(map[string]*EngineConfig)
+	}
+	for _, e := range c.Engines {
+		if e == nil {
+			continue
+		}
+		for _, key := range append([]string{e.Key}, e.Keys...) {
+			if key != "" && !IsEncrypted(key) {
+				c.plainKeys = true
+			}
+		}
+	}
+	c.applyEnv()
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// applyEnv 使用环境变量覆盖配置，如 MTO_FOFA_KEY、MTO_FOFA_KEY_POLICY、MTO_HTTP_PROXY
+func (c *Config) applyEnv() {
+	for _, name := range engineNames {
+		prefix := "MTO_" + strings.ToUpper(name) + "_"
+		e := c.Engines[name]
+		if e == nil {
+			e = &EngineConfig{}
+		}
+
+		if v := os.Getenv(prefix + "KEY"); v != "" {
+			// 环境变量中的密钥替换配置文件中的全部密钥
+			e.Key, e.Keys = "", nil
+			for _, key := range strings.Split(v, ",") {
+				e.Keys = append(e.Keys, strings.TrimSpace(key))
+			}
+		}
+		if v := os.Getenv(prefix + "KEY_POLICY"); v != "" {
+			e.KeyPolicy = v
+		}
+		if e.Key != "" || len(e.Keys) > 0 || c.Engines[name] != nil {
+			c.Engines[name] = e
+		}
+	}
+
+	if v := os.Getenv("MTO_HTTP_PROXY"); v != "" {
+		c.HTTP.Proxy = v
+	}
+}
+
+// validate 检查配置的取值，可以忽略的问题记录为警告
+func (c *Config) validate() error {
+	if err := validateHTTP("http", c.HTTP); err != nil {
+		return err
+	}
+	if c.Encryption != nil {
+		if err := c.Encryption.validate(); err != nil {
+			return err
+		}
+	}
+
+	names := make([]string, 0, len(c.Engines))
+	for name := range c.Engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		e := c.Engines[name]
+		if !isEngine(name) {
+			c.warnings = append(c.warnings, fmt.Sprintf("未知的配置项 %s，可选的引擎: %s", name, strings.Join(engineNames, "、")))
+			continue
+		}
+		if e == nil {
+			continue
+		}
+		if c.Encryption == nil {
+			for _, key := range append([]string{e.Key}, e.Keys...) {
+				if IsEncrypted(key) {
+					return fmt.Errorf("%s: API密钥已加密，但配置文件中缺少 encryption 部分", name)
+				}
+			}
+		}
+		if _, err := engine.ParseKeyPolicy(e.KeyPolicy); err != nil {
+			return fmt.Errorf("%s.key_policy: %v", name, err)
+		}
+		if err := validateHTTP(name+".http", e.HTTP); err != nil {
+			return err
+		}
+		if e.Rate.Interval < 0 || e.Rate.Burst < 0 || e.Rate.EngineInterval < 0 {
+			return fmt.Errorf("%s.rate: 不能为负数", name)
+		}
+
+		fields := make([]string, 0, len(e.Unknown))
+		for field := range e.Unknown {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			if field == "email" {
+				c.warnings = append(c.warnings, fmt.Sprintf("%s.email 已不再使用，可以删除", name))
+				continue
+			}
+			c.warnings = append(c.warnings, fmt.Sprintf("未知的配置项 %s.%s", name, field))
+		}
+	}
+	return nil
+}
+
+// validateHTTP 检查HTTP配置，section 为出错时提示的配置位置
+func validateHTTP(section string, h httpclient.Config) error {
+	if h.Proxy != "" {
+		if _, err := httpclient.ParseProxy(h.Proxy); err != nil {
+			return fmt.Errorf("%s.proxy: %v", section, err)
+		}
+	}
+	if h.Timeout < 0 {
+		return fmt.Errorf("%s.timeout: 不能为负数", section)
+	}
+	if h.CACert != "" {
+		if _, err := os.Stat(h.CACert); err != nil {
+			return fmt.Errorf("%s.ca_cert: %v", section, err)
+		}
+	}
+	return nil
+}
+
+// Warnings 返回配置中可以忽略的问题，如已不再使用的字段
+func (c *Config) Warnings() []string {
+	return c.warnings
+}
+
+// Engine 返回指定引擎的配置，未配置时返回零值
+func (c *Config) Engine(name string) EngineConfig {
+	if e := c.Engines[name]; e != nil {
+		return *e
+	}
+	return EngineConfig{}
+}
+
+// Keys 返回指定引擎的全部API密钥和密钥选择策略，未配置时返回错误
+// 加密的密钥在这里解密，第一次解密时需要密码或密钥文件
+func (c *Config) Keys(name string) ([]string, string, error) {
+	e := c.Engine(name)
+
+	// 去掉空值和重复的密钥，保持配置中的顺序
+	var keys []string
+	seen := make(map[string]bool)
+	for _, key := range append([]string{e.Key}, e.Keys...) {
+		if IsEncrypted(key) {
+			var err error
+			if key, err = c.decrypt(key); err != nil {
+				return nil, "", fmt.Errorf("%s API密钥: %v", name, err)
+			}
+		}
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, "", fmt.Errorf("%s API密钥未配置，请在配置文件 %s 中设置或使用环境变量 MTO_%s_KEY", name, ConfigPath, strings.ToUpper(name))
+	}
+	return keys, e.KeyPolicy, nil
+}
+
+// DecryptValue 解密其他包通过 Unmarshal 读取的值，如通知目标的 url 和 secret，未加密的值原样返回
+func (c *Config) DecryptValue(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	return c.decrypt(value)
+}
+
+// decrypt 解密一个API密钥
+func (c *Config) decrypt(value string) (string, error) {
+	if c.Encryption == nil {
+		return "", fmt.Errorf("配置文件中缺少 encryption 部分，无法解密")
+	}
+	key, err := c.Encryption.unlock()
+	if err != nil {
+		return "", err
+	}
+	return decrypt(key, value)
+}
+
+// HTTPConfig 返回指定引擎的HTTP客户端配置，引擎下 http 部分的非空字段覆盖全局 http 部分
+func (c *Config) HTTPConfig(name string) httpclient.Config {
+	return c.HTTP.Merge(c.Engine(name).HTTP)
+}
+
+// Unmarshal 将配置文件的内容解析到 v，用于其他包读取自己的配置部分
+func (c *Config) Unmarshal(v any) error {
+	if err := yaml.Unmarshal(c.raw, v); err != nil {
+		return fmt.Errorf("解析配置文件出错: %v", err)
+	}
+	return nil
+}
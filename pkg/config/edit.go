@@ -0,0 +1,439 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Get 读取配置文件中 path 对应的值，path 形如 fofa.key、http.proxy，不包含环境变量的覆盖
+// 不存在时第二个返回值为 false
+func Get(path string) (any, bool, error) {
+	doc, err := readNode()
+	if err != nil {
+		return nil, false, err
+	}
+
+	node := doc
+	for _, name := range splitPath(path) {
+		if node = child(node, name); node == nil {
+			return nil, false, nil
+		}
+	}
+
+	var v any
+	if err := node.Decode(&v); err != nil {
+		return nil, false, fmt.Errorf("解析 %s 失败: %v", path, err)
+	}
+	return v, true, nil
+}
+
+// Set 修改配置文件中 path 对应的值，保留文件中的注释和顺序，不存在的配置项会自动创建
+// value 按 YAML 解析，如 [key1, key2] 为列表；名称为 key 的配置项总是作为字符串保存
+// 配置文件已加密时，新设置的引擎密钥和通知目标的地址、加签密钥同样加密保存；修改后的配置校验失败时不写入文件
+func Set(path, value string) error {
+	names := splitPath(path)
+	if len(names) == 0 {
+		return fmt.Errorf("配置项不能为空")
+	}
+
+	doc, err := readNode()
+	if err != nil {
+		return err
+	}
+
+	var parsed yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &parsed); err != nil {
+		return fmt.Errorf("解析 %s 的值失败: %v", path, err)
+	}
+	val := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	if len(parsed.Content) > 0 && names[len(names)-1] != "key" {
+		val = parsed.Content[0]
+	}
+	if val.Kind == yaml.ScalarNode && val.Tag == "!!str" {
+		val.Style = yaml.DoubleQuotedStyle
+	}
+
+	// 逐级查找，不存在的层级创建为映射
+	node := doc
+	for _, name := range names[:len(names)-1] {
+		next := child(node, name)
+		if next == nil {
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, next)
+		} else if next.Kind != yaml.MappingNode {
+			// 如 fofa: 下没有任何内容时为空值，替换为映射
+			*next = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", HeadComment: next.HeadComment, LineComment: next.LineComment}
+		}
+		node = next
+	}
+	last := names[len(names)-1]
+	if old := child(node, last); old != nil {
+		val.HeadComment, val.LineComment, val.FootComment = old.HeadComment, old.LineComment, old.FootComment
+		*old = *val
+	} else {
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: last}, val)
+	}
+
+	if err := encryptSet(doc, child(node, last)); err != nil {
+		return err
+	}
+	return save(doc, 0)
+}
+
+// encryptSet 配置文件已加密时，加密 Set 修改的值中以明文保存的密钥，只修改其他配置项时不需要解锁
+func encryptSet(doc, set *yaml.Node) error {
+	e, err := readEncryption(doc)
+	if err != nil || e == nil {
+		return err
+	}
+	var plain []secret
+	for _, s := range secrets(doc) {
+		if !IsEncrypted(s.node.Value) && within(set, s.node) {
+			plain = append(plain, s)
+		}
+	}
+	if len(plain) == 0 {
+		return nil
+	}
+	key, err := e.unlock()
+	if err != nil {
+		return err
+	}
+	_, err = convertSecrets(plain, key, true)
+	return err
+}
+
+// Encrypt 加密配置文件中所有未加密的API密钥和通知目标的地址、加签密钥、请求头，返回加密的数量，加密后配置文件的权限为 0600
+// keyfile 不为空时使用密钥文件解锁，文件不存在时自动生成；否则使用环境变量 MTO_PASSPHRASE 或通过 Prompt 设置密码
+// 已经加密过的配置文件使用原来的密码或密钥文件，只加密之后以明文添加的密钥
+func Encrypt(keyfile string) (int, error) {
+	doc, err := readNode()
+	if err != nil {
+		return 0, err
+	}
+	e, err := readEncryption(doc)
+	if err != nil {
+		return 0, err
+	}
+
+	var key []byte
+	if e != nil {
+		if keyfile != "" {
+			return 0, fmt.Errorf("API密钥已经加密，更换密码或密钥文件请先执行 mto config decrypt")
+		}
+		if key, err = e.unlock(); err != nil {
+			return 0, err
+		}
+	} else {
+		var secret []byte
+		if keyfile != "" {
+			if keyfile, err = createKeyfile(keyfile); err != nil {
+				return 0, err
+			}
+			secret, err = readKeyfile(keyfile)
+		} else {
+			secret, err = newPassphrase()
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if e, err = newEncryption(keyfile); err != nil {
+			return 0, err
+		}
+		key = e.derive(secret)
+		if e.Check, err = encrypt(key, checkText); err != nil {
+			return 0, err
+		}
+
+		node := &yaml.Node{}
+		if err := node.Encode(e); err != nil {
+			return 0, fmt.Errorf("生成加密参数失败: %v", err)
+		}
+		name := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "encryption",
+			HeadComment: "API密钥的加密参数，删除后无法解密，使用 mto config decrypt 恢复为明文"}
+		doc.Content = append(doc.Content, name, node)
+
+		keyMu.Lock()
+		keys[e.Salt] = key
+		keyMu.Unlock()
+	}
+
+	n, err := convertSecrets(secrets(doc), key, true)
+	if err != nil {
+		return 0, err
+	}
+	return n, save(doc, 0600)
+}
+
+// Decrypt 将配置文件中加密的值恢复为明文并删除 encryption 部分，返回解密的数量
+func Decrypt() (int, error) {
+	doc, err := readNode()
+	if err != nil {
+		return 0, err
+	}
+	e, err := readEncryption(doc)
+	if err != nil {
+		return 0, err
+	}
+	if e == nil {
+		return 0, fmt.Errorf("配置文件中的API密钥没有加密")
+	}
+	key, err := e.unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := convertSecrets(secrets(doc), key, false)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if doc.Content[i].Value == "encryption" {
+			doc.Content = append(doc.Content[:i], doc.Content[i+2:]...)
+			break
+		}
+	}
+	return n, save(doc, 0)
+}
+
+// newPassphrase 读取新设置的密码，终端输入时需要输入两次
+func newPassphrase() ([]byte, error) {
+	if v := os.Getenv("MTO_PASSPHRASE"); v != "" {
+		return []byte(v), nil
+	}
+	if Prompt == nil {
+		return nil, fmt.Errorf("请设置环境变量 MTO_PASSPHRASE 或使用密钥文件")
+	}
+
+	v, err := Prompt("设置配置文件密码: ")
+	if err != nil {
+		return nil, err
+	}
+	if v == "" {
+		return nil, fmt.Errorf("密码不能为空")
+	}
+	again, err := Prompt("再次输入密码: ")
+	if err != nil {
+		return nil, err
+	}
+	if again != v {
+		return nil, fmt.Errorf("两次输入的密码不一致")
+	}
+	return []byte(v), nil
+}
+
+// readEncryption 读取配置文件中的 encryption 部分，没有时返回 nil
+func readEncryption(doc *yaml.Node) (*Encryption, error) {
+	node := child(doc, "encryption")
+	if node == nil {
+		return nil, nil
+	}
+	var e Encryption
+	if err := node.Decode(&e); err != nil {
+		return nil, fmt.Errorf("解析 encryption 失败: %v", err)
+	}
+	if err := e.validate(); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// secret 配置文件中需要加密的一个值
+type secret struct {
+	path string // 如 fofa.keys、notify.targets[0].url，用于错误提示
+	node *yaml.Node
+}
+
+// secrets 返回配置文件中需要加密的值：各引擎的 key 和 keys，通知目标的 url、secret 和 headers
+// 机器人的 url 中带有 access_token，通用 webhook 的 headers 中通常有认证信息
+func secrets(doc *yaml.Node) []secret {
+	var result []secret
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		name := doc.Content[i].Value
+		if !isEngine(name) {
+			continue
+		}
+		for _, field := range []string{"key", "keys"} {
+			if node := child(doc.Content[i+1], field); node != nil {
+				result = appendScalars(result, name+"."+field, node)
+			}
+		}
+	}
+
+	targets := child(child(doc, "notify"), "targets")
+	if targets == nil || targets.Kind != yaml.SequenceNode {
+		return result
+	}
+	for i, t := range targets.Content {
+		for _, field := range []string{"url", "secret", "headers"} {
+			if node := child(t, field); node != nil {
+				result = appendScalars(result, fmt.Sprintf("notify.targets[%d].%s", i, field), node)
+			}
+		}
+	}
+	return result
+}
+
+// appendScalars 添加节点中的字符串，节点为字符串、字符串列表或值为字符串的映射，空值不添加
+func appendScalars(result []secret, path string, node *yaml.Node) []secret {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Value != "" && node.Tag != "!!null" {
+			result = append(result, secret{path, node})
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			result = appendScalars(result, fmt.Sprintf("%s[%d]", path, i), item)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			result = appendScalars(result, path+"."+node.Content[i].Value, node.Content[i+1])
+		}
+	}
+	return result
+}
+
+// convertSecrets 加密(enc 为 true)或解密全部值，加密时跳过已加密的值，解密时跳过未加密的值，返回修改的数量
+func convertSecrets(list []secret, key []byte, enc bool) (int, error) {
+	convert := decrypt
+	if enc {
+		convert = encrypt
+	}
+	total := 0
+	for _, s := range list {
+		if IsEncrypted(s.node.Value) == enc {
+			continue
+		}
+		out, err := convert(key, s.node.Value)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %v", s.path, err)
+		}
+		s.node.Value, s.node.Tag, s.node.Style = out, "!!str", yaml.DoubleQuotedStyle
+		total++
+	}
+	return total, nil
+}
+
+// within 判断 n 是否为 root 或 root 的子节点
+func within(root, n *yaml.Node) bool {
+	if root == n {
+		return true
+	}
+	for _, c := range root.Content {
+		if within(c, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// save 校验修改后的配置并写入文件，perm 为 0 时保留原文件的权限
+func save(doc *yaml.Node, perm os.FileMode) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("生成配置文件失败: %v", err)
+	}
+	if _, err := Parse(buf.Bytes()); err != nil {
+		return fmt.Errorf("修改后的配置无效: %v", err)
+	}
+	if err := writeFile(ConfigPath, buf.Bytes(), perm); err != nil {
+		return err
+	}
+
+	// 之后的 Load 读取修改后的文件
+	mu.Lock()
+	loaded = nil
+	mu.Unlock()
+	return nil
+}
+
+// splitPath 将 fofa.http.proxy 拆分为各级名称
+func splitPath(path string) []string {
+	var names []string
+	for _, name := range strings.Split(path, ".") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// readNode 读取配置文件，返回顶层的映射节点，文件为空时返回空映射
+func readNode() (*yaml.Node, error) {
+	if ConfigPath == "" {
+		return nil, fmt.Errorf("无法获取用户主目录，请使用 --config 或环境变量 MTO_CONFIG 指定配置文件")
+	}
+	content, err := os.ReadFile(ConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("配置文件读取错误: %v", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("配置文件 %s: 解析出错: %v", ConfigPath, err)
+	}
+	if len(doc.Content) == 0 {
+		// 只有注释的文件没有内容节点，保留注释并创建空映射
+		root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", HeadComment: doc.HeadComment, FootComment: doc.FootComment}
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("配置文件 %s: 顶层应为映射", ConfigPath)
+	}
+	return root, nil
+}
+
+// child 返回映射节点中名称为 name 的值节点，不存在时返回 nil
+func child(node *yaml.Node, name string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == name {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// writeFile 先写入临时文件再替换，避免中途出错时配置文件损坏
+// perm 为 0 时保留原文件的权限，新文件为 0600
+func writeFile(path string, content []byte, perm os.FileMode) error {
+	mode := perm
+	if mode == 0 {
+		mode = 0600
+		if info, err := os.Stat(path); err == nil {
+			mode = info.Mode().Perm()
+		}
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*.yml")
+	if err != nil {
+		return fmt.Errorf("写入配置文件失败: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入配置文件失败: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入配置文件失败: %v", err)
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("写入配置文件失败: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("写入配置文件失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// EncPrefix 加密后的API密钥的前缀，如 key: "enc:..."
+const EncPrefix = "enc:"
+
+// checkText 加密后保存在 encryption.check 中，用于验证密码是否正确
+const checkText = "mto"
+
+// Encryption 配置文件中 encryption 部分，记录加密API密钥使用的参数
+// 使用 argon2id 从密码或密钥文件派生密钥，XChaCha20-Poly1305 加密每个API密钥
+type Encryption struct {
+	KDF     string `yaml:"kdf"`               // 密钥派生算法，目前只支持 argon2id
+	Salt    string `yaml:"salt"`              // base64 编码的随机盐
+	Time    uint32 `yaml:"time"`              // argon2id 迭代次数
+	Memory  uint32 `yaml:"memory"`            // argon2id 内存大小(KiB)
+	Threads uint8  `yaml:"threads"`           // argon2id 并行度
+	Check   string `yaml:"check"`             // 加密的固定内容，用于验证密码是否正确
+	Keyfile string `yaml:"keyfile,omitempty"` // 使用密钥文件解锁时的文件路径，为空时使用密码
+}
+
+// Prompt 需要密码时调用，由命令行从终端读取，为空时只能使用环境变量 MTO_PASSPHRASE 或 MTO_KEYFILE
+var Prompt func(prompt string) (string, error)
+
+var (
+	keyMu sync.Mutex
+	keys  = make(map[string][]byte) // 已解锁的密钥，按 salt 缓存，同一进程中只输入一次密码
+)
+
+// IsEncrypted 判断值是否为加密后的API密钥
+func IsEncrypted(v string) bool {
+	return strings.HasPrefix(v, EncPrefix)
+}
+
+// newEncryption 生成新的加密参数，keyfile 为空时使用密码
+func newEncryption(keyfile string) (*Encryption, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("生成随机盐失败: %v", err)
+	}
+	return &Encryption{
+		KDF:     "argon2id",
+		Salt:    base64.StdEncoding.EncodeToString(salt),
+		Time:    3,
+		Memory:  64 * 1024,
+		Threads: 4,
+		Keyfile: keyfile,
+	}, nil
+}
+
+// validate 检查加密参数
+func (e *Encryption) validate() error {
+	if e.KDF != "argon2id" {
+		return fmt.Errorf("encryption.kdf: 不支持的密钥派生算法 %q，可选: argon2id", e.KDF)
+	}
+	if salt, err := base64.StdEncoding.DecodeString(e.Salt); err != nil || len(salt) < 16 {
+		return fmt.Errorf("encryption.salt: 应为至少16字节的 base64 编码")
+	}
+	if e.Time == 0 || e.Memory == 0 || e.Threads == 0 {
+		return fmt.Errorf("encryption: time、memory、threads 不能为 0")
+	}
+	if !IsEncrypted(e.Check) {
+		return fmt.Errorf("encryption.check: 缺少用于验证密码的内容")
+	}
+	return nil
+}
+
+// derive 使用 argon2id 从密码或密钥文件的内容派生密钥
+func (e *Encryption) derive(secret []byte) []byte {
+	salt, _ := base64.StdEncoding.DecodeString(e.Salt)
+	return argon2.IDKey(secret, salt, e.Time, e.Memory, e.Threads, chacha20poly1305.KeySize)
+}
+
+// unlock 获取解密API密钥使用的密钥，同一个 salt 只派生一次
+// 依次使用环境变量 MTO_KEYFILE、encryption.keyfile、环境变量 MTO_PASSPHRASE，都没有时通过 Prompt 输入密码
+func (e *Encryption) unlock() ([]byte, error) {
+	keyMu.Lock()
+	defer keyMu.Unlock()
+
+	if key, ok := keys[e.Salt]; ok {
+		return key, nil
+	}
+
+	secret, source, err := e.secret()
+	if err != nil {
+		return nil, err
+	}
+	key := e.derive(secret)
+	if text, err := decrypt(key, e.Check); err != nil || text != checkText {
+		return nil, fmt.Errorf("%s不正确，无法解密API密钥", source)
+	}
+	keys[e.Salt] = key
+	return key, nil
+}
+
+// secret 读取解锁使用的密钥文件内容或密码，同时返回来源的名称用于提示
+func (e *Encryption) secret() ([]byte, string, error) {
+	if path := os.Getenv("MTO_KEYFILE"); path != "" {
+		b, err := readKeyfile(path)
+		return b, "密钥文件", err
+	}
+	if e.Keyfile != "" {
+		b, err := readKeyfile(e.Keyfile)
+		return b, "密钥文件", err
+	}
+	if v := os.Getenv("MTO_PASSPHRASE"); v != "" {
+		return []byte(v), "环境变量 MTO_PASSPHRASE 中的密码", nil
+	}
+	if Prompt == nil {
+		return nil, "", fmt.Errorf("配置文件中的API密钥已加密，请设置环境变量 MTO_PASSPHRASE 或 MTO_KEYFILE")
+	}
+	v, err := Prompt("配置文件密码: ")
+	if err != nil {
+		return nil, "", err
+	}
+	if v == "" {
+		return nil, "", fmt.Errorf("密码不能为空")
+	}
+	return []byte(v), "密码", nil
+}
+
+// readKeyfile 读取密钥文件，忽略首尾空白
+func readKeyfile(path string) ([]byte, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取密钥文件失败: %v", err)
+	}
+	if b = bytes.TrimSpace(b); len(b) == 0 {
+		return nil, fmt.Errorf("密钥文件 %s 为空", path)
+	}
+	return b, nil
+}
+
+// createKeyfile 密钥文件不存在时生成 32 字节随机内容，权限为 0600，返回文件的绝对路径
+func createKeyfile(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("密钥文件路径错误: %v", err)
+	}
+	if _, err := os.Stat(abs); err == nil {
+		return abs, nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("读取密钥文件失败: %v", err)
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("生成密钥文件失败: %v", err)
+	}
+	if err := os.WriteFile(abs, []byte(hex.EncodeToString(b)+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("生成密钥文件失败: %v", err)
+	}
+	return abs, nil
+}
+
+// encrypt 使用 XChaCha20-Poly1305 加密，返回 enc: 加 base64(nonce + 密文)
+func encrypt(key []byte, text string) (string, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(text)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %v", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(text), nil)
+	return EncPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt 解密 encrypt 的结果，密钥不正确或内容被修改时返回错误
+func decrypt(key []byte, value string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, EncPrefix))
+	if err != nil {
+		return "", fmt.Errorf("加密内容格式错误: %v", err)
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("加密内容格式错误")
+	}
+	text, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("解密失败，密码不正确或内容已被修改")
+	}
+	return string(text), nil
+}
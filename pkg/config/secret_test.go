@@ -0,0 +1,291 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testConfig 包含引擎密钥、通知目标和不需要加密的配置项
+const testConfig = `http:
+  proxy: socks5://127.0.0.1:1080
+fofa:
+  key: fofa-key-1234
+hunter:
+  keys: [hunter-key-1, hunter-key-2]
+notify:
+  targets:
+    - type: dingtalk
+      url: https://oapi.dingtalk.com/robot/send?access_token=ding-token
+      secret: SECding
+    - type: webhook
+      url: https://example.com/hook
+      headers:
+        Authorization: Bearer hook-token
+`
+
+// plaintexts testConfig 中需要加密的值
+var plaintexts = []string{
+	"fofa-key-1234", "hunter-key-1", "hunter-key-2",
+	"ding-token", "SECding", "https://example.com/hook", "hook-token",
+}
+
+// setupConfig 在临时目录中写入配置文件并清空已解锁的密钥，结束后恢复原来的配置文件路径
+func setupConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	old, oldPrompt := ConfigPath, Prompt
+	SetPath(path)
+	Prompt = nil
+	resetKeys()
+	t.Cleanup(func() {
+		SetPath(old)
+		Prompt = oldPrompt
+		resetKeys()
+	})
+
+	for _, name := range []string{"MTO_KEYFILE", "MTO_PASSPHRASE", "MTO_FOFA_KEY", "MTO_HUNTER_KEY", "MTO_QUAKE_KEY"} {
+		t.Setenv(name, "")
+	}
+	return path
+}
+
+// resetKeys 清空已解锁的密钥，之后的解密重新读取密码或密钥文件
+func resetKeys() {
+	keyMu.Lock()
+	clear(keys)
+	keyMu.Unlock()
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	path := setupConfig(t, testConfig)
+	t.Setenv("MTO_PASSPHRASE", "correct horse")
+
+	n, err := Encrypt("")
+	if err != nil {
+		t.Fatalf("Encrypt() error: %v", err)
+	}
+	if n != len(plaintexts) {
+		t.Errorf("Encrypt() = %d, want %d", n, len(plaintexts))
+	}
+
+	content := readFile(t, path)
+	for _, s := range plaintexts {
+		if strings.Contains(content, s) {
+			t.Errorf("encrypted config still contains %q", s)
+		}
+	}
+	if !strings.Contains(content, "socks5://127.0.0.1:1080") {
+		t.Errorf("encrypted config lost http.proxy:\n%s", content)
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm() != 0600 {
+		t.Errorf("config permission = %04o, want 0600", info.Mode().Perm())
+	}
+
+	// 再次加密时没有新的明文密钥
+	if n, err := Encrypt(""); err != nil || n != 0 {
+		t.Errorf("Encrypt() again = %d, %v, want 0, nil", n, err)
+	}
+
+	// 重新派生密钥后可以解密
+	resetKeys()
+	SetPath(path)
+	c, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	keys, _, err := c.Keys("hunter")
+	if err != nil || strings.Join(keys, ",") != "hunter-key-1,hunter-key-2" {
+		t.Errorf("Keys(hunter) = %v, %v", keys, err)
+	}
+	var conf struct {
+		Notify struct {
+			Targets []struct {
+				Secret string `yaml:"secret"`
+			} `yaml:"targets"`
+		} `yaml:"notify"`
+	}
+	if err := c.Unmarshal(&conf); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := c.DecryptValue(conf.Notify.Targets[0].Secret); err != nil || s != "SECding" {
+		t.Errorf("DecryptValue(secret) = %q, %v, want SECding", s, err)
+	}
+
+	n, err = Decrypt()
+	if err != nil {
+		t.Fatalf("Decrypt() error: %v", err)
+	}
+	if n != len(plaintexts) {
+		t.Errorf("Decrypt() = %d, want %d", n, len(plaintexts))
+	}
+	content = readFile(t, path)
+	for _, s := range plaintexts {
+		if !strings.Contains(content, s) {
+			t.Errorf("decrypted config does not contain %q", s)
+		}
+	}
+	if strings.Contains(content, "encryption") || strings.Contains(content, EncPrefix) {
+		t.Errorf("decrypted config still encrypted:\n%s", content)
+	}
+}
+
+func TestEncryptWrongPassphrase(t *testing.T) {
+	path := setupConfig(t, testConfig)
+	t.Setenv("MTO_PASSPHRASE", "correct horse")
+	if _, err := Encrypt(""); err != nil {
+		t.Fatal(err)
+	}
+
+	resetKeys()
+	t.Setenv("MTO_PASSPHRASE", "wrong")
+	if _, err := Decrypt(); err == nil || !strings.Contains(err.Error(), "不正确") {
+		t.Errorf("Decrypt() with wrong passphrase error = %v", err)
+	}
+	if !strings.Contains(readFile(t, path), EncPrefix) {
+		t.Errorf("config changed after failed Decrypt")
+	}
+}
+
+func TestSetEncrypts(t *testing.T) {
+	path := setupConfig(t, testConfig)
+	t.Setenv("MTO_PASSPHRASE", "correct horse")
+	if _, err := Encrypt(""); err != nil {
+		t.Fatal(err)
+	}
+
+	// 修改不需要加密的配置项时不需要解锁
+	resetKeys()
+	t.Setenv("MTO_PASSPHRASE", "")
+	if err := Set("http.timeout", "30s"); err != nil {
+		t.Fatalf("Set(http.timeout) error: %v", err)
+	}
+
+	t.Setenv("MTO_PASSPHRASE", "correct horse")
+	if err := Set("quake.key", "quake-key-1234"); err != nil {
+		t.Fatalf("Set(quake.key) error: %v", err)
+	}
+	if err := Set("notify.targets", `[{type: wecom, url: "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=wecom-key"}]`); err != nil {
+		t.Fatalf("Set(notify.targets) error: %v", err)
+	}
+	content := readFile(t, path)
+	for _, s := range []string{"quake-key-1234", "wecom-key"} {
+		if strings.Contains(content, s) {
+			t.Errorf("Set stored %q in plaintext", s)
+		}
+	}
+
+	v, ok, err := Get("http.timeout")
+	if err != nil || !ok || v != "30s" {
+		t.Errorf("Get(http.timeout) = %v, %v, %v", v, ok, err)
+	}
+}
+
+func TestUnlockOrder(t *testing.T) {
+	dir := t.TempDir()
+	envKeyfile := filepath.Join(dir, "env.key")
+	confKeyfile := filepath.Join(dir, "conf.key")
+	for path, content := range map[string]string{envKeyfile: "env-keyfile\n", confKeyfile: "conf-keyfile\n"} {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		envKeyfile string
+		keyfile    string
+		passphrase string
+		prompt     string
+		want       string // 用于解锁的内容，为空时应返回错误
+	}{
+		{"MTO_KEYFILE优先", envKeyfile, confKeyfile, "env-pass", "prompt-pass", "env-keyfile"},
+		{"encryption.keyfile", "", confKeyfile, "env-pass", "prompt-pass", "conf-keyfile"},
+		{"MTO_PASSPHRASE", "", "", "env-pass", "prompt-pass", "env-pass"},
+		{"输入密码", "", "", "", "prompt-pass", "prompt-pass"},
+		{"没有可用的密码", "", "", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupConfig(t, "")
+			t.Setenv("MTO_KEYFILE", tt.envKeyfile)
+			t.Setenv("MTO_PASSPHRASE", tt.passphrase)
+			if tt.prompt != "" {
+				Prompt = func(string) (string, error) { return tt.prompt, nil }
+			}
+
+			e, err := newEncryption(tt.keyfile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e.Time, e.Memory, e.Threads = 1, 1024, 1
+			want := tt.want
+			if want == "" {
+				want = "unused"
+			}
+			if e.Check, err = encrypt(e.derive([]byte(want)), checkText); err != nil {
+				t.Fatal(err)
+			}
+
+			key, err := e.unlock()
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("unlock() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unlock() error: %v", err)
+			}
+
+			// 解锁后的密钥按 salt 缓存，之后不再读取密码
+			t.Setenv("MTO_KEYFILE", "")
+			t.Setenv("MTO_PASSPHRASE", "")
+			Prompt = nil
+			again, err := e.unlock()
+			if err != nil || string(again) != string(key) {
+				t.Errorf("cached unlock() = %v, want cached key", err)
+			}
+		})
+	}
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	key := make([]byte, 32)
+	for _, text := range []string{"", "a", "fofa-key-1234", "中文密钥", strings.Repeat("x", 1000)} {
+		enc, err := encrypt(key, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !IsEncrypted(enc) {
+			t.Errorf("encrypt(%q) = %q, want %s prefix", text, enc, EncPrefix)
+		}
+		if got, err := decrypt(key, enc); err != nil || got != text {
+			t.Errorf("decrypt(encrypt(%q)) = %q, %v", text, got, err)
+		}
+	}
+
+	enc, _ := encrypt(key, "secret")
+	other := make([]byte, 32)
+	other[0] = 1
+	if _, err := decrypt(other, enc); err == nil {
+		t.Errorf("decrypt with another key error = nil")
+	}
+	if _, err := decrypt(key, enc[:len(enc)-4]+"AAAA"); err == nil {
+		t.Errorf("decrypt modified value error = nil")
+	}
+}
@@ -0,0 +1,145 @@
+package diff
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yaxigin/mto/pkg/asset"
+)
+
+// Kind 资产变化类型
+type Kind string
+
+const (
+	KindNew     Kind = "new"     // 本次新出现的资产
+	KindGone    Kind = "gone"    // 本次没有再出现的资产
+	KindChanged Kind = "changed" // 标题、Server 或开放端口发生变化
+)
+
+// Change 单个字段的变化
+type Change struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Entry 一条变化记录，端口集合的变化以IP为单位记录
+type Entry struct {
+	Kind    Kind        `json:"kind"`
+	Key     string      `json:"key"`
+	Asset   asset.Asset `json:"asset"` // 新增和变化的资产为本次结果，消失的资产为上次结果
+	Changes []Change    `json:"changes,omitempty"`
+}
+
+// Report 两次运行之间的差异
+type Report struct {
+	New     []Entry `json:"new"`
+	Gone    []Entry `json:"gone"`
+	Changed []Entry `json:"changed"`
+}
+
+// Empty 两次运行的结果是否完全一致
+func (r *Report) Empty() bool {
+	return len(r.New) == 0 && len(r.Gone) == 0 && len(r.Changed) == 0
+}
+
+// Compare 比较两次运行的结果，old 和 new 中重复的资产会先合并
+func Compare(old, new *Snapshot) *Report {
+	oldAssets := index(old.Assets)
+	newAssets := index(new.Assets)
+	report := &Report{New: []Entry{}, Gone: []Entry{}, Changed: []Entry{}}
+
+	// 只比较两边都有的字段，避免不同格式的文件互相比较时产生大量误报
+	compareTitle := old.Columns["title"] && new.Columns["title"]
+	compareServer := old.Columns["server"] && new.Columns["server"]
+
+	for _, key := range sortedKeys(newAssets) {
+		a := newAssets[key]
+		prev, ok := oldAssets[key]
+		if !ok {
+			report.New = append(report.New, Entry{Kind: KindNew, Key: key, Asset: a})
+			continue
+		}
+
+		var changes []Change
+		if compareTitle && prev.Title != a.Title {
+			changes = append(changes, Change{Field: "title", Old: prev.Title, New: a.Title})
+		}
+		if compareServer && prev.Server != a.Server {
+			changes = append(changes, Change{Field: "server", Old: prev.Server, New: a.Server})
+		}
+		if len(changes) > 0 {
+			report.Changed = append(report.Changed, Entry{Kind: KindChanged, Key: key, Asset: a, Changes: changes})
+		}
+	}
+
+	for _, key := range sortedKeys(oldAssets) {
+		if _, ok := newAssets[key]; !ok {
+			report.Gone = append(report.Gone, Entry{Kind: KindGone, Key: key, Asset: oldAssets[key]})
+		}
+	}
+
+	// 端口集合的变化按IP比较，只比较两次都出现过的IP
+	if old.Columns["port"] && new.Columns["port"] {
+		oldPorts, newPorts := portSets(old.Assets), portSets(new.Assets)
+		for _, ip := range sortedKeys(newPorts) {
+			prev, ok := oldPorts[ip]
+			if !ok || slices.Equal(prev, newPorts[ip]) {
+				continue
+			}
+			report.Changed = append(report.Changed, Entry{
+				Kind:    KindChanged,
+				Key:     ip,
+				Asset:   asset.Asset{IP: ip},
+				Changes: []Change{{Field: "ports", Old: joinPorts(prev), New: joinPorts(newPorts[ip])}},
+			})
+		}
+	}
+
+	return report
+}
+
+// index 按资产 Key 合并去重
+func index(assets []asset.Asset) map[string]asset.Asset {
+	result := make(map[string]asset.Asset)
+	for _, a := range asset.Merge(assets) {
+		result[a.Key()] = a
+	}
+	return result
+}
+
+// portSets 返回每个IP开放的端口（已排序）
+func portSets(assets []asset.Asset) map[string][]int {
+	sets := make(map[string][]int)
+	for _, a := range assets {
+		if a.IP == "" || a.Port == 0 || slices.Contains(sets[a.IP], a.Port) {
+			continue
+		}
+		sets[a.IP] = append(sets[a.IP], a.Port)
+	}
+	for _, ports := range sets {
+		slices.Sort(ports)
+	}
+	return sets
+}
+
+// joinPorts 以逗号连接端口
+func joinPorts(ports []int) string {
+	s := make([]string, len(ports))
+	for i, p := range ports {
+		s[i] = strconv.Itoa(p)
+	}
+	return strings.Join(s, ",")
+}
+
+// sortedKeys 返回排序后的键，保证输出稳定
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,225 @@
+package diff
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/yaxigin/mto/pkg/asset"
+)
+
+// Snapshot 一次运行的结果
+type Snapshot struct {
+	Assets  []asset.Asset
+	Columns map[string]bool // 文件中包含的字段，只比较两边都有的字段
+}
+
+// allColumns 统一的资产结构包含全部字段
+var allColumns = map[string]bool{"ip": true, "port": true, "title": true, "server": true}
+
+// FromAssets 使用本次运行得到的资产创建快照
+func FromAssets(assets []asset.Asset) *Snapshot {
+	return &Snapshot{Assets: assets, Columns: allColumns}
+}
+
+// legacyQuakeHeader 旧版 quake 输出的表头有 Host 列，但数据行没有
+var legacyQuakeHeader = []string{"IP", "Domain", "Port", "Protocol", "Host", "URL", "Title", "Server", "ICP", "Unit", "ISP"}
+
+// columnFields 表头名称（小写）对应的资产字段，包括各版本输出中的写法
+var columnFields = map[string]string{
+	"ip":         "ip",
+	"port":       "port",
+	"domain":     "domain",
+	"host":       "host",
+	"url":        "url",
+	"link":       "url", // 旧版 fofa
+	"title":      "title",
+	"web title":  "title", // 旧版 hunter
+	"server":     "server",
+	"protocol":   "protocol",
+	"icp":        "icp",
+	"number":     "icp", // 旧版 hunter 的备案号
+	"org":        "org",
+	"company":    "org", // 旧版 hunter
+	"unit":       "org", // 旧版 quake
+	"isp":        "isp",
+	"country":    "country",
+	"components": "components",
+	"source":     "source",
+	"lastseen":   "lastseen",
+}
+
+// Load 读取之前的输出文件，支持 CSV（统一格式和旧版 fofa/hunter/quake 格式）、JSON 和 JSON Lines
+func Load(path string) (*Snapshot, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+	content = bytes.TrimPrefix(content, []byte("\xEF\xBB\xBF"))
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return loadJSON(content)
+	case ".jsonl":
+		return loadJSONL(content)
+	}
+
+	snap, err := loadCSV(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %v", path, err)
+	}
+	return snap, nil
+}
+
+// loadCSV 按表头识别列，兼容各版本的输出格式
+func loadCSV(r io.Reader) (*Snapshot, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // 旧版 quake 的表头和数据行列数不同
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("读取表头失败: %v", err)
+	}
+
+	columns := mapColumns(header)
+	if !slices.Contains(columns, "ip") {
+		return nil, fmt.Errorf("无法识别的表头: %s", strings.Join(header, ","))
+	}
+	// 旧版 quake 的数据行少了 Host 列
+	var quakeRows []string
+	if slices.Equal(header, legacyQuakeHeader) {
+		quakeRows = mapColumns(slices.Delete(slices.Clone(header), 4, 5))
+	}
+
+	snap := &Snapshot{Columns: make(map[string]bool)}
+	for _, c := range columns {
+		if c != "" {
+			snap.Columns[c] = true
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// 多次运行追加到同一文件时可能重复写入表头
+		if len(record) > 0 {
+			record[0] = strings.TrimPrefix(record[0], "\xEF\xBB\xBF")
+		}
+		if slices.Equal(record, header) {
+			continue
+		}
+
+		cols := columns
+		if quakeRows != nil && len(record) == len(quakeRows) {
+			cols = quakeRows
+		}
+		snap.Assets = append(snap.Assets, recordAsset(cols, record))
+	}
+	return snap, nil
+}
+
+// mapColumns 将表头转换为资产字段名，无法识别的列为空字符串
+func mapColumns(header []string) []string {
+	columns := make([]string, len(header))
+	for i, h := range header {
+		columns[i] = columnFields[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\xEF\xBB\xBF")))]
+	}
+	return columns
+}
+
+// recordAsset 将一行数据转换为资产
+func recordAsset(columns, record []string) asset.Asset {
+	var a asset.Asset
+	for i, v := range record {
+		if i >= len(columns) {
+			break
+		}
+		v = strings.TrimSpace(v)
+		switch columns[i] {
+		case "ip":
+			a.IP = v
+		case "port":
+			a.Port = asset.ParsePort(v)
+		case "domain":
+			a.Domain = v
+		case "host":
+			a.Host = v
+		case "url":
+			a.URL = v
+		case "title":
+			a.Title = v
+		case "server":
+			a.Server = v
+		case "protocol":
+			a.Protocol = v
+		case "icp":
+			a.ICP = v
+		case "org":
+			a.Org = v
+		case "isp":
+			a.ISP = v
+		case "country":
+			a.Country = v
+		case "components":
+			if v != "" {
+				a.Components = strings.Split(v, ",")
+			}
+		case "source":
+			if v != "" {
+				a.Sources = strings.Split(v, "|")
+				a.Source = a.Sources[0]
+			}
+		case "lastseen":
+			a.LastSeen = asset.ParseTime(v)
+		}
+	}
+
+	// 旧版输出没有端口时尝试从URL中获取
+	if a.Port == 0 && a.URL != "" {
+		if _, port, err := net.SplitHostPort(asset.HostFromURL(a.URL)); err == nil {
+			a.Port = asset.ParsePort(port)
+		}
+	}
+	return a
+}
+
+// loadJSON 读取 -json 输出的资产数组
+func loadJSON(content []byte) (*Snapshot, error) {
+	var assets []asset.Asset
+	if err := json.Unmarshal(content, &assets); err != nil {
+		return nil, fmt.Errorf("解析JSON失败: %v", err)
+	}
+	return FromAssets(assets), nil
+}
+
+// loadJSONL 读取 -jsonl 输出，每行一个资产
+func loadJSONL(content []byte) (*Snapshot, error) {
+	snap := FromAssets(nil)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 1024*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var a asset.Asset
+		if err := json.Unmarshal(scanner.Bytes(), &a); err != nil {
+			return nil, fmt.Errorf("解析第 %d 行失败: %v", line, err)
+		}
+		snap.Assets = append(snap.Assets, a)
+	}
+	return snap, scanner.Err()
+}
@@ -0,0 +1,157 @@
+package diff
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/yaxigin/mto/pkg/asset"
+)
+
+const bom = "\xEF\xBB\xBF"
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		content     string
+		want        []asset.Asset
+		wantColumns []string
+		wantErr     bool
+	}{
+		{
+			name: "统一格式",
+			file: "result.csv",
+			content: bom + "IP,Port,Domain,Host,URL,Title,Server,Protocol,ICP,Org,ISP,Country,Components,Source,LastSeen,Query\n" +
+				"1.1.1.1,443,a.com,a.com,https://a.com,首页,nginx,https,,,,,\"vue,jquery\",fofa|hunter,,\"title=\"\"首页\"\"\"\n",
+			want: []asset.Asset{{
+				IP: "1.1.1.1", Port: 443, Domain: "a.com", Host: "a.com", URL: "https://a.com", Title: "首页", Server: "nginx",
+				Protocol: "https", Components: []string{"vue", "jquery"}, Source: "fofa", Sources: []string{"fofa", "hunter"},
+			}},
+			wantColumns: []string{"ip", "port", "title", "server"},
+		},
+		{
+			name: "旧版fofa",
+			file: "fofa.csv",
+			content: bom + "IP,Domain,Port,Protocol,Link,Title,Server\n" +
+				"1.1.1.1,a.com,80,http,http://a.com,登录,nginx\n",
+			want:        []asset.Asset{{IP: "1.1.1.1", Domain: "a.com", Port: 80, Protocol: "http", URL: "http://a.com", Title: "登录", Server: "nginx"}},
+			wantColumns: []string{"ip", "port", "title", "server"},
+		},
+		{
+			name: "旧版fofa追加时重复写入带BOM的表头",
+			file: "fofa.csv",
+			content: bom + "IP,Domain,Port,Protocol,Link,Title,Server\n" +
+				"1.1.1.1,,80,http,,a,\n" +
+				bom + "IP,Domain,Port,Protocol,Link,Title,Server\n" +
+				"2.2.2.2,,80,http,,b,\n",
+			want:        []asset.Asset{{IP: "1.1.1.1", Port: 80, Protocol: "http", Title: "a"}, {IP: "2.2.2.2", Port: 80, Protocol: "http", Title: "b"}},
+			wantColumns: []string{"ip", "port", "title", "server"},
+		},
+		{
+			name: "旧版hunter",
+			file: "hunter.csv",
+			content: bom + "IP,Port,Domain,Protocol,Base Protocol,URL,Web Title,Status Code,Company,Number,Country,Is Web,ISP\n" +
+				"1.1.1.1,8080,a.com,http,tcp,http://a.com:8080,后台,200,某公司,京ICP备1号,中国,是,电信\n",
+			want: []asset.Asset{{
+				IP: "1.1.1.1", Port: 8080, Domain: "a.com", Protocol: "http", URL: "http://a.com:8080", Title: "后台",
+				Org: "某公司", ICP: "京ICP备1号", Country: "中国", ISP: "电信",
+			}},
+			wantColumns: []string{"ip", "port", "title"},
+		},
+		{
+			name: "旧版quake数据行没有Host列",
+			file: "quake.csv",
+			content: bom + "IP,Domain,Port,Protocol,Host,URL,Title,Server,ICP,Unit,ISP\n" +
+				"1.1.1.1,a.com,443,https,https://a.com,首页,nginx,京ICP备1号,某公司,电信\n",
+			want: []asset.Asset{{
+				IP: "1.1.1.1", Domain: "a.com", Port: 443, Protocol: "https", URL: "https://a.com", Title: "首页", Server: "nginx",
+				ICP: "京ICP备1号", Org: "某公司", ISP: "电信",
+			}},
+			wantColumns: []string{"ip", "port", "title", "server"},
+		},
+		{
+			name: "旧版quake列数与表头一致的行",
+			file: "quake.csv",
+			content: "IP,Domain,Port,Protocol,Host,URL,Title,Server,ICP,Unit,ISP\n" +
+				"1.1.1.1,,443,https,a.com,https://a.com,首页,nginx,,,\n",
+			want:        []asset.Asset{{IP: "1.1.1.1", Port: 443, Protocol: "https", Host: "a.com", URL: "https://a.com", Title: "首页", Server: "nginx"}},
+			wantColumns: []string{"ip", "port", "title", "server"},
+		},
+		{
+			name: "没有端口时从URL获取",
+			file: "result.csv",
+			content: "IP,URL,Title\n" +
+				"1.1.1.1,https://a.com:8443/login,a\n",
+			want:        []asset.Asset{{IP: "1.1.1.1", Port: 8443, URL: "https://a.com:8443/login", Title: "a"}},
+			wantColumns: []string{"ip", "title"},
+		},
+		{
+			name:    "无法识别的表头",
+			file:    "result.csv",
+			content: "a,b,c\n1,2,3\n",
+			wantErr: true,
+		},
+		{
+			name:        "JSON",
+			file:        "result.json",
+			content:     bom + `[{"ip":"1.1.1.1","port":80,"title":"a","source":"fofa"}]`,
+			want:        []asset.Asset{{IP: "1.1.1.1", Port: 80, Title: "a", Source: "fofa"}},
+			wantColumns: []string{"ip", "port", "title", "server"},
+		},
+		{
+			name:        "JSON Lines",
+			file:        "result.jsonl",
+			content:     "{\"ip\":\"1.1.1.1\",\"port\":80}\n\n{\"ip\":\"2.2.2.2\",\"port\":443}\n",
+			want:        []asset.Asset{{IP: "1.1.1.1", Port: 80}, {IP: "2.2.2.2", Port: 443}},
+			wantColumns: []string{"ip", "port", "title", "server"},
+		},
+		{
+			name:    "JSON Lines格式错误",
+			file:    "result.jsonl",
+			content: "{\"ip\":\"1.1.1.1\"}\nnot json\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			snap, err := Load(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if len(snap.Assets) != len(tt.want) {
+				t.Fatalf("Load() 得到 %d 个资产, want %d: %+v", len(snap.Assets), len(tt.want), snap.Assets)
+			}
+			for i, want := range tt.want {
+				if got := snap.Assets[i]; !sameAsset(got, want) {
+					t.Errorf("资产 %d = %+v, want %+v", i, got, want)
+				}
+			}
+			// 只检查 Compare 使用的字段
+			for c := range allColumns {
+				if snap.Columns[c] != slices.Contains(tt.wantColumns, c) {
+					t.Errorf("Columns[%s] = %v, wantColumns %v", c, snap.Columns[c], tt.wantColumns)
+				}
+			}
+		})
+	}
+}
+
+// sameAsset 比较测试关心的字段
+func sameAsset(a, b asset.Asset) bool {
+	return a.IP == b.IP && a.Port == b.Port && a.Domain == b.Domain && a.Host == b.Host && a.URL == b.URL &&
+		a.Title == b.Title && a.Server == b.Server && a.Protocol == b.Protocol && a.ICP == b.ICP && a.Org == b.Org &&
+		a.ISP == b.ISP && a.Country == b.Country && a.Source == b.Source &&
+		strings.Join(a.Components, ",") == strings.Join(b.Components, ",") && strings.Join(a.Sources, "|") == strings.Join(b.Sources, "|")
+}
@@ -0,0 +1,31 @@
+package engine
+
+import (
+	"context"
+	"strings"
+)
+
+// Account 账号的会员等级和剩余额度，数值为 -1 表示该引擎不提供
+type Account struct {
+	Engine    string `json:"engine"`
+	Key       string `json:"key"`        // 脱敏后的API密钥
+	User      string `json:"user"`       // 用户名或邮箱
+	Level     string `json:"level"`      // 会员等级
+	Quota     int    `json:"quota"`      // 剩余额度，单位见 QuotaUnit
+	QuotaUnit string `json:"quota_unit"` // 额度单位，如 F点、积分
+	QueryLeft int    `json:"query_left"` // 剩余可查询次数
+}
+
+// AccountInfo 可以查询账号信息的引擎实现该接口
+type AccountInfo interface {
+	// Account 查询当前API密钥对应的账号信息，不消耗额度
+	Account(ctx context.Context) (Account, error)
+}
+
+// MaskKey 隐藏API密钥的中间部分，只保留首尾各4个字符
+func MaskKey(key string) string {
+	if len(key) <= 8 {
+		return strings.Repeat("*", len(key))
+	}
+	return key[:4] + "****" + key[len(key)-4:]
+}
@@ -0,0 +1,200 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/yaxigin/mto/pkg/asset"
+	"github.com/yaxigin/mto/pkg/httpclient"
+	"github.com/yaxigin/mto/pkg/ratelimit"
+)
+
+// Options 查询参数，各引擎只使用其中自己关心的部分
+type Options struct {
+	Months     int  // 查询月份范围（hunter/quake）
+	MaxResults int  // 最大结果数量，不大于0时 fofa 获取 1000 条，hunter/quake 获取全部结果
+	UseNext    bool // 使用连续翻页接口（fofa）
+	NoLint     bool // 跳过查询前的离线检查
+
+	// Cursor 从指定位置继续翻页，取值为之前 OnPage 收到的 Page.Next
+	Cursor string
+	// OnPage 每获取一页结果后调用，用于边查询边写入结果并保存翻页进度
+	OnPage func(p Page)
+}
+
+// Page 分页查询中的一页结果
+type Page struct {
+	Assets []asset.Asset
+	Next   string // 获取下一页所需的位置（hunter为页码，quake为Start，fofa为next游标），空字符串表示没有下一页
+}
+
+// Report 通知调用方获取到一页结果，未设置 OnPage 时不做任何事
+func (o Options) Report(assets []asset.Asset, next string) {
+	if o.OnPage != nil {
+		o.OnPage(Page{Assets: assets, Next: next})
+	}
+}
+
+// Engine 统一的搜索引擎接口
+type Engine interface {
+	// Name 返回引擎名称，同时也是命令行子命令名
+	Name() string
+	// Search 执行查询并返回全部资产
+	Search(ctx context.Context, query string, opts Options) ([]asset.Asset, error)
+	// Count 只获取查询结果总数，不拉取数据
+	Count(ctx context.Context, query string, opts Options) (int, error)
+}
+
+// Logger 客户端的日志输出，引擎本身不写终端，由调用方决定日志去向
+type Logger interface {
+	Infof(format string, args ...any)
+	Warningf(format string, args ...any)
+}
+
+// nopLogger 不输出任何日志
+type nopLogger struct{}
+
+func (nopLogger) Infof(string, ...any)    {}
+func (nopLogger) Warningf(string, ...any) {}
+
+// ClientOptions 创建引擎客户端的参数，零值即可使用
+type ClientOptions struct {
+	HTTPClient *http.Client // 为空时使用默认配置的 httpclient 客户端
+	BaseURL    string       // 为空时使用官方API地址
+	Logger     Logger       // 为空时不输出日志
+	Backoff    Backoff      // 临时错误的重试策略，零值时使用 DefaultBackoff
+
+	Rate ratelimit.Config // 请求限速，零值字段使用引擎的默认值
+	Keys int              // 同一引擎同时使用的密钥数量，用于计算引擎的总速率，由 NewMultiKey 设置
+}
+
+// HTTP 返回客户端使用的 http.Client
+func (o ClientOptions) HTTP() *http.Client {
+	if o.HTTPClient != nil {
+		return o.HTTPClient
+	}
+	// 默认配置不会出错
+	client, _ := httpclient.Get(httpclient.Config{})
+	return client
+}
+
+// Limiter 返回客户端使用的限速器，def 为引擎默认的单个密钥速率
+// 同一引擎相同配置的客户端共用限速器，多个密钥合计的速率不超过引擎的总速率
+func (o ClientOptions) Limiter(name string, def ratelimit.Rate) *ratelimit.Limiter {
+	total, perKey := o.Rate.Rates(def, o.Keys)
+	return ratelimit.Get(name, total, perKey)
+}
+
+// Log 返回客户端使用的日志输出
+func (o ClientOptions) Log() Logger {
+	if o.Logger != nil {
+		return o.Logger
+	}
+	return nopLogger{}
+}
+
+// URL 返回 BaseURL 或默认地址
+func (o ClientOptions) URL(def string) string {
+	if o.BaseURL != "" {
+		return o.BaseURL
+	}
+	return def
+}
+
+// Factory 使用API密钥创建引擎客户端
+type Factory func(key string, opts ClientOptions) Engine
+
+// Describer 可选接口，提供命令行帮助中使用的说明文字
+type Describer interface {
+	// Description 返回模块简介
+	Description() string
+	// Syntax 返回语法参考（-k 参数输出）
+	Syntax() string
+}
+
+var (
+	mu        sync.RWMutex
+	factories = make(map[string]Factory)
+)
+
+// Register 注册引擎的客户端构造函数，名称重复时 panic
+func Register(name string, f Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := factories[name]; ok {
+		panic(fmt.Sprintf("引擎重复注册: %s", name))
+	}
+	factories[name] = f
+}
+
+// Has 判断引擎是否已注册
+func Has(name string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	_, ok := factories[name]
+	return ok
+}
+
+// New 使用API密钥创建已注册引擎的客户端
+func New(name, key string, opts ClientOptions) (Engine, error) {
+	mu.RLock()
+	f, ok := factories[name]
+	mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("未知引擎: %s", name)
+	}
+	return f(key, opts), nil
+}
+
+// Describe 返回引擎的说明文字，引擎未实现 Describer 时返回 nil
+func Describe(name string) Describer {
+	e, err := New(name, "", ClientOptions{})
+	if err != nil {
+		return nil
+	}
+	d, _ := e.(Describer)
+	return d
+}
+
+// Names 返回所有已注册引擎的名称（按字母排序）
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StopError 翻页中途停止，说明停止的原因和位置，使用 Cursor 可以从停止的位置继续
+type StopError struct {
+	Err    error  // 停止的原因，被取消时为 context.Canceled
+	Got    int    // 停止前已获取的结果数量
+	At     string // 停止的位置，如 "第 3 页"
+	Cursor string // 继续查询时使用的 Options.Cursor
+}
+
+func (e *StopError) Error() string {
+	return fmt.Sprintf("已获取 %d 条结果，停止于%s: %v", e.Got, e.At, e.Err)
+}
+
+func (e *StopError) Unwrap() error {
+	return e.Err
+}
+
+// Stop 翻页中途出错时返回 *StopError，尚未获取到结果且不是被取消时原样返回 err
+func Stop(ctx context.Context, err error, got int, at, cursor string) error {
+	if got == 0 && ctx.Err() == nil {
+		return err
+	}
+	return &StopError{Err: err, Got: got, At: at, Cursor: cursor}
+}
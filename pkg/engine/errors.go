@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// 接口错误的类型，使用 errors.Is 判断，如 errors.Is(err, engine.ErrQuota)
+var (
+	ErrAuth      = errors.New("API密钥无效或没有权限")
+	ErrQuota     = errors.New("额度不足")
+	ErrField     = errors.New("账号没有权限使用相关字段") // 密钥本身有效，不需要切换密钥
+	ErrSyntax    = errors.New("查询语法错误")
+	ErrResultCap = errors.New("超过结果数量上限")
+	ErrTransient = errors.New("临时错误")
+
+	// ErrRateLimit 请求过于频繁，同时也是临时错误，会按退避策略重试
+	ErrRateLimit = fmt.Errorf("请求过于频繁: %w", ErrTransient)
+)
+
+// APIError 引擎接口返回的错误，使用 errors.As 获取错误码和原始信息
+type APIError struct {
+	Engine string
+	Kind   error  // 上面的错误类型之一，无法归类时为 nil
+	Status int    // HTTP状态码，接口返回业务错误时为 200，未收到响应时为 0
+	Code   string // 引擎的错误码
+	Msg    string // 引擎返回的错误信息
+}
+
+func (e *APIError) Error() string {
+	switch {
+	case e.Code != "":
+		return fmt.Sprintf("API错误: %s - %s", e.Code, e.Msg)
+	case e.Msg == "":
+		return fmt.Sprintf("请求失败，状态码: %d", e.Status)
+	case e.Status == 0:
+		return fmt.Sprintf("请求失败: %s", e.Msg)
+	default:
+		return fmt.Sprintf("API错误: %s", e.Msg)
+	}
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Kind
+}
+
+// NewAPIError 创建接口错误，codes 中有对应错误码时使用其类型，否则根据HTTP状态码和错误信息判断
+func NewAPIError(engine string, status int, code, msg string, codes map[string]error) *APIError {
+	e := &APIError{Engine: engine, Status: status, Code: code, Msg: msg}
+	if kind, ok := codes[code]; ok {
+		e.Kind = kind
+	} else if kind := classifyStatus(status); kind != nil {
+		e.Kind = kind
+	} else {
+		e.Kind = classifyMessage(msg)
+	}
+	return e
+}
+
+// RequestError 包装发送请求时的错误，被取消时返回 ctx 的错误，否则为可以重试的临时错误
+func RequestError(ctx context.Context, engine string, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("请求失败: %w", ctx.Err())
+	}
+	// *url.Error 中的URL可能包含API密钥，只保留内部错误
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	return &APIError{Engine: engine, Kind: ErrTransient, Msg: err.Error()}
+}
+
+// classifyStatus 根据HTTP状态码判断错误类型
+func classifyStatus(status int) error {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrAuth
+	case status == http.StatusTooManyRequests:
+		return ErrRateLimit
+	case status >= 500:
+		return ErrTransient
+	}
+	return nil
+}
+
+// messageKinds 错误信息中的关键字，按顺序匹配
+// 只用于各引擎错误码表中没有收录的错误，关键字使用完整的短语，避免把无关的错误判断为密钥无效而切换密钥
+var messageKinds = []struct {
+	words []string
+	kind  error
+}{
+	{[]string{"最大允许查询", "10000条"}, ErrResultCap},
+	{[]string{"请求频繁", "请求过于频繁", "请求过快", "请求太多", "too many requests", "rate limit"}, ErrRateLimit},
+	{[]string{"余额不足", "积分不足", "额度不足", "积分已用完", "额度已用完", "quota exceeded", "insufficient balance"}, ErrQuota},
+	{[]string{"语法错误", "syntax error"}, ErrSyntax},
+	{[]string{"密钥无效", "api key无效", "无效的api key", "invalid api key", "invalid key", "令牌过期", "认证失败", "账号无效", "unauthorized"}, ErrAuth},
+	{[]string{"请求超时", "服务繁忙", "系统繁忙", "timeout", "try again later"}, ErrTransient},
+}
+
+// classifyMessage 根据错误信息判断错误类型，无法判断时返回 nil，由调用方当作不可重试的错误处理
+func classifyMessage(msg string) error {
+	msg = strings.ToLower(msg)
+	for _, m := range messageKinds {
+		for _, w := range m.words {
+			if strings.Contains(msg, w) {
+				return m.kind
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNewAPIError(t *testing.T) {
+	codes := map[string]error{
+		"-700":   ErrAuth,
+		"820001": ErrField,
+		"40204":  ErrQuota,
+	}
+	tests := []struct {
+		name   string
+		status int
+		code   string
+		msg    string
+		want   error
+	}{
+		{"错误码优先", 200, "-700", "账号无效", ErrAuth},
+		{"错误码优先于错误信息", 200, "820001", "没有权限搜索该字段，请求过于频繁", ErrField},
+		{"错误码优先于状态码", 500, "40204", "", ErrQuota},
+		{"状态码401", 401, "", "", ErrAuth},
+		{"状态码403", 403, "x", "", ErrAuth},
+		{"状态码429", 429, "", "", ErrRateLimit},
+		{"状态码5xx", 502, "", "", ErrTransient},
+		{"结果数量上限", 200, "q2001", "网页查询最大允许查询10000条数据", ErrResultCap},
+		{"请求频繁", 200, "q3005", "API请求频繁，请稍后再试", ErrRateLimit},
+		{"积分不足", 200, "u3007", "您的积分不足", ErrQuota},
+		{"语法错误", 200, "q2001", "查询语法错误", ErrSyntax},
+		{"英文错误信息忽略大小写", 200, "", "Invalid API Key", ErrAuth},
+		{"服务繁忙", 200, "", "系统繁忙，请稍后再试", ErrTransient},
+		{"无法判断的错误", 200, "q2001", "查询参数错误", nil},
+		{"只包含无关的单词", 200, "", "key field error", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewAPIError("test", tt.status, tt.code, tt.msg, codes)
+			if err.Kind != tt.want {
+				t.Errorf("NewAPIError(%d, %q, %q).Kind = %v, want %v", tt.status, tt.code, tt.msg, err.Kind, tt.want)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.want)
+			}
+		})
+	}
+
+	// 请求频繁同时也是临时错误，会按退避策略重试
+	if !errors.Is(NewAPIError("test", 429, "", "", nil), ErrTransient) {
+		t.Error("ErrRateLimit 应同时是 ErrTransient")
+	}
+}
+
+func TestAPIErrorMessage(t *testing.T) {
+	tests := []struct {
+		err  *APIError
+		want string
+	}{
+		{&APIError{Code: "820000", Msg: "语法错误"}, "API错误: 820000 - 语法错误"},
+		{&APIError{Status: 502}, "请求失败，状态码: 502"},
+		{&APIError{Msg: "connection refused"}, "请求失败: connection refused"},
+		{&APIError{Status: 200, Msg: "查询失败"}, "API错误: 查询失败"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestRequestError(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	urlErr := &url.Error{Op: "Get", URL: "https://fofa.info/api/v1/search/all?key=secret-key", Err: errors.New("connection refused")}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want error
+	}{
+		{"临时错误", context.Background(), ErrTransient},
+		{"已取消", canceled, context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := RequestError(tt.ctx, "fofa", urlErr)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("RequestError() = %v, want %v", err, tt.want)
+			}
+			if strings.Contains(err.Error(), "secret-key") {
+				t.Errorf("错误信息中包含API密钥: %v", err)
+			}
+		})
+	}
+}
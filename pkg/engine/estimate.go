@@ -0,0 +1,24 @@
+package engine
+
+// Estimate 执行查询前估算的数据量和额度消耗
+type Estimate struct {
+	Total int    `json:"total"` // 查询结果总数
+	Fetch int    `json:"fetch"` // 实际会获取的结果数量，受接口上限和查询参数限制
+	Pages int    `json:"pages"` // 需要发送的请求数
+	Cost  int    `json:"cost"`  // 预计消耗的额度
+	Unit  string `json:"unit"`  // 额度单位，如 F点、积分
+}
+
+// Estimator 可以估算额度消耗的引擎实现该接口
+type Estimator interface {
+	// Estimate 根据 Count 返回的结果总数估算获取数据的开销，不发送请求
+	Estimate(total int, opts Options) Estimate
+}
+
+// Pages 获取 n 条结果需要的请求数，没有结果时仍需要一次请求
+func Pages(n, pageSize int) int {
+	if n <= 0 {
+		return 1
+	}
+	return (n + pageSize - 1) / pageSize
+}
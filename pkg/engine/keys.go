@@ -0,0 +1,231 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yaxigin/mto/pkg/asset"
+)
+
+// KeyPolicy 配置了多个API密钥时选择密钥的策略
+type KeyPolicy string
+
+const (
+	RoundRobin KeyPolicy = "round-robin" // 每次查询依次轮换使用各个密钥
+	MostQuota  KeyPolicy = "most-quota"  // 优先使用剩余额度最多的密钥，引擎需要实现 AccountInfo
+)
+
+// ParseKeyPolicy 解析密钥选择策略，为空时使用 round-robin
+func ParseKeyPolicy(s string) (KeyPolicy, error) {
+	switch KeyPolicy(s) {
+	case "", RoundRobin:
+		return RoundRobin, nil
+	case MostQuota:
+		return MostQuota, nil
+	}
+	return "", fmt.Errorf("未知的密钥选择策略 %q，可选: %s、%s", s, RoundRobin, MostQuota)
+}
+
+// rankTTL most-quota 排序结果的有效期，过期后重新查询各密钥的剩余额度
+const rankTTL = 10 * time.Minute
+
+// MultiKey 使用同一引擎的多个API密钥，实现 Engine 接口
+// 密钥额度不足或无效时切换到下一个密钥，分页查询从出错的那一页继续，已获取的结果不会丢失
+type MultiKey struct {
+	name    string
+	keys    []string
+	clients []Engine
+	policy  KeyPolicy
+	log     Logger
+
+	mu       sync.Mutex
+	next     int           // round-robin 下一次查询首先使用的密钥
+	ranked   []int         // most-quota 按剩余额度从多到少排列的密钥，为空时需要重新排序
+	rankedAt time.Time     // ranked 的排序时间
+	failed   map[int]error // 额度不足或无效的密钥
+
+	rankMu sync.Mutex // 同一时间只有一个协程查询剩余额度，查询期间不持有 mu
+}
+
+// NewMultiKey 为每个API密钥创建已注册引擎的客户端
+func NewMultiKey(name string, keys []string, policy KeyPolicy, opts ClientOptions) (*MultiKey, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s 没有配置API密钥", name)
+	}
+
+	m := &MultiKey{name: name, keys: keys, policy: policy, log: opts.Log(), failed: make(map[int]error)}
+	opts.Keys = len(keys)
+	for _, key := range keys {
+		c, err := New(name, key, opts)
+		if err != nil {
+			return nil, err
+		}
+		m.clients = append(m.clients, c)
+	}
+	return m, nil
+}
+
+// Name 返回引擎名称
+func (m *MultiKey) Name() string {
+	return m.name
+}
+
+// Search 依次使用可用的密钥执行查询，切换密钥时从上一个密钥最后获取的一页之后继续
+func (m *MultiKey) Search(ctx context.Context, query string, opts Options) ([]asset.Asset, error) {
+	var (
+		results []asset.Asset
+		err     error
+	)
+	cursor, report := opts.Cursor, opts.OnPage
+	order := m.order(ctx)
+	for n, i := range order {
+		o := opts
+		o.Cursor = cursor
+		o.OnPage = func(p Page) {
+			cursor = p.Next
+			if report != nil {
+				report(p)
+			}
+		}
+
+		var assets []asset.Asset
+		assets, err = m.clients[i].Search(ctx, query, o)
+		results = append(results, assets...)
+		if !m.failover(ctx, i, err, n < len(order)-1) {
+			break
+		}
+	}
+	return results, err
+}
+
+// Count 依次使用可用的密钥获取查询结果总数
+func (m *MultiKey) Count(ctx context.Context, query string, opts Options) (int, error) {
+	var (
+		total int
+		err   error
+	)
+	order := m.order(ctx)
+	for n, i := range order {
+		total, err = m.clients[i].Count(ctx, query, opts)
+		if !m.failover(ctx, i, err, n < len(order)-1) {
+			break
+		}
+	}
+	return total, err
+}
+
+// Estimate 使用引擎自己的估算方式，引擎未实现 Estimator 时只返回结果总数
+func (m *MultiKey) Estimate(total int, opts Options) Estimate {
+	if e, ok := m.clients[0].(Estimator); ok {
+		return e.Estimate(total, opts)
+	}
+	return Estimate{Total: total, Fetch: total, Pages: -1, Cost: -1}
+}
+
+// order 返回本次查询尝试密钥的顺序，跳过已失效的密钥
+// 全部密钥都失效时重新尝试全部密钥，长时间运行时额度可能已经恢复
+func (m *MultiKey) order(ctx context.Context) []int {
+	var base []int
+	if m.policy == MostQuota {
+		base = m.ranking(ctx)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.failed) == len(m.clients) {
+		clear(m.failed)
+	}
+
+	if base == nil {
+		for n := range m.clients {
+			base = append(base, (m.next+n)%len(m.clients))
+		}
+		m.next = (m.next + 1) % len(m.clients)
+	}
+
+	var order []int
+	for _, i := range base {
+		if _, ok := m.failed[i]; !ok {
+			order = append(order, i)
+		}
+	}
+	return order
+}
+
+// ranking 返回按剩余额度排序的密钥，排序结果过期或被清空时重新查询
+func (m *MultiKey) ranking(ctx context.Context) []int {
+	m.rankMu.Lock()
+	defer m.rankMu.Unlock()
+
+	m.mu.Lock()
+	ranked := m.ranked
+	if time.Since(m.rankedAt) >= rankTTL {
+		ranked = nil
+	}
+	m.mu.Unlock()
+	if ranked != nil {
+		return ranked
+	}
+
+	ranked = m.rank(ctx)
+	// 被取消时部分密钥没有查询到额度，本次使用但不缓存
+	if ctx.Err() == nil {
+		m.mu.Lock()
+		m.ranked, m.rankedAt = ranked, time.Now()
+		m.mu.Unlock()
+	}
+	return ranked
+}
+
+// rank 查询每个密钥的剩余额度并从多到少排序，查询失败的密钥排在最后
+func (m *MultiKey) rank(ctx context.Context) []int {
+	quota := make([]int, len(m.clients))
+	for i, c := range m.clients {
+		quota[i] = -1
+		info, ok := c.(AccountInfo)
+		if !ok {
+			continue
+		}
+		account, err := info.Account(ctx)
+		if err != nil {
+			m.log.Warningf("%s 密钥 %s 查询剩余额度失败: %v", m.name, MaskKey(m.keys[i]), err)
+			continue
+		}
+		quota[i] = account.Quota
+	}
+
+	ranked := make([]int, len(m.clients))
+	for i := range ranked {
+		ranked[i] = i
+	}
+	sort.SliceStable(ranked, func(a, b int) bool {
+		return quota[ranked[a]] > quota[ranked[b]]
+	})
+	return ranked
+}
+
+// failover 密钥额度不足或无效时记录该密钥，还有其他密钥可用时返回 true
+func (m *MultiKey) failover(ctx context.Context, i int, err error, more bool) bool {
+	if err == nil || ctx.Err() != nil || !(errors.Is(err, ErrQuota) || errors.Is(err, ErrAuth)) {
+		return false
+	}
+
+	m.mu.Lock()
+	m.failed[i] = err
+	// 额度已经变化，下次查询时重新排序
+	if errors.Is(err, ErrQuota) {
+		m.ranked = nil
+	}
+	m.mu.Unlock()
+
+	if !more {
+		return false
+	}
+	m.log.Warningf("%s 密钥 %s 不可用，切换到下一个密钥继续: %v", m.name, MaskKey(m.keys[i]), err)
+	return true
+}
@@ -0,0 +1,265 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/yaxigin/mto/pkg/asset"
+)
+
+// fakeKey 测试密钥的行为
+type fakeKey struct {
+	quota      int   // Account 返回的剩余额度
+	accountErr error // Account 返回的错误
+	err        error // Search 在第 failAt 页返回的错误，为 nil 时成功
+	failAt     int
+}
+
+// fakeKeys 测试使用的密钥，测试中修改后新建的 MultiKey 会使用新的行为
+var (
+	fakeMu   sync.Mutex
+	fakeKeys = map[string]*fakeKey{}
+	fakeLog  []string // 每次 Search 的 "密钥:翻页位置"
+)
+
+// fakePages 每个查询的总页数，每页一个资产
+const fakePages = 3
+
+// keyClient 按 fakeKeys 中的行为返回结果
+type keyClient struct {
+	key string
+}
+
+func init() {
+	Register("fake", func(key string, opts ClientOptions) Engine {
+		return &keyClient{key: key}
+	})
+}
+
+func (c *keyClient) Name() string { return "fake" }
+
+func (c *keyClient) behavior() fakeKey {
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	return *fakeKeys[c.key]
+}
+
+func (c *keyClient) Search(ctx context.Context, query string, opts Options) ([]asset.Asset, error) {
+	fakeMu.Lock()
+	fakeLog = append(fakeLog, c.key+":"+opts.Cursor)
+	fakeMu.Unlock()
+
+	k := c.behavior()
+	start, _ := strconv.Atoi(opts.Cursor)
+	var results []asset.Asset
+	for page := start; page < fakePages; page++ {
+		if k.err != nil && page == k.failAt {
+			return results, Stop(ctx, k.err, len(results), fmt.Sprintf("第 %d 页", page), strconv.Itoa(page))
+		}
+		a := asset.Asset{IP: fmt.Sprintf("10.0.0.%d", page), Port: 80, Source: c.key}
+		results = append(results, a)
+		next := ""
+		if page+1 < fakePages {
+			next = strconv.Itoa(page + 1)
+		}
+		opts.Report([]asset.Asset{a}, next)
+	}
+	return results, nil
+}
+
+func (c *keyClient) Count(ctx context.Context, query string, opts Options) (int, error) {
+	return fakePages, c.behavior().err
+}
+
+func (c *keyClient) Account(ctx context.Context) (Account, error) {
+	k := c.behavior()
+	return Account{Quota: k.quota}, k.accountErr
+}
+
+// setupKeys 设置测试密钥的行为并清空调用记录
+func setupKeys(t *testing.T, keys map[string]*fakeKey) {
+	t.Helper()
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	fakeKeys, fakeLog = keys, nil
+}
+
+// searchLog 返回调用记录并清空
+func searchLog() []string {
+	fakeMu.Lock()
+	defer fakeMu.Unlock()
+	l := fakeLog
+	fakeLog = nil
+	return l
+}
+
+// sources 返回每个资产来自的密钥
+func sources(assets []asset.Asset) []string {
+	var s []string
+	for _, a := range assets {
+		s = append(s, a.Source)
+	}
+	return s
+}
+
+func TestMultiKeyFailover(t *testing.T) {
+	tests := []struct {
+		name        string
+		keys        map[string]*fakeKey
+		wantErr     error
+		wantCalls   []string
+		wantSources []string
+	}{
+		{
+			name:        "第一个密钥可用",
+			keys:        map[string]*fakeKey{"a": {}, "b": {}},
+			wantCalls:   []string{"a:"},
+			wantSources: []string{"a", "a", "a"},
+		},
+		{
+			name:        "额度不足时从出错的那一页继续",
+			keys:        map[string]*fakeKey{"a": {err: ErrQuota, failAt: 1}, "b": {}},
+			wantCalls:   []string{"a:", "b:1"},
+			wantSources: []string{"a", "b", "b"},
+		},
+		{
+			name:        "密钥无效时切换",
+			keys:        map[string]*fakeKey{"a": {err: ErrAuth}, "b": {}},
+			wantCalls:   []string{"a:", "b:"},
+			wantSources: []string{"b", "b", "b"},
+		},
+		{
+			name:        "其他错误不切换密钥",
+			keys:        map[string]*fakeKey{"a": {err: ErrSyntax}, "b": {}},
+			wantErr:     ErrSyntax,
+			wantCalls:   []string{"a:"},
+			wantSources: nil,
+		},
+		{
+			name:        "临时错误不切换密钥",
+			keys:        map[string]*fakeKey{"a": {err: ErrRateLimit, failAt: 2}, "b": {}},
+			wantErr:     ErrRateLimit,
+			wantCalls:   []string{"a:"},
+			wantSources: []string{"a", "a"},
+		},
+		{
+			name:        "全部密钥额度不足",
+			keys:        map[string]*fakeKey{"a": {err: ErrQuota, failAt: 1}, "b": {err: fmt.Errorf("积分不足: %w", ErrQuota), failAt: 2}},
+			wantErr:     ErrQuota,
+			wantCalls:   []string{"a:", "b:1"},
+			wantSources: []string{"a", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupKeys(t, tt.keys)
+			m, err := NewMultiKey("fake", []string{"a", "b"}, RoundRobin, ClientOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			results, err := m.Search(context.Background(), "q", Options{})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("Search() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls := searchLog(); !slices.Equal(calls, tt.wantCalls) {
+				t.Errorf("调用 = %v, want %v", calls, tt.wantCalls)
+			}
+			if got := sources(results); !slices.Equal(got, tt.wantSources) {
+				t.Errorf("结果来源 = %v, want %v", got, tt.wantSources)
+			}
+		})
+	}
+}
+
+func TestMultiKeyRoundRobin(t *testing.T) {
+	setupKeys(t, map[string]*fakeKey{"a": {err: ErrQuota}, "b": {}, "c": {}})
+	m, err := NewMultiKey("fake", []string{"a", "b", "c"}, RoundRobin, ClientOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 每次查询依次从下一个密钥开始，已失效的密钥不再使用
+	want := [][]string{{"a:", "b:"}, {"b:"}, {"c:"}, {"b:"}}
+	for i, w := range want {
+		if _, err := m.Search(context.Background(), "q", Options{}); err != nil {
+			t.Fatalf("第 %d 次查询: %v", i+1, err)
+		}
+		if calls := searchLog(); !slices.Equal(calls, w) {
+			t.Errorf("第 %d 次查询调用 = %v, want %v", i+1, calls, w)
+		}
+	}
+}
+
+func TestMultiKeyMostQuota(t *testing.T) {
+	tests := []struct {
+		name      string
+		keys      map[string]*fakeKey
+		wantCalls []string
+	}{
+		{
+			name:      "优先使用剩余额度最多的密钥",
+			keys:      map[string]*fakeKey{"a": {quota: 10}, "b": {quota: 100}, "c": {quota: 50}},
+			wantCalls: []string{"b:"},
+		},
+		{
+			name:      "查询额度失败的密钥排在最后",
+			keys:      map[string]*fakeKey{"a": {accountErr: errors.New("timeout")}, "b": {quota: 0, err: ErrQuota}, "c": {quota: 5, err: ErrQuota}},
+			wantCalls: []string{"c:", "b:", "a:"},
+		},
+		{
+			name:      "额度相同时保持配置顺序",
+			keys:      map[string]*fakeKey{"a": {quota: 10}, "b": {quota: 10}, "c": {quota: 10}},
+			wantCalls: []string{"a:"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupKeys(t, tt.keys)
+			m, err := NewMultiKey("fake", []string{"a", "b", "c"}, MostQuota, ClientOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			m.Search(context.Background(), "q", Options{})
+			if calls := searchLog(); !slices.Equal(calls, tt.wantCalls) {
+				t.Errorf("调用 = %v, want %v", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestMultiKeyRankCache(t *testing.T) {
+	keys := map[string]*fakeKey{"a": {quota: 10}, "b": {quota: 100}}
+	setupKeys(t, keys)
+	m, err := NewMultiKey("fake", []string{"a", "b"}, MostQuota, ClientOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name      string
+		change    func()
+		wantCalls []string
+	}{
+		{"首次排序", func() {}, []string{"b:"}},
+		// 有效期内不重新查询额度
+		{"使用缓存的排序", func() { keys["a"].quota = 1000 }, []string{"b:"}},
+		// 额度不足时清空排序，切换到下一个密钥，下次查询重新排序
+		{"额度不足后重新排序", func() { keys["b"].err = ErrQuota }, []string{"b:", "a:"}},
+		{"失效的密钥不再使用", func() { keys["b"].quota = 5000 }, []string{"a:"}},
+	}
+	for _, s := range steps {
+		fakeMu.Lock()
+		s.change()
+		fakeMu.Unlock()
+		m.Search(context.Background(), "q", Options{})
+		if calls := searchLog(); !slices.Equal(calls, s.wantCalls) {
+			t.Errorf("%s: 调用 = %v, want %v", s.name, calls, s.wantCalls)
+		}
+	}
+}
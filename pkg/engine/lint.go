@@ -0,0 +1,23 @@
+package engine
+
+import (
+	"github.com/yaxigin/mto/pkg/query"
+)
+
+// Preflight 发送请求前离线检查查询语句，存在错误时返回错误，警告和提示写入 log
+// opts.NoLint 为 true 时跳过检查，用于字段目录尚未收录的新字段
+func Preflight(d query.Dialect, q string, opts Options, log Logger) error {
+	if opts.NoLint {
+		return nil
+	}
+
+	issues, err := query.Check(d, q)
+	for _, issue := range issues {
+		if issue.Severity == query.SeverityWarning {
+			log.Warningf("查询检查: %s", issue)
+		} else {
+			log.Infof("查询检查: %s", issue)
+		}
+	}
+	return err
+}
@@ -0,0 +1,52 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff 重试策略，第 n 次重试前等待 Base*2^n（不超过 Max），并在一半到全部之间随机抖动
+type Backoff struct {
+	Attempts int           // 最多尝试的次数，包括第一次
+	Base     time.Duration // 第一次重试前的等待时间
+	Max      time.Duration // 单次等待时间的上限
+}
+
+// DefaultBackoff 未指定重试策略时使用
+var DefaultBackoff = Backoff{Attempts: 4, Base: 2 * time.Second, Max: 30 * time.Second}
+
+// delay 返回第 n 次重试前的等待时间
+func (b Backoff) delay(n int) time.Duration {
+	d := b.Base << n
+	if d <= 0 || d > b.Max {
+		d = b.Max
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// Retry 执行 fn，只在返回临时错误（包括请求过于频繁）时按退避策略重试，其他错误立即返回
+func Retry(ctx context.Context, b Backoff, log Logger, fn func() error) error {
+	if b.Attempts < 1 {
+		b = DefaultBackoff
+	}
+
+	var err error
+	for n := range b.Attempts {
+		if err = fn(); err == nil || !errors.Is(err, ErrTransient) || n == b.Attempts-1 {
+			return err
+		}
+
+		d := b.delay(n)
+		log.Warningf("%v，%s 后第 %d 次重试", err, d.Round(100*time.Millisecond), n+1)
+		timer := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+	return err
+}
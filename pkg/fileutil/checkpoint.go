@@ -0,0 +1,112 @@
+package fileutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+// Checkpoint 批量查询的进度，记录已完成的查询和未完成查询的翻页位置
+// 不是并发安全的，由调用方加锁
+type Checkpoint struct {
+	Engine    string            `json:"engine"`
+	Input     string            `json:"input"`
+	Output    string            `json:"output"`
+	Completed []string          `json:"completed"`
+	Cursors   map[string]string `json:"cursors,omitempty"` // 查询语句 -> 下一页的位置
+	UpdatedAt time.Time         `json:"updated_at"`
+
+	path string
+}
+
+// CheckpointPath 返回输出文件对应的检查点文件路径
+func CheckpointPath(outputFile string) string {
+	return outputFile + ".checkpoint.json"
+}
+
+// NewCheckpoint 创建新的检查点
+func NewCheckpoint(path, engineName, input, output string) *Checkpoint {
+	return &Checkpoint{
+		Engine:  engineName,
+		Input:   input,
+		Output:  output,
+		Cursors: make(map[string]string),
+		path:    path,
+	}
+}
+
+// LoadCheckpoint 读取检查点文件，文件不存在时返回 os.ErrNotExist
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Checkpoint{}
+	if err := json.Unmarshal(content, c); err != nil {
+		return nil, fmt.Errorf("解析检查点文件失败: %v", err)
+	}
+	if c.Cursors == nil {
+		c.Cursors = make(map[string]string)
+	}
+	c.path = path
+	return c, nil
+}
+
+// Done 查询是否已经完成
+func (c *Checkpoint) Done(query string) bool {
+	return slices.Contains(c.Completed, query)
+}
+
+// Cursor 返回查询上次停止时的翻页位置，没有记录时返回空字符串
+func (c *Checkpoint) Cursor(query string) string {
+	return c.Cursors[query]
+}
+
+// SetCursor 记录查询下一页的位置并保存
+func (c *Checkpoint) SetCursor(query, cursor string) error {
+	if cursor == "" {
+		delete(c.Cursors, query)
+	} else {
+		c.Cursors[query] = cursor
+	}
+	return c.save()
+}
+
+// Complete 标记查询已完成并保存
+func (c *Checkpoint) Complete(query string) error {
+	delete(c.Cursors, query)
+	if !c.Done(query) {
+		c.Completed = append(c.Completed, query)
+	}
+	return c.save()
+}
+
+// Remove 删除检查点文件，批量查询全部成功后调用
+func (c *Checkpoint) Remove() error {
+	if err := os.Remove(c.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// save 先写临时文件再重命名，避免中途退出时留下不完整的检查点
+func (c *Checkpoint) save() error {
+	c.UpdatedAt = time.Now()
+	content, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("生成检查点失败: %v", err)
+	}
+
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return fmt.Errorf("写入检查点失败: %v", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("保存检查点失败: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,298 @@
+package fileutil
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/yaxigin/mto/pkg/asset"
+	"github.com/yaxigin/mto/pkg/engine"
+	"github.com/yaxigin/mto/pkg/output"
+)
+
+// fakeEngine 按查询语句返回预设的分页结果，记录每次查询收到的翻页位置
+type fakeEngine struct {
+	pages map[string][]engine.Page // 查询语句 -> 依次返回的页
+	fail  map[string]int           // 查询语句 -> 返回第几页（从0开始）之前失败
+
+	mu      sync.Mutex
+	cursors map[string]string // 查询语句 -> 收到的 Options.Cursor
+}
+
+func (e *fakeEngine) Name() string { return "fake" }
+
+func (e *fakeEngine) Search(ctx context.Context, query string, opts engine.Options) ([]asset.Asset, error) {
+	e.mu.Lock()
+	e.cursors[query] = opts.Cursor
+	e.mu.Unlock()
+
+	var results []asset.Asset
+	for i, p := range e.pages[query] {
+		if n, ok := e.fail[query]; ok && i == n {
+			return results, errors.New("请求失败")
+		}
+		results = append(results, p.Assets...)
+		opts.Report(p.Assets, p.Next)
+	}
+	if n, ok := e.fail[query]; ok && n >= len(e.pages[query]) {
+		return results, errors.New("请求失败")
+	}
+	return results, nil
+}
+
+func (e *fakeEngine) Count(ctx context.Context, query string, opts engine.Options) (int, error) {
+	return 0, nil
+}
+
+// page 返回只有一个资产的页
+func page(ip, next string) engine.Page {
+	return engine.Page{Assets: []asset.Asset{{IP: ip, Port: 80}}, Next: next}
+}
+
+// writeQueries 在临时目录中写入查询文件，返回查询文件和输出文件的路径
+func writeQueries(t *testing.T, queries ...string) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	input := filepath.Join(dir, "queries.txt")
+	if err := os.WriteFile(input, []byte(strings.Join(queries, "\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return input, filepath.Join(dir, "result.csv")
+}
+
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "result.csv.checkpoint.json")
+	cp := NewCheckpoint(path, "fofa", "queries.txt", "result.csv")
+
+	steps := []struct {
+		name string
+		do   func() error
+	}{
+		{"记录翻页位置", func() error { return cp.SetCursor("a", "2") }},
+		{"另一条查询", func() error { return cp.SetCursor("b", "next-token") }},
+		{"完成后清除翻页位置", func() error { return cp.Complete("a") }},
+		{"重复完成", func() error { return cp.Complete("a") }},
+		{"空位置清除记录", func() error { return cp.SetCursor("c", "") }},
+	}
+	for _, s := range steps {
+		if err := s.do(); err != nil {
+			t.Fatalf("%s: %v", s.name, err)
+		}
+	}
+
+	loaded, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		query      string
+		wantDone   bool
+		wantCursor string
+	}{
+		{"a", true, ""},
+		{"b", false, "next-token"},
+		{"c", false, ""},
+	}
+	for _, tt := range tests {
+		if done := loaded.Done(tt.query); done != tt.wantDone {
+			t.Errorf("Done(%q) = %v, want %v", tt.query, done, tt.wantDone)
+		}
+		if cursor := loaded.Cursor(tt.query); cursor != tt.wantCursor {
+			t.Errorf("Cursor(%q) = %q, want %q", tt.query, cursor, tt.wantCursor)
+		}
+	}
+	if len(loaded.Completed) != 1 || loaded.Engine != "fofa" || loaded.Output != "result.csv" {
+		t.Errorf("LoadCheckpoint() = %+v", loaded)
+	}
+
+	if err := loaded.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCheckpoint(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Remove 之后 LoadCheckpoint() error = %v, want os.ErrNotExist", err)
+	}
+	// 文件不存在时 Remove 不报错
+	if err := loaded.Remove(); err != nil {
+		t.Errorf("重复 Remove() error = %v", err)
+	}
+}
+
+func TestProcessFileCheckpoint(t *testing.T) {
+	tests := []struct {
+		name          string
+		queries       []string
+		pages         map[string][]engine.Page
+		fail          map[string]int
+		wantErr       bool
+		wantSaved     bool
+		wantCompleted []string
+		wantCursors   map[string]string
+	}{
+		{
+			name:  "全部成功时删除检查点",
+			pages: map[string][]engine.Page{"a": {page("1.1.1.1", "")}, "b": {page("2.2.2.2", "")}},
+		},
+		{
+			name:          "获取第一页之前失败时保存检查点",
+			pages:         map[string][]engine.Page{"a": {page("1.1.1.1", "")}, "b": {page("2.2.2.2", "")}},
+			fail:          map[string]int{"b": 0},
+			wantSaved:     true,
+			wantCompleted: []string{"a"},
+		},
+		{
+			name:      "唯一的查询在第一页之前失败",
+			queries:   []string{"a"},
+			pages:     map[string][]engine.Page{"a": {page("1.1.1.1", "")}},
+			fail:      map[string]int{"a": 0},
+			wantErr:   true,
+			wantSaved: true,
+		},
+		{
+			name:          "翻页中失败时保存下一页的位置",
+			pages:         map[string][]engine.Page{"a": {page("1.1.1.1", "")}, "b": {page("2.2.2.2", "2"), page("3.3.3.3", "")}},
+			fail:          map[string]int{"b": 1},
+			wantSaved:     true,
+			wantCompleted: []string{"a"},
+			wantCursors:   map[string]string{"b": "2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queries := tt.queries
+			if queries == nil {
+				queries = []string{"a", "b"}
+			}
+			input, out := writeQueries(t, queries...)
+			eng := &fakeEngine{pages: tt.pages, fail: tt.fail, cursors: make(map[string]string)}
+
+			err := ProcessFile(context.Background(), eng, input, out, engine.Options{}, BatchOptions{Format: output.FormatCSV})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProcessFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			cp, err := LoadCheckpoint(CheckpointPath(out))
+			if !tt.wantSaved {
+				if !errors.Is(err, os.ErrNotExist) {
+					t.Fatalf("检查点应已删除, LoadCheckpoint() error = %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("检查点应已保存: %v", err)
+			}
+			if !slices.Equal(cp.Completed, tt.wantCompleted) {
+				t.Errorf("Completed = %v, want %v", cp.Completed, tt.wantCompleted)
+			}
+			if len(cp.Cursors) != len(tt.wantCursors) {
+				t.Errorf("Cursors = %v, want %v", cp.Cursors, tt.wantCursors)
+			}
+			for q, c := range tt.wantCursors {
+				if cp.Cursor(q) != c {
+					t.Errorf("Cursor(%q) = %q, want %q", q, cp.Cursor(q), c)
+				}
+			}
+		})
+	}
+}
+
+func TestProcessFileResume(t *testing.T) {
+	input, out := writeQueries(t, "a", "b", "c")
+
+	// 上次运行完成了 a，b 获取了第一页
+	cp := NewCheckpoint(CheckpointPath(out), "fake", input, out)
+	if err := cp.Complete("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cp.SetCursor("b", "2"); err != nil {
+		t.Fatal(err)
+	}
+	w, err := output.NewWriter(output.FormatCSV, out, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write([]asset.Asset{{IP: "1.1.1.1", Port: 80}}, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	eng := &fakeEngine{
+		pages:   map[string][]engine.Page{"b": {page("2.2.2.2", "")}, "c": {page("3.3.3.3", "")}},
+		cursors: make(map[string]string),
+	}
+	err = ProcessFile(context.Background(), eng, input, out, engine.Options{}, BatchOptions{Format: output.FormatCSV, Resume: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query      string
+		wantCalled bool
+		wantCursor string
+	}{
+		{"a", false, ""},
+		{"b", true, "2"},
+		{"c", true, ""},
+	}
+	for _, tt := range tests {
+		cursor, called := eng.cursors[tt.query]
+		if called != tt.wantCalled || cursor != tt.wantCursor {
+			t.Errorf("查询 %s: called = %v, cursor = %q, want %v, %q", tt.query, called, cursor, tt.wantCalled, tt.wantCursor)
+		}
+	}
+
+	// 继续时追加到上次的结果之后，全部完成后删除检查点
+	content, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "1.1.1.1") || !strings.Contains(string(content), "3.3.3.3") {
+		t.Errorf("输出文件应保留上次的结果并追加本次结果:\n%s", content)
+	}
+	if _, err := os.Stat(CheckpointPath(out)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("全部完成后检查点应已删除, Stat() error = %v", err)
+	}
+}
+
+func TestOpenCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "result.csv")
+	saved := NewCheckpoint(CheckpointPath(out), "hunter", "queries.txt", out)
+	if err := saved.Complete("a"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		engine      string
+		output      string
+		resume      bool
+		wantResumed bool
+		wantErr     bool
+	}{
+		{"不继续时重新开始", "hunter", out, false, false, false},
+		{"继续", "hunter", out, true, true, false},
+		{"检查点不存在时从头开始", "hunter", filepath.Join(dir, "other.csv"), true, false, false},
+		{"引擎不一致", "fofa", out, true, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cp, resumed, err := openCheckpoint(tt.engine, "queries.txt", tt.output, tt.resume)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("openCheckpoint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if resumed != tt.wantResumed || cp.Done("a") != tt.wantResumed {
+				t.Errorf("openCheckpoint() resumed = %v, Done(a) = %v, want %v", resumed, cp.Done("a"), tt.wantResumed)
+			}
+		})
+	}
+}
@@ -2,247 +2,294 @@ package fileutil
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
-	"time"
+	"sync"
 
-	"github.com/yaxigin/mto/pkg/fofa"
-	"github.com/yaxigin/mto/pkg/hunter"
-	"github.com/yaxigin/mto/pkg/quake"
+	"github.com/yaxigin/mto/pkg/asset"
+	"github.com/yaxigin/mto/pkg/engine"
+	"github.com/yaxigin/mto/pkg/notify"
+	"github.com/yaxigin/mto/pkg/output"
+	"github.com/yaxigin/mto/pkg/store"
 
 	"github.com/projectdiscovery/gologger"
 )
 
-// ProcessFofaFile 处理fofa批量查询文件
-func ProcessFofaFile(inputFile, outputFile string, maxResults int, useNext bool) error {
+// ReadQueries 读取查询文件，忽略空行
+func ReadQueries(inputFile string) ([]string, error) {
 	// 验证输入
 	if inputFile == "" {
-		return fmt.Errorf("输入文件路径不能为空")
-	}
-	if outputFile == "" {
-		outputFile = "fofa.csv"
-		gologger.Info().Msgf("未指定输出文件，使用默认文件名: %s", outputFile)
+		return nil, fmt.Errorf("输入文件路径不能为空")
 	}
 
-	// 读取输入文件
 	gologger.Info().Msgf("读取输入文件: %s", inputFile)
 	file, err := os.Open(inputFile)
 	if err != nil {
 		gologger.Error().Msgf("打开文件失败: %v", err)
-		return fmt.Errorf("打开文件失败: %v", err)
+		return nil, fmt.Errorf("打开文件失败: %v", err)
 	}
 	defer file.Close()
 
-	// 首先计算总行数，以便显示进度
-	lineCount := 0
-	tmpScanner := bufio.NewScanner(file)
-	for tmpScanner.Scan() {
-		if strings.TrimSpace(tmpScanner.Text()) != "" {
-			lineCount++
-		}
-	}
-	if tmpScanner.Err() != nil {
-		gologger.Error().Msgf("计算行数时出错: %v", tmpScanner.Err())
-	}
-
-	// 重置文件指针到开头
-	file.Seek(0, 0)
-
-	// 处理每一行
-	gologger.Info().Msgf("开始处理查询，共 %d 条", lineCount)
+	var queries []string
 	scanner := bufio.NewScanner(file)
-	processed := 0
-	success := 0
-	failed := 0
-
 	for scanner.Scan() {
-		query := strings.TrimSpace(scanner.Text())
-		if query == "" {
-			continue
-		}
-
-		processed++
-		// 调用 fofa.FOF 处理每一行
-		gologger.Info().Msgf("[%d/%d] 处理查询: %s", processed, lineCount, query)
-
-		// 使用传入的最大结果数量
-		maxLimit := maxResults
-
-		if err := fofa.FOF(query, outputFile, maxLimit, useNext); err != nil {
-			gologger.Warning().Msgf("[%d/%d] 处理失败: %v", processed, lineCount, err)
-			failed++
-			continue // 继续处理下一行，而不是直接返回错误
+		if query := strings.TrimSpace(scanner.Text()); query != "" {
+			queries = append(queries, query)
 		}
-		success++
-	}
-
-	// 检查是否成功写入了文件
-	if fi, err := os.Stat(outputFile); err != nil || fi.Size() == 0 {
-		gologger.Error().Msgf("输出文件为空或不存在")
-		return fmt.Errorf("输出文件为空或不存在")
 	}
 
 	// 检查扫描器错误
 	if err := scanner.Err(); err != nil {
 		gologger.Error().Msgf("读取文件出错: %v", err)
-		return fmt.Errorf("读取文件出错: %v", err)
+		return nil, fmt.Errorf("读取文件出错: %v", err)
 	}
 
-	// 输出最终统计信息
-	gologger.Info().Msgf("批量处理完成: 总计 %d 条查询, 成功 %d 条, 失败 %d 条", lineCount, success, failed)
-	gologger.Info().Msgf("结果已保存到: %s", outputFile)
-
-	return nil
+	return queries, nil
 }
 
-// 其他文件处理函数
+// BatchOptions 批量查询参数
+type BatchOptions struct {
+	Concurrency int  // 同时执行的查询数量，小于1时按1处理
+	Resume      bool // 从检查点继续上次未完成的批量查询
 
-// ProcessQuakeFile 处理Quake批量查询文件
-func ProcessQuakeFile(inputFile, outputFile string, months int) error {
-	// 验证输入
-	if inputFile == "" {
-		return fmt.Errorf("输入文件路径不能为空")
-	}
+	Format output.Format // 输出文件格式
+	Store  *store.Store  // 不为空时同时写入资产库
+
+	Notifier *notify.Notifier // 不为空时在完成后发送通知
+
+	// OnAssets 每写入一页结果后调用，调用时已持有写锁
+	OnAssets func(assets []asset.Asset, query string)
+}
+
+// ProcessFile 使用指定引擎处理批量查询文件，所有查询的结果追加写入同一个文件，每条记录都带有产生它的查询语句
+// 查询由 batch.Concurrency 个协程并发执行，请求速率由各引擎的限速器控制，写文件始终串行
+// 每获取一页结果就写入文件并更新检查点，中断后可以使用 batch.Resume 从断点继续
+func ProcessFile(ctx context.Context, eng engine.Engine, inputFile, outputFile string, opts engine.Options, batch BatchOptions) error {
 	if outputFile == "" {
-		outputFile = "quake.csv"
+		outputFile = eng.Name() + batch.Format.Ext()
 		gologger.Info().Msgf("未指定输出文件，使用默认文件名: %s", outputFile)
 	}
 
-	// 读取输入文件
-	gologger.Info().Msgf("读取输入文件: %s", inputFile)
-	file, err := os.Open(inputFile)
+	queries, err := ReadQueries(inputFile)
 	if err != nil {
-		gologger.Error().Msgf("打开文件失败: %v", err)
-		return fmt.Errorf("打开文件失败: %v", err)
+		return err
 	}
-	defer file.Close()
+	queries = dedupeQueries(queries)
 
-	// 首先计算总行数，以便显示进度
-	lineCount := 0
-	tmpScanner := bufio.NewScanner(file)
-	for tmpScanner.Scan() {
-		if strings.TrimSpace(tmpScanner.Text()) != "" {
-			lineCount++
-		}
-	}
-	if tmpScanner.Err() != nil {
-		gologger.Error().Msgf("计算行数时出错: %v", tmpScanner.Err())
+	cp, resumed, err := openCheckpoint(eng.Name(), inputFile, outputFile, batch.Resume)
+	if err != nil {
+		return err
 	}
 
-	// 重置文件指针到开头
-	file.Seek(0, 0)
-
-	// 处理每一行
-	gologger.Info().Msgf("开始处理Quake查询，共 %d 条", lineCount)
-	scanner := bufio.NewScanner(file)
-	processed := 0
-	success := 0
-	failed := 0
-
-	for scanner.Scan() {
-		query := strings.TrimSpace(scanner.Text())
-		if query == "" {
-			continue
-		}
-
-		processed++
-		// 调用 quake.QUF 处理每一行
-		gologger.Info().Msgf("[%d/%d] 处理Quake查询: %s", processed, lineCount, query)
-		if err := quake.QUF(query, outputFile, months); err != nil {
-			gologger.Warning().Msgf("[%d/%d] 处理失败: %v", processed, lineCount, err)
-			failed++
-			continue
-		}
-		success++
+	// 输出文件在整个批量查询期间只打开一次，从检查点继续时追加到上次的结果之后，否则重新写入
+	writer, err := output.NewWriter(batch.Format, outputFile, resumed)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+
+	workers := max(batch.Concurrency, 1)
+	lineCount := len(queries)
+	gologger.Info().Msgf("开始处理%s查询，共 %d 条，并发数 %d", eng.Name(), lineCount, workers)
+
+	var (
+		mu      sync.Mutex // 保护输出、检查点和统计信息
+		success int
+		failed  int
+		skipped int
+		rows    int           // 本次写入的资产数量
+		found   []asset.Asset // 需要通知时收集本次写入的资产
+		wg      sync.WaitGroup
+	)
+	jobs := make(chan int)
+
+	// 密钥无效或额度不足时剩余的查询也会失败，停止分配新的查询，保留进度用于继续
+	ctx, abort := context.WithCancelCause(ctx)
+	defer abort(nil)
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				query := queries[i]
+
+				mu.Lock()
+				done, cursor := cp.Done(query), cp.Cursor(query)
+				if done {
+					skipped++
+				}
+				mu.Unlock()
+				if done {
+					gologger.Info().Msgf("[%d/%d] 已完成，跳过: %s", i+1, lineCount, query)
+					continue
+				}
+				gologger.Info().Msgf("[%d/%d] 处理查询: %s", i+1, lineCount, query)
+
+				// 每获取一页就写入文件并记录下一页的位置，写入失败时不再推进检查点
+				var writeErr error
+				queryOpts := opts
+				queryOpts.Cursor = cursor
+				queryOpts.OnPage = func(p engine.Page) {
+					mu.Lock()
+					defer mu.Unlock()
+					if writeErr != nil {
+						return
+					}
+
+					// 输出链接，便于和其他工具配合使用
+					output.Print(os.Stdout, p.Assets, output.ModeLinks)
+
+					if writeErr = writer.Write(p.Assets, query); writeErr != nil {
+						return
+					}
+					rows += len(p.Assets)
+					if batch.Notifier != nil {
+						found = append(found, p.Assets...)
+					}
+					if batch.OnAssets != nil {
+						batch.OnAssets(p.Assets, query)
+					}
+					if batch.Store != nil {
+						if err := batch.Store.Upsert(p.Assets, query); err != nil {
+							gologger.Warning().Msgf("写入资产库失败: %v", err)
+						}
+					}
+					if err := cp.SetCursor(query, p.Next); err != nil {
+						gologger.Warning().Msgf("%v", err)
+					}
+				}
+
+				_, err := eng.Search(ctx, query, queryOpts)
+
+				mu.Lock()
+				switch {
+				case err != nil && ctx.Err() != nil:
+					gologger.Warning().Msgf("[%d/%d] 已中断: %v", i+1, lineCount, err)
+					failed++
+				case errors.Is(err, engine.ErrAuth) || errors.Is(err, engine.ErrQuota):
+					gologger.Error().Msgf("[%d/%d] 处理失败: %v，停止批量查询", i+1, lineCount, err)
+					failed++
+					abort(err)
+				case err != nil:
+					gologger.Warning().Msgf("[%d/%d] 处理失败: %v", i+1, lineCount, err)
+					failed++ // 继续处理下一行，而不是直接返回错误
+				case writeErr != nil:
+					gologger.Warning().Msgf("[%d/%d] 写入数据失败: %v", i+1, lineCount, writeErr)
+					failed++
+				default:
+					if err := cp.Complete(query); err != nil {
+						gologger.Warning().Msgf("%v", err)
+					}
+					success++
+				}
+				mu.Unlock()
+			}
+		}()
 	}
 
-	// 检查扫描器错误
-	if err := scanner.Err(); err != nil {
-		gologger.Error().Msgf("读取文件出错: %v", err)
-		return fmt.Errorf("读取文件出错: %v", err)
+	// 被中断时不再分配新的查询，已获取的结果都已写入文件
+feed:
+	for i := range queries {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
 	}
+	close(jobs)
+	wg.Wait()
 
 	// 输出最终统计信息
-	gologger.Info().Msgf("批量处理完成: 总计 %d 条查询, 成功 %d 条, 失败 %d 条", lineCount, success, failed)
+	gologger.Info().Msgf("批量处理完成: 总计 %d 条查询, 成功 %d 条, 失败 %d 条, 跳过 %d 条, 写入 %d 条结果", lineCount, success, failed, skipped, rows)
 	gologger.Info().Msgf("结果已保存到: %s", outputFile)
 
-	return nil
-}
-
-// ProcessHunterFile 处理 Hunter 批量查询文件
-func ProcessHunterFile(inputFile, outputFile string) error {
-	// 验证输入
-	if inputFile == "" {
-		return fmt.Errorf("输入文件路径不能为空")
-	}
-	if outputFile == "" {
-		outputFile = "hunter.csv"
-		gologger.Info().Msgf("未指定输出文件，使用默认文件名: %s", outputFile)
+	title := fmt.Sprintf("%s 批量查询完成", eng.Name())
+	if ctx.Err() != nil {
+		title = fmt.Sprintf("%s 批量查询已中断", eng.Name())
 	}
-
-	// 读取输入文件
-	gologger.Info().Msgf("读取输入文件: %s", inputFile)
-	file, err := os.Open(inputFile)
+	// 被中断时仍然通知已获取的结果
+	notifyCtx, cancel := notify.SendContext(ctx)
+	defer cancel()
+	err = batch.Notifier.Notify(notifyCtx, notify.Event{
+		Title:  title,
+		Source: inputFile,
+		Summary: []string{
+			fmt.Sprintf("查询 %d 条，成功 %d 条，失败 %d 条，跳过 %d 条", lineCount, success, failed, skipped),
+			fmt.Sprintf("结果文件: %s", outputFile),
+		},
+		Assets: asset.Dedupe(found),
+	})
 	if err != nil {
-		gologger.Error().Msgf("打开文件失败: %v", err)
-		return fmt.Errorf("打开文件失败: %v", err)
+		gologger.Warning().Msgf("发送通知失败: %v", err)
 	}
-	defer file.Close()
 
-	// 首先计算总行数，以便显示进度
-	lineCount := 0
-	tmpScanner := bufio.NewScanner(file)
-	for tmpScanner.Scan() {
-		if strings.TrimSpace(tmpScanner.Text()) != "" {
-			lineCount++
+	// 全部完成后删除检查点，否则保留用于继续
+	// 查询在获取第一页之前失败或中断时还没有写入过检查点，提示之前先保存
+	if ctx.Err() == nil && failed == 0 {
+		if err := cp.Remove(); err != nil {
+			gologger.Warning().Msgf("删除检查点失败: %v", err)
 		}
+	} else if err := cp.save(); err != nil {
+		gologger.Warning().Msgf("保存检查点失败: %v", err)
+	} else if ctx.Err() != nil {
+		pending := lineCount - success - skipped
+		gologger.Warning().Msgf("批量查询已中断，%d 条查询未完成，进度已保存到 %s，可使用 --resume 继续", pending, cp.path)
+	} else {
+		gologger.Info().Msgf("进度已保存到 %s，可使用 --resume 重试失败的查询", cp.path)
 	}
-	if tmpScanner.Err() != nil {
-		gologger.Error().Msgf("计算行数时出错: %v", tmpScanner.Err())
-	}
-
-	// 重置文件指针到开头
-	file.Seek(0, 0)
 
-	// 处理每一行
-	gologger.Info().Msgf("开始处理Hunter查询，共 %d 条", lineCount)
-	scanner := bufio.NewScanner(file)
-	processed := 0
-	success := 0
-	failed := 0
+	// 检查是否写入了结果
+	if rows == 0 && skipped == 0 {
+		gologger.Error().Msgf("没有获取到任何结果")
+		return fmt.Errorf("没有获取到任何结果")
+	}
 
-	for scanner.Scan() {
-		query := strings.TrimSpace(scanner.Text())
-		if query == "" {
-			continue
-		}
+	return nil
+}
 
-		processed++
-		// 调用 hunter.HUPILIANG 处理每一行
-		gologger.Info().Msgf("[%d/%d] 处理Hunter查询: %s", processed, lineCount, query)
-		if err := hunter.HUPILIANG(query, 0, outputFile); err != nil {
-			gologger.Warning().Msgf("[%d/%d] 处理失败: %v", processed, lineCount, err)
-			failed++
-			// 失败后等待一下再继续下一个查询
-			time.Sleep(2 * time.Second)
-			continue
+// openCheckpoint 继续运行时读取已有的检查点，否则创建新的检查点
+func openCheckpoint(engineName, inputFile, outputFile string, resume bool) (*Checkpoint, bool, error) {
+	path := CheckpointPath(outputFile)
+	if !resume {
+		if _, err := os.Stat(path); err == nil {
+			gologger.Warning().Msgf("发现未完成的检查点 %s，本次将重新开始，如需继续请使用 --resume", path)
 		}
-		success++
-
-		// 每个查询之间添加延时
-		time.Sleep(3 * time.Second)
+		return NewCheckpoint(path, engineName, inputFile, outputFile), false, nil
 	}
 
-	// 检查扫描器错误
-	if err := scanner.Err(); err != nil {
-		gologger.Error().Msgf("读取文件出错: %v", err)
-		return fmt.Errorf("读取文件出错: %v", err)
+	cp, err := LoadCheckpoint(path)
+	if errors.Is(err, os.ErrNotExist) {
+		gologger.Warning().Msgf("未找到检查点 %s，从头开始", path)
+		return NewCheckpoint(path, engineName, inputFile, outputFile), false, nil
 	}
+	if err != nil {
+		return nil, false, err
+	}
+	if cp.Engine != engineName {
+		return nil, false, fmt.Errorf("检查点属于 %s 引擎，不能用于 %s", cp.Engine, engineName)
+	}
+	gologger.Info().Msgf("从检查点继续: 已完成 %d 条查询, %d 条查询翻页中", len(cp.Completed), len(cp.Cursors))
+	return cp, true, nil
+}
 
-	// 输出最终统计信息
-	gologger.Info().Msgf("批量处理完成: 总计 %d 条查询, 成功 %d 条, 失败 %d 条", lineCount, success, failed)
-	gologger.Info().Msgf("结果已保存到: %s", outputFile)
-
-	return nil
+// dedupeQueries 去掉重复的查询语句，检查点按查询语句记录进度
+func dedupeQueries(queries []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, q := range queries {
+		if seen[q] {
+			gologger.Warning().Msgf("跳过重复的查询: %s", q)
+			continue
+		}
+		seen[q] = true
+		result = append(result, q)
+	}
+	return result
 }
@@ -1,24 +1,28 @@
 package fofa
 
 import (
+	"context"
 	"encoding/base64"
-	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
-	"github.com/yaxigin/mto/pkg/config"
-
-	"github.com/olekukonko/tablewriter"
-	"github.com/parnurzeal/gorequest"
-	"github.com/projectdiscovery/gologger"
-	"gopkg.in/yaml.v2"
+	"github.com/yaxigin/mto/pkg/asset"
+	"github.com/yaxigin/mto/pkg/engine"
+	"github.com/yaxigin/mto/pkg/query"
+	"github.com/yaxigin/mto/pkg/ratelimit"
 )
 
 // 传统翻页API响应结构
 type Fofa struct {
+	Error   bool       `json:"error"`
+	Errmsg  string     `json:"errmsg"`
 	Results [][]string `json:"results"`
 	Size    int        `json:"size"`
 	Page    int        `json:"page"`
@@ -29,662 +33,449 @@ type Fofa struct {
 // 连续翻页API响应结构
 type FofaNext struct {
 	Error   bool       `json:"error"`
+	Errmsg  string     `json:"errmsg"`
 	Size    int        `json:"size"`
 	Page    int        `json:"page"`
 	Results [][]string `json:"results"`
 	Next    string     `json:"next"`
 }
 
+// 账号信息接口响应结构
+type FofaUser struct {
+	Error          bool   `json:"error"`
+	Errmsg         string `json:"errmsg"`
+	Email          string `json:"email"`
+	Username       string `json:"username"`
+	FofaPoint      int    `json:"fofa_point"`
+	RemainAPIQuery int    `json:"remain_api_query"`
+	RemainAPIData  int    `json:"remain_api_data"`
+	IsVIP          bool   `json:"isvip"`
+	VIPLevel       int    `json:"vip_level"`
+}
+
 // API相关常量
 const (
 	DefaultPageSize = "1000"
 	FofaAPIURL      = "https://fofa.info/api/v1/search/all"  // 传统翻页API
 	FofaNextAPIURL  = "https://fofa.info/api/v1/search/next" // 连续翻页API
+	FofaInfoURL     = "https://fofa.info/api/v1/info/my"     // 账号信息API
 	DefaultFields   = "ip,domain,port,protocol,link,title,server"
 	MaxResults      = 10000 // FOFA API最大支持查询10000条结果
 )
 
-type Config struct {
-	Fofa struct {
-		Key string `yaml:"key"`
-	}
-	Chinaz struct {
-		Key string `yaml:"key"`
-	}
-}
-
-// FOCMD 处理单个查询并显示结果
-func FOCMD(s string, h bool, onlyIP bool, maxResults int, useNext bool) error {
-	// 验证输入
-	if s == "" {
-		return fmt.Errorf("查询语句不能为空")
-	}
-
-	// 读取配置
-	conf := Config{}
-	content, err := os.ReadFile(config.GetConfigPath())
-	if err != nil {
-		gologger.Error().Msgf("配置文件读取错误: %v", err)
-		return err
-	}
-	if err := yaml.Unmarshal(content, &conf); err != nil {
-		gologger.Error().Msgf("解析config.yaml出错: %v", err)
-		return err
-	}
-
-	// 验证API密钥
-	if conf.Fofa.Key == "" {
-		return fmt.Errorf("Fofa API密钥未配置，请在配置文件中设置")
-	}
-
-	// 处理查询语句
-	// 保存原始查询语句以便输出
-	originalQuery := s
-
-	// 检查查询语句是否包含逻辑运算符
-	if strings.Contains(s, "&&") || strings.Contains(s, "||") {
-		// 处理复杂查询（包含逻辑运算符）
-		s = processComplexQuery(s)
-	} else if strings.Contains(s, "=") {
-		// 处理简单查询（单个键值对）
-		s = processSimpleQuery(s)
-	}
-
-	gologger.Info().Msgf("原始查询语句: %s", originalQuery)
-	gologger.Info().Msgf("处理后的查询语句: %s", s)
-
-	// Base64编码查询语句
-	queryBase64 := base64.StdEncoding.EncodeToString([]byte(s))
-	gologger.Debug().Msgf("base64编码后的查询语句: %s", queryBase64)
-
-	// 初始化结果列表
-	var allResults [][]string
-
-	// 总结果数
-	totalResults := 0
-
-	// 处理用户指定的最大结果数量
-	maxLimit := maxResults
-	if maxLimit <= 0 {
-		// 如果用户没有指定或指定为0，则使用默认值1000
-		maxLimit = 1000
-	} else if maxLimit > MaxResults {
-		// 如果用户指定的数量超过API限制，则使用API限制
-		maxLimit = MaxResults
-		gologger.Warning().Msgf("指定的最大结果数量(%d)超过API限制(%d)，将只获取前%d条结果", maxResults, MaxResults, MaxResults)
-	}
-
-	// 根据用户选择使用不同的翻页方式
-	if useNext {
-		// 使用连续翻页接口
-		gologger.Info().Msgf("使用连续翻页接口获取数据")
-
-		// 初始化next参数，第一次请求不需要指定next参数
-		nextParam := ""
-
-		// 循环获取所有页的数据，直到没有更多结果或者达到最大限制
-		for {
-			// 连续翻页接口每页固定使用 10000 条结果
-			const pageSize = 10000
-
-			// 构建请求URL
-			var url string
-			if nextParam == "" {
-				// 第一次请求，不需要指定next参数
-				url = fmt.Sprintf("%s?key=%s&qbase64=%s&size=%d&fields=%s",
-					FofaNextAPIURL, conf.Fofa.Key, queryBase64, pageSize, DefaultFields)
-			} else {
-				// 后续请求，带上next参数
-				url = fmt.Sprintf("%s?key=%s&qbase64=%s&size=%d&fields=%s&next=%s",
-					FofaNextAPIURL, conf.Fofa.Key, queryBase64, pageSize, DefaultFields, nextParam)
-			}
-
-			request := gorequest.New()
-			resp, body, errs := request.Get(url).
-				Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/55.0.2883.87 Safari/537.36").
-				Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8").
-				Set("Accept-Language", "zh-CN,zh;q=0.8").
-				End()
-
-			if len(errs) > 0 {
-				gologger.Error().Msgf("请求失败: %v", errs[0])
-				return errs[0]
-			}
-
-			if resp.StatusCode != 200 {
-				gologger.Error().Msgf("请求失败，状态码: %d", resp.StatusCode)
-				return fmt.Errorf("请求失败，状态码: %d", resp.StatusCode)
-			}
-
-			var d FofaNext
-			if err := json.Unmarshal([]byte(body), &d); err != nil {
-				gologger.Error().Msgf("解析响应失败: %v", err)
-				return fmt.Errorf("解析响应失败: %v", err)
-			}
-
-			if d.Error {
-				gologger.Error().Msgf("请求出错: %s", body)
-				return fmt.Errorf("请求出错: %s", body)
-			}
-
-			if len(d.Results) == 0 {
-				break
-			}
-
-			// 添加当前页的结果到总结果中
-			allResults = append(allResults, d.Results...)
-			totalResults += len(d.Results)
-
-			gologger.Info().Msgf("当前已获取 %d 条结果，查询总数量: %d", totalResults, d.Size)
+// AllFields 默认请求的字段，后面几项需要较高的会员等级，账号没有权限时只请求 DefaultFields
+const AllFields = DefaultFields + ",host,icp,as_organization,country,lastupdatetime"
 
-			// 如果没有next参数，说明已经没有更多结果
-			if d.Next == "" {
-				gologger.Info().Msgf("没有更多结果了")
-				break
-			}
-
-			// 连续翻页接口不使用 maxLimit 参数，获取所有可用结果
-
-			// 更新next参数，继续获取下一页
-			nextParam = d.Next
-
-			// 添加延时，避免请求过快
-			time.Sleep(1 * time.Second)
-		}
-	} else {
-		// 使用传统查询接口，不使用翻页
-		gologger.Info().Msgf("使用传统查询接口获取数据")
-
-		// 使用用户指定的数量
-		pageSize := maxLimit
-
-		// 构建请求URL
-		url := fmt.Sprintf("%s?key=%s&qbase64=%s&page=1&size=%d&fields=%s",
-			FofaAPIURL, conf.Fofa.Key, queryBase64, pageSize, DefaultFields)
-
-		// 发送请求
-		request := gorequest.New()
-		resp, body, errs := request.Get(url).
-			Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/55.0.2883.87 Safari/537.36").
-			Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8").
-			Set("Accept-Language", "zh-CN,zh;q=0.8").
-			End()
-
-		// 处理请求错误
-		if len(errs) > 0 {
-			gologger.Error().Msgf("请求失败: %v", errs[0])
-			return errs[0]
-		}
-
-		// 检查HTTP状态码
-		if resp.StatusCode != 200 {
-			gologger.Error().Msgf("请求失败，状态码: %d", resp.StatusCode)
-			return fmt.Errorf("请求失败，状态码: %d", resp.StatusCode)
-		}
+// Client FOFA API 客户端，实现 engine.Engine 接口
+// 客户端不读取配置文件也不写终端，日志通过 engine.ClientOptions.Logger 输出
+type Client struct {
+	key     string
+	opts    engine.ClientOptions
+	log     engine.Logger
+	limiter *ratelimit.Limiter
 
-		// 解析响应
-		var d Fofa
-		if err := json.Unmarshal([]byte(body), &d); err != nil {
-			gologger.Error().Msgf("解析响应失败: %v", err)
-			return fmt.Errorf("解析响应失败: %v", err)
-		}
-
-		// 如果没有结果，跳出循环
-		if len(d.Results) == 0 {
-			gologger.Warning().Msgf("未找到结果: %s", s)
-			return fmt.Errorf("未找到结果: %s", s)
-		}
+	basic atomic.Bool // 账号没有权限获取 AllFields 中的字段，只请求 DefaultFields
+}
 
-		// 添加结果到总结果中
-		allResults = append(allResults, d.Results...)
-		totalResults += len(d.Results)
+// NewClient 使用API密钥创建客户端
+func NewClient(key string, opts engine.ClientOptions) *Client {
+	return &Client{key: key, opts: opts, log: opts.Log(), limiter: opts.Limiter("fofa", defaultRate)}
+}
 
-		// 显示当前进度和总数量
-		gologger.Info().Msgf("获取到 %d 条结果，查询总数量: %d", totalResults, d.Size)
-	}
+func init() {
+	engine.Register("fofa", func(key string, opts engine.ClientOptions) engine.Engine {
+		return NewClient(key, opts)
+	})
+}
 
-	// 显示总结果数量
-	gologger.Info().Msgf("总共找到 %d 条结果", totalResults)
+// Name 返回引擎名称
+func (c *Client) Name() string {
+	return "fofa"
+}
 
-	// 根据选项输出结果
-	if onlyIP {
-		// 只输出IP
-		uniqueIP := deduplicateIP(allResults)
-		gologger.Info().Msgf("去重后共 %d 个唯一IP", len(uniqueIP))
-		for _, ip := range uniqueIP {
-			fmt.Println(ip)
-		}
-	} else if h {
-		// 只输出链接
-		hata(allResults)
-	} else {
-		// 表格输出所有信息
-		data(allResults)
-	}
+// Description 返回模块简介
+func (c *Client) Description() string {
+	return "从fofa提取资产信息。"
+}
 
-	return nil
+// Syntax 返回FOFA语法参考
+func (c *Client) Syntax() string {
+	return syntaxHelp
 }
 
-// FOF 处理单个查询并将结果写入文件
-func FOF(s string, outputFile string, maxResults int, useNext bool) error {
-	// 验证输入
+// prepareQuery 检查查询语句、补全引号并进行Base64编码
+func (c *Client) prepareQuery(s string, opts engine.Options) (string, error) {
 	if s == "" {
-		return fmt.Errorf("查询语句不能为空")
+		return "", fmt.Errorf("查询语句不能为空")
+	}
+	if c.key == "" {
+		return "", fmt.Errorf("Fofa API密钥未配置")
 	}
-	if outputFile == "" {
-		return fmt.Errorf("输出文件路径不能为空")
+	if err := engine.Preflight(query.FOFA, s, opts, c.log); err != nil {
+		return "", err
 	}
 
-	// 读取配置
-	conf := Config{}
-	content, err := os.ReadFile(config.GetConfigPath())
+	normalized, err := query.Normalize(query.FOFA, s)
 	if err != nil {
-		gologger.Error().Msgf("配置文件读取错误: %v", err)
-		return fmt.Errorf("配置文件读取错误: %v", err)
-	}
-	if err := yaml.Unmarshal(content, &conf); err != nil {
-		gologger.Error().Msgf("解析config.yaml出错: %v", err)
-		return fmt.Errorf("解析config.yaml出错: %v", err)
+		return "", err
 	}
 
-	// 验证API密钥
-	if conf.Fofa.Key == "" {
-		return fmt.Errorf("Fofa API密钥未配置，请在配置文件中设置")
-	}
+	c.log.Infof("原始查询语句: %s", s)
+	c.log.Infof("处理后的查询语句: %s", normalized)
 
-	// 处理查询语句
-	// 保存原始查询语句以便输出
-	originalQuery := s
+	// Base64编码查询语句
+	return base64.StdEncoding.EncodeToString([]byte(normalized)), nil
+}
 
-	// 检查查询语句是否包含逻辑运算符
-	if strings.Contains(s, "&&") || strings.Contains(s, "||") {
-		// 处理复杂查询（包含逻辑运算符）
-		s = processComplexQuery(s)
-	} else if strings.Contains(s, "=") {
-		// 处理简单查询（单个键值对）
-		s = processSimpleQuery(s)
+// Search 执行查询并返回全部资产
+func (c *Client) Search(ctx context.Context, s string, opts engine.Options) ([]asset.Asset, error) {
+	queryBase64, err := c.prepareQuery(s, opts)
+	if err != nil {
+		return nil, err
 	}
 
-	gologger.Debug().Msgf("原始查询语句: %s", originalQuery)
-	gologger.Debug().Msgf("处理后的查询语句: %s", s)
-
-	// Base64编码查询语句
-	queryBase64 := base64.StdEncoding.EncodeToString([]byte(s))
+	// 根据用户选择使用不同的翻页方式
+	// 连续翻页接口每页都会通知调用方，传统接口只有一页
+	if opts.UseNext {
+		return c.searchNext(ctx, queryBase64, opts)
+	}
+	fields, results, err := c.searchAll(ctx, queryBase64, opts.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	assets := toAssets(fields, results)
+	opts.Report(assets, "")
+	return assets, nil
+}
 
-	// 总结果数
-	totalResults := 0
+// searchAll 使用传统查询接口获取数据，不使用翻页
+func (c *Client) searchAll(ctx context.Context, queryBase64 string, maxResults int) ([]string, [][]string, error) {
+	c.log.Infof("使用传统查询接口获取数据")
 
 	// 处理用户指定的最大结果数量
-	maxLimit := maxResults
-	if maxLimit <= 0 {
+	pageSize := maxResults
+	if pageSize <= 0 {
 		// 如果用户没有指定或指定为0，则使用默认值1000
-		maxLimit = 1000
-	} else if maxLimit > MaxResults {
+		pageSize = 1000
+	} else if pageSize > MaxResults {
 		// 如果用户指定的数量超过API限制，则使用API限制
-		maxLimit = MaxResults
-		gologger.Warning().Msgf("指定的最大结果数量(%d)超过API限制(%d)，将只获取前%d条结果", maxResults, MaxResults, MaxResults)
+		pageSize = MaxResults
+		c.log.Warningf("指定的最大结果数量(%d)超过API限制(%d)，将只获取前%d条结果", maxResults, MaxResults, MaxResults)
 	}
 
-	// 初始化文件，写入表头
-	if err := initCSVFile(outputFile); err != nil {
-		gologger.Error().Msgf("初始化文件失败: %v", err)
-		return fmt.Errorf("初始化文件失败: %v", err)
+	params := url.Values{
+		"qbase64": {queryBase64},
+		"page":    {"1"},
+		"size":    {strconv.Itoa(pageSize)},
 	}
 
-	// 根据用户选择使用不同的翻页方式
-	if useNext {
-		// 使用连续翻页接口
-		gologger.Info().Msgf("使用连续翻页接口获取数据")
-
-		// 初始化next参数，第一次请求不需要指定next参数
-		nextParam := ""
-
-		// 循环获取所有页的数据，直到没有更多结果或者达到最大限制
-		for {
-			// 连续翻页接口每页固定使用 10000 条结果
-			const pageSize = 10000
-
-			// 构建请求URL
-			var url string
-			if nextParam == "" {
-				// 第一次请求
-				url = fmt.Sprintf("%s?key=%s&qbase64=%s&size=%d&fields=%s",
-					FofaNextAPIURL, conf.Fofa.Key, queryBase64, pageSize, DefaultFields)
-			} else {
-				// 后续请求，带上next参数
-				url = fmt.Sprintf("%s?key=%s&qbase64=%s&size=%d&fields=%s&next=%s",
-					FofaNextAPIURL, conf.Fofa.Key, queryBase64, pageSize, DefaultFields, nextParam)
-			}
-
-			// 发送请求
-			request := gorequest.New()
-			resp, body, errs := request.Get(url).
-				Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/55.0.2883.87 Safari/537.36").
-				Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8").
-				Set("Accept-Language", "zh-CN,zh;q=0.8").
-				End()
-
-			// 处理请求错误
-			if len(errs) > 0 {
-				gologger.Error().Msgf("请求失败: %v", errs[0])
-				return fmt.Errorf("请求失败: %v", errs[0])
-			}
-
-			// 检查HTTP状态码
-			if resp.StatusCode != 200 {
-				gologger.Error().Msgf("请求失败，状态码: %d", resp.StatusCode)
-				return fmt.Errorf("请求失败，状态码: %d", resp.StatusCode)
-			}
-
-			// 解析响应
-			var d FofaNext
-			if err := json.Unmarshal([]byte(body), &d); err != nil {
-				gologger.Error().Msgf("解析响应失败: %v", err)
-				return fmt.Errorf("解析响应失败: %v", err)
-			}
-
-			// 检查是否有错误
-			if d.Error {
-				gologger.Error().Msgf("请求出错: %s", body)
-				return fmt.Errorf("请求出错: %s", body)
-			}
-
-			// 如果没有结果，跳出循环
-			if len(d.Results) == 0 {
-				break
-			}
+	var d Fofa
+	fields, err := c.fetch(ctx, c.opts.URL(FofaAPIURL), params, &d)
+	if err != nil {
+		return nil, nil, err
+	}
 
-			// 更新总结果数量
-			totalResults += len(d.Results)
+	// 如果没有结果，直接返回
+	if len(d.Results) == 0 {
+		return nil, nil, fmt.Errorf("未找到结果")
+	}
 
-			// 显示当前进度
-			gologger.Info().Msgf("当前已获取 %d 条结果，总结果数量: %d", totalResults, d.Size)
+	// 显示当前进度和总数量
+	c.log.Infof("获取到 %d 条结果，查询总数量: %d", len(d.Results), d.Size)
 
-			// 输出当前页的链接
-			for _, result := range d.Results {
-				if len(result) > 4 { // 确保索引安全
-					fmt.Println(result[4]) // 输出链接
-				}
-			}
+	return fields, d.Results, nil
+}
 
-			// 将当前页的结果写入CSV文件
-			if err := AppendToCSV(d.Results, outputFile); err != nil {
-				gologger.Error().Msgf("写入数据失败: %v", err)
-				return fmt.Errorf("写入数据失败: %v", err)
-			}
+// searchNext 使用连续翻页接口获取所有可用结果，opts.Cursor 不为空时从该游标继续
+func (c *Client) searchNext(ctx context.Context, queryBase64 string, opts engine.Options) ([]asset.Asset, error) {
+	c.log.Infof("使用连续翻页接口获取数据")
 
-			// 如果没有next参数，说明已经没有更多结果
-			if d.Next == "" {
-				gologger.Info().Msgf("没有更多结果了")
-				break
-			}
+	// 连续翻页接口每页固定使用 10000 条结果
+	const pageSize = 10000
 
-			// 连续翻页接口不使用 maxLimit 参数，获取所有可用结果
+	var allResults []asset.Asset
 
-			// 更新next参数，继续获取下一页
-			nextParam = d.Next
+	// 初始化next参数，第一次请求不需要指定next参数，断点续传时从上次的游标继续
+	nextParam := opts.Cursor
+	if nextParam != "" {
+		c.log.Infof("从游标 %s 继续获取", nextParam)
+	}
 
-			// 添加延时，避免请求过快
-			time.Sleep(1 * time.Second)
+	// 循环获取所有页的数据，直到没有更多结果
+	for {
+		if err := ctx.Err(); err != nil {
+			return allResults, engine.Stop(ctx, err, len(allResults), "游标 "+nextParam, nextParam)
 		}
 
-	} else {
-		// 使用传统查询接口，不使用翻页
-		gologger.Info().Msgf("使用传统查询接口获取数据")
-
-		// 使用用户指定的数量
-		pageSize := maxLimit
-
-		// 构建请求URL
-		url := fmt.Sprintf("%s?key=%s&qbase64=%s&page=1&size=%d&fields=%s",
-			FofaAPIURL, conf.Fofa.Key, queryBase64, pageSize, DefaultFields)
-
-		// 发送请求
-		request := gorequest.New()
-		resp, body, errs := request.Get(url).
-			Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/55.0.2883.87 Safari/537.36").
-			Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8").
-			Set("Accept-Language", "zh-CN,zh;q=0.8").
-			End()
-
-		// 处理请求错误
-		if len(errs) > 0 {
-			gologger.Error().Msgf("请求失败: %v", errs[0])
-			return fmt.Errorf("请求失败: %v", errs[0])
+		params := url.Values{
+			"qbase64": {queryBase64},
+			"size":    {strconv.Itoa(pageSize)},
 		}
-
-		// 检查HTTP状态码
-		if resp.StatusCode != 200 {
-			gologger.Error().Msgf("请求失败，状态码: %d", resp.StatusCode)
-			return fmt.Errorf("请求失败，状态码: %d", resp.StatusCode)
+		if nextParam != "" {
+			// 后续请求，带上next参数
+			params.Set("next", nextParam)
 		}
 
-		// 解析响应
-		var d Fofa
-		if err := json.Unmarshal([]byte(body), &d); err != nil {
-			gologger.Error().Msgf("解析响应失败: %v", err)
-			return fmt.Errorf("解析响应失败: %v", err)
+		var d FofaNext
+		fields, err := c.fetch(ctx, c.opts.URL(FofaNextAPIURL), params, &d)
+		if err != nil {
+			return allResults, engine.Stop(ctx, err, len(allResults), "游标 "+nextParam, nextParam)
 		}
 
-		// 如果没有结果，跳出循环
 		if len(d.Results) == 0 {
-			gologger.Warning().Msgf("未找到结果: %s", s)
-			return fmt.Errorf("未找到结果: %s", s)
+			break
 		}
 
-		// 更新总结果数量
-		totalResults += len(d.Results)
-
-		// 显示当前进度和总数量
-		gologger.Info().Msgf("获取到 %d 条结果，查询总数量: %d", totalResults, d.Size)
+		// 添加当前页的结果到总结果中
+		pageResults := toAssets(fields, d.Results)
+		allResults = append(allResults, pageResults...)
+		opts.Report(pageResults, d.Next)
+		c.log.Infof("当前已获取 %d 条结果，查询总数量: %d", len(allResults), d.Size)
 
-		// 输出链接
-		for _, result := range d.Results {
-			if len(result) > 4 { // 确保索引安全
-				fmt.Println(result[4]) // 输出链接
-			}
+		// 如果没有next参数，说明已经没有更多结果
+		if d.Next == "" {
+			c.log.Infof("没有更多结果了")
+			break
 		}
 
-		// 将结果写入CSV文件
-		if err := AppendToCSV(d.Results, outputFile); err != nil {
-			gologger.Error().Msgf("写入数据失败: %v", err)
-			return fmt.Errorf("写入数据失败: %v", err)
-		}
+		// 更新next参数，继续获取下一页
+		nextParam = d.Next
 	}
 
-	// 显示总结果数量
-	gologger.Info().Msgf("总共找到 %d 条结果，已写入文件: %s", totalResults, outputFile)
-
-	return nil
+	if len(allResults) == 0 {
+		return nil, fmt.Errorf("未找到结果")
+	}
+	return allResults, nil
 }
 
-// WriteToCSV 将结果写入CSV文件 - 创建新文件并写入数据
-func WriteToCSV(results [][]string, outputFile string) error {
-	if outputFile == "" {
-		outputFile = "fofa.csv"
-		gologger.Info().Msgf("未指定输出文件，使用默认文件名: %s", outputFile)
+// Estimate 估算获取数据的开销，返回的数据量计入会员每月的API数据额度，超出部分消耗F点
+func (c *Client) Estimate(total int, opts engine.Options) engine.Estimate {
+	e := engine.Estimate{Total: total, Unit: "条数据"}
+	if opts.UseNext {
+		// 连续翻页接口获取全部结果，每页 10000 条
+		e.Fetch = total
+		e.Pages = engine.Pages(total, 10000)
+	} else {
+		pageSize := opts.MaxResults
+		if pageSize <= 0 {
+			pageSize = 1000
+		}
+		e.Fetch = min(total, pageSize, MaxResults)
+		e.Pages = 1
 	}
+	e.Cost = e.Fetch
+	return e
+}
 
-	// 创建或打开文件
-	gologger.Debug().Msgf("创建文件: %s", outputFile)
-	f, err := os.Create(outputFile)
+// Count 只获取查询结果总数
+func (c *Client) Count(ctx context.Context, s string, opts engine.Options) (int, error) {
+	queryBase64, err := c.prepareQuery(s, opts)
 	if err != nil {
-		gologger.Error().Msgf("创建文件失败: %v", err)
-		return fmt.Errorf("创建文件失败: %v", err)
+		return 0, err
 	}
-	defer f.Close()
-
-	// 写入 UTF-8 BOM
-	f.WriteString("\xEF\xBB\xBF")
-
-	writer := csv.NewWriter(f)
-	defer writer.Flush()
 
-	// 写入表头
-	headers := []string{"IP", "Domain", "Port", "Protocol", "Link", "Title", "Server"}
-	if err := writer.Write(headers); err != nil {
-		gologger.Error().Msgf("写入表头失败: %v", err)
-		return fmt.Errorf("写入表头失败: %v", err)
+	params := url.Values{
+		"qbase64": {queryBase64},
+		"page":    {"1"},
+		"size":    {"1"},
+		"fields":  {"ip"},
 	}
 
-	// 写入数据
-	gologger.Info().Msgf("正在写入 %d 条数据...", len(results))
-	for _, result := range results {
-		if err := writer.Write(result); err != nil {
-			gologger.Error().Msgf("写入数据行失败: %v", err)
-			return fmt.Errorf("写入数据行失败: %v", err)
-		}
+	var d Fofa
+	if err := c.makeRequest(ctx, c.opts.URL(FofaAPIURL), params, &d); err != nil {
+		return 0, err
 	}
+	return d.Size, nil
+}
 
-	gologger.Info().Msgf("成功写入 %d 条数据到文件: %s", len(results), outputFile)
-	return nil
+// vipLevels FOFA 会员等级的名称
+var vipLevels = map[int]string{
+	0: "注册用户",
+	1: "普通会员",
+	2: "高级会员",
+	3: "企业会员",
 }
 
-// initCSVFile 初始化CSV文件，写入表头
-func initCSVFile(outputFile string) error {
-	if outputFile == "" {
-		outputFile = "fofa.csv"
-		gologger.Info().Msgf("未指定输出文件，使用默认文件名: %s", outputFile)
+// Account 查询API密钥对应的账号信息，不消耗F点
+func (c *Client) Account(ctx context.Context) (engine.Account, error) {
+	if c.key == "" {
+		return engine.Account{}, fmt.Errorf("Fofa API密钥未配置")
 	}
 
-	// 创建或打开文件
-	f, err := os.Create(outputFile)
-	if err != nil {
-		gologger.Error().Msgf("创建文件失败: %v", err)
-		return fmt.Errorf("创建文件失败: %v", err)
+	var d FofaUser
+	if err := c.makeRequest(ctx, c.opts.URL(FofaInfoURL), url.Values{}, &d); err != nil {
+		return engine.Account{}, err
 	}
-	defer f.Close()
 
-	// 写入 UTF-8 BOM
-	f.WriteString("\xEF\xBB\xBF")
+	level, ok := vipLevels[d.VIPLevel]
+	if !ok {
+		level = fmt.Sprintf("会员等级 %d", d.VIPLevel)
+	}
+	if !d.IsVIP {
+		level = vipLevels[0]
+	}
+	user := d.Username
+	if user == "" {
+		user = d.Email
+	}
+	return engine.Account{
+		Engine:    "fofa",
+		Key:       engine.MaskKey(c.key),
+		User:      user,
+		Level:     level,
+		Quota:     d.FofaPoint,
+		QuotaUnit: "F点",
+		QueryLeft: d.RemainAPIQuery,
+	}, nil
+}
 
-	writer := csv.NewWriter(f)
-	defer writer.Flush()
+// defaultRate 未配置 rate 时的请求限速，单个密钥每秒最多发送一次请求
+var defaultRate = ratelimit.Rate{Every: time.Second, Burst: 1}
 
-	// 写入表头
-	headers := []string{"IP", "Domain", "Port", "Protocol", "Link", "Title", "Server"}
-	if err := writer.Write(headers); err != nil {
-		gologger.Error().Msgf("写入表头失败: %v", err)
-		return fmt.Errorf("写入表头失败: %v", err)
+// errorCodes FOFA 错误码对应的错误类型，未收录的错误码根据错误信息判断
+var errorCodes = map[string]error{
+	"-700":   engine.ErrAuth,   // 账号无效
+	"820000": engine.ErrSyntax, // 查询语法错误
+	"820001": engine.ErrField,  // 没有权限搜索相关字段
+	"820002": engine.ErrSyntax, // 查询语句不合法
+	"820031": engine.ErrQuota,  // F点余额不足
+}
+
+// apiError 将接口返回的 errmsg 转换为错误，errmsg 形如 "[820031] F点余额不足"
+func apiError(errmsg string) error {
+	code, msg := "", errmsg
+	if rest, ok := strings.CutPrefix(errmsg, "["); ok {
+		if c, m, ok := strings.Cut(rest, "]"); ok {
+			code, msg = c, strings.TrimSpace(m)
+		}
 	}
+	return engine.NewAPIError("fofa", http.StatusOK, code, msg, errorCodes)
+}
 
-	return nil
+// response 两种接口响应共有的错误字段
+type response interface {
+	apiError() error
 }
 
-// AppendToCSV 将结果追加到现有CSV文件中
-func AppendToCSV(results [][]string, outputFile string) error {
-	if outputFile == "" {
-		outputFile = "fofa.csv"
-		gologger.Info().Msgf("未指定输出文件，使用默认文件名: %s", outputFile)
+func (d *Fofa) apiError() error {
+	if !d.Error {
+		return nil
 	}
+	return apiError(d.Errmsg)
+}
 
-	// 以追加模式打开文件
-	f, err := os.OpenFile(outputFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
-	if err != nil {
-		gologger.Error().Msgf("打开文件失败: %v", err)
-		return fmt.Errorf("打开文件失败: %v", err)
+func (d *FofaUser) apiError() error {
+	if !d.Error {
+		return nil
 	}
-	defer f.Close()
-
-	writer := csv.NewWriter(f)
-	defer writer.Flush()
+	return apiError(d.Errmsg)
+}
 
-	// 写入数据
-	gologger.Debug().Msgf("正在写入 %d 条数据...", len(results))
-	for _, result := range results {
-		if err := writer.Write(result); err != nil {
-			gologger.Error().Msgf("写入数据行失败: %v", err)
-			return fmt.Errorf("写入数据行失败: %v", err)
-		}
+func (d *FofaNext) apiError() error {
+	if !d.Error {
+		return nil
 	}
+	return apiError(d.Errmsg)
+}
 
-	return nil
+// makeRequest 发送GET请求并解析响应，临时错误按 ClientOptions.Backoff 重试
+func (c *Client) makeRequest(ctx context.Context, api string, params url.Values, v response) error {
+	return engine.Retry(ctx, c.opts.Backoff, c.log, func() error {
+		return c.doRequest(ctx, api, params, v)
+	})
 }
 
-// 修改去重函数，确保正确处理数据
-func removeDuplicates(data [][]string) [][]string {
-	seen := make(map[string]bool)
-	var result [][]string
+// doRequest 发送一次请求，API密钥作为 key 参数附加到请求中
+func (c *Client) doRequest(ctx context.Context, api string, params url.Values, v response) error {
+	if err := c.limiter.Wait(ctx, c.key); err != nil {
+		return err
+	}
 
-	for _, row := range data {
-		// 确保行数据完整
-		if len(row) < 5 {
-			continue
-		}
+	params.Set("key", c.key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, api+"?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("构建请求失败: %v", err)
+	}
 
-		// 使用URL作为唯一标识
-		key := row[4]
-		if key == "" {
-			continue
-		}
+	resp, err := c.opts.HTTP().Do(req)
+	if err != nil {
+		// 错误信息中的URL包含API密钥，不直接返回
+		return engine.RequestError(ctx, "fofa", err)
+	}
+	defer resp.Body.Close()
 
-		if !seen[key] {
-			seen[key] = true
-			result = append(result, row)
-		}
+	// 检查HTTP状态码
+	if resp.StatusCode != 200 {
+		return engine.NewAPIError("fofa", resp.StatusCode, "", "", nil)
 	}
 
-	return result
+	// 解析响应
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+	return v.apiError()
 }
 
-// link输出
-func hata(temp [][]string) {
-	// 先去重
-	uniqueData := removeDuplicates(temp)
-	for _, row := range uniqueData {
-		fmt.Println(row[4])
+// fetch 请求结果数据，返回请求的字段，顺序与结果的列一一对应
+// 账号没有权限获取 AllFields 中的字段时改用 DefaultFields 重试，之后的请求都只使用 DefaultFields
+func (c *Client) fetch(ctx context.Context, api string, params url.Values, v response) ([]string, error) {
+	fields := AllFields
+	if c.basic.Load() {
+		fields = DefaultFields
+	}
+	params.Set("fields", fields)
+	err := c.makeRequest(ctx, api, params, v)
+	if fields == AllFields && errors.Is(err, engine.ErrField) {
+		params.Set("fields", DefaultFields)
+		if err = c.makeRequest(ctx, api, params, v); err != nil {
+			return nil, err
+		}
+		// 查询语句本身使用了没有权限的字段时重试同样失败，只有重试成功才说明是返回字段的问题
+		c.basic.Store(true)
+		c.log.Warningf("账号没有权限获取 host、icp、as_organization 等字段，只获取 %s", DefaultFields)
+		return strings.Split(DefaultFields, ","), nil
 	}
+	return strings.Split(fields, ","), err
 }
 
-// 表格输出
-func data(temp [][]string) {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"IP", "Domain", "Port", "Protocol", "Link", "Title", "Server"})
-	table.SetHeaderColor(tablewriter.Colors{tablewriter.Bold, tablewriter.BgGreenColor},
-		tablewriter.Colors{tablewriter.FgHiRedColor, tablewriter.Bold, tablewriter.BgBlackColor},
-		tablewriter.Colors{tablewriter.BgRedColor, tablewriter.FgWhiteColor},
-		tablewriter.Colors{tablewriter.BgCyanColor, tablewriter.FgWhiteColor},
-		tablewriter.Colors{tablewriter.BgCyanColor, tablewriter.FgWhiteColor},
-		tablewriter.Colors{tablewriter.BgCyanColor, tablewriter.FgWhiteColor},
-		tablewriter.Colors{tablewriter.BgCyanColor, tablewriter.FgWhiteColor})
-
-	table.SetColumnColor(tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiBlackColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiBlackColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiRedColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiBlackColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgBlackColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgBlackColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgBlackColor})
-	table.AppendBulk(temp)
-	table.Render()
-}
+// toAssets 将接口返回的结果行映射为资产，fields 为请求的字段
+func toAssets(fields []string, results [][]string) []asset.Asset {
+	assets := make([]asset.Asset, 0, len(results))
+	for _, row := range results {
+		// 按字段名取值，避免依赖列下标
+		m := make(map[string]string, len(fields))
+		for i, field := range fields {
+			if i < len(row) {
+				m[field] = row[i]
+			}
+		}
+		raw, _ := json.Marshal(m)
 
-// URL去重函数
-// func deduplicateURLs(results [][]string) []string {
-// 	seen := make(map[string]bool)
-// 	var uniqueURLs []string
-
-// 	for _, result := range results {
-// 		url := result[7] // URL在第8列
-// 		if url != "" && !seen[url] {
-// 			seen[url] = true
-// 			uniqueURLs = append(uniqueURLs, url)
-// 		}
-// 	}
-// 	return uniqueURLs
-// }
-
-// URL去重函数
-func deduplicateIP(results [][]string) []string {
-	seen := make(map[string]bool)
-	var uniqueIP []string
-
-	for _, result := range results {
-		url := result[0] // URL在第8列
-		if url != "" && !seen[url] {
-			seen[url] = true
-			uniqueIP = append(uniqueIP, url)
+		// 只请求 DefaultFields 时没有 host，从 link 中获取
+		host := m["host"]
+		if host == "" {
+			host = m["link"]
 		}
-	}
-	return uniqueIP
+		if strings.Contains(host, "://") {
+			host = asset.HostFromURL(host)
+		}
+
+		assets = append(assets, asset.Asset{
+			IP:       m["ip"],
+			Port:     asset.ParsePort(m["port"]),
+			Domain:   m["domain"],
+			Host:     host,
+			URL:      m["link"],
+			Title:    m["title"],
+			Server:   m["server"],
+			Protocol: m["protocol"],
+			ICP:      m["icp"],
+			Org:      m["as_organization"],
+			Country:  m["country"],
+			Source:   "fofa",
+			LastSeen: asset.ParseTime(m["lastupdatetime"]),
+			Raw:      raw,
+		})
+	}
+	return assets
 }
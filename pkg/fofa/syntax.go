@@ -0,0 +1,80 @@
+package fofa
+
+// syntaxHelp Fofa 语法参考
+const syntaxHelp = `
+Fofa 语法参考:
+
+基础查询:
+  ip="1.1.1.1"              - 搜索指定IPv4地址
+  ip="1.1.1.1/24"          - 搜索指定IPv4 C段
+  ip="2600:9000:xxx"       - 搜索指定IPv6地址
+  port="6379"              - 搜索指定端口
+  domain="qq.com"          - 搜索根域名
+  host=".fofa.info"        - 搜索主机名
+  os="centos"              - 搜索操作系统
+  server="MicrosoftIIS/10" - 搜索Web服务器
+  asn="19551"              - 搜索自治系统号
+  org="LLC Baxet"          - 搜索所属组织
+
+标记类:
+  app="MicrosoftExchange"  - 通过FOFA规则搜索
+  product="NGINX"          - 搜索产品名称
+  category="服务"          - 搜索分类
+  type="service"           - 筛选协议资产
+  type="subdomain"         - 筛选网站类资产
+  cloud_name="Aliyundun"   - 搜索云服务商
+  is_cloud=true/false      - 筛选云服务资产
+  is_domain=true/false     - 筛选域名资产
+  is_ipv6=true/false       - 筛选IPv6/IPv4资产
+
+协议类(type=service):
+  protocol="quic"          - 搜索协议名称
+  banner="users"           - 搜索协议返回信息
+  base_protocol="udp/tcp"  - 搜索传输层协议
+
+网站类(type=subdomain):
+  title="beijing"          - 搜索网站标题
+  header="elastic"         - 搜索响应头
+  body="网络空间测绘"      - 搜索网页内容
+  js_name="jquery.js"      - 搜索JS文件名
+  status_code="200"        - 搜索HTTP状态码
+  icp="京ICP证030173号"    - 搜索ICP备案号
+
+地理位置:
+  country="CN/中国"        - 搜索国家
+  region="Zhejiang/浙江"   - 搜索省份/地区
+  city="Hangzhou"          - 搜索城市
+
+证书类:
+  cert="baidu"             - 搜索证书信息
+  cert.subject="Oracle"    - 搜索证书持有者
+  cert.issuer="DigiCert"   - 搜索证书颁发者
+  cert.domain="huawei.com" - 搜索证书域名
+  cert.is_valid=true/false - 筛选有效证书
+
+时间类:
+  after="20230101"         - 某时间后更新的资产
+  before="20231201"        - 某时间前更新的资产
+
+运算符:
+  &&  - 与运算
+  ||  - 或运算
+  !=  - 不等于
+  =   - 等于
+  *=  - 模糊匹配
+
+示例:
+1. 搜索中国境内的Apache服务器:
+   country="CN" && server="Apache"
+
+2. 搜索某个IP段的Web服务:
+   ip="192.168.1.1/24" && port="80"
+
+3. 搜索指定时间区间的资产:
+   after="20230101" && before="20231201"
+
+注意事项:
+1. 多个条件可以用 && 和 || 组合
+2. 支持 = != *= 三种匹配方式
+3. 时间格式为 YYYYMMDD
+4. 部分高级功能需要对应会员等级`
@@ -0,0 +1,177 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout 未配置超时时间时单次请求的超时时间
+const DefaultTimeout = 60 * time.Second
+
+// DefaultUserAgent 未配置 user_agent 时请求使用的 User-Agent
+const DefaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+// defaultHeaders 请求中没有设置时添加的请求头，各引擎的API都返回JSON
+var defaultHeaders = map[string]string{
+	"Accept":          "application/json, text/plain, */*",
+	"Accept-Language": "zh-CN,zh;q=0.9",
+}
+
+// Config HTTP客户端配置，全局配置和各引擎的配置使用同一结构
+type Config struct {
+	Proxy     string        `yaml:"proxy"`      // 代理地址，支持 http://、https://、socks5://
+	Timeout   time.Duration `yaml:"timeout"`    // 单次请求的超时时间，如 30s
+	CACert    string        `yaml:"ca_cert"`    // 额外信任的CA证书(PEM)，用于有中间人证书的代理
+	Insecure  bool          `yaml:"insecure"`   // 跳过TLS证书校验
+	UserAgent string        `yaml:"user_agent"` // 覆盖默认的 User-Agent
+}
+
+// Merge 返回用 o 中的非空字段覆盖 c 后的配置
+func (c Config) Merge(o Config) Config {
+	if o.Proxy != "" {
+		c.Proxy = o.Proxy
+	}
+	if o.Timeout != 0 {
+		c.Timeout = o.Timeout
+	}
+	if o.CACert != "" {
+		c.CACert = o.CACert
+	}
+	if o.Insecure {
+		c.Insecure = true
+	}
+	if o.UserAgent != "" {
+		c.UserAgent = o.UserAgent
+	}
+	return c
+}
+
+var (
+	mu      sync.Mutex
+	clients = make(map[Config]*http.Client)
+)
+
+// Get 返回配置对应的客户端，相同配置共用同一个客户端以复用连接
+func Get(c Config) (*http.Client, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if client, ok := clients[c]; ok {
+		return client, nil
+	}
+	client, err := New(c)
+	if err != nil {
+		return nil, err
+	}
+	clients[c] = client
+	return client, nil
+}
+
+// New 根据配置创建客户端
+func New(c Config) (*http.Client, error) {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	if c.Proxy != "" {
+		proxy, err := ParseProxy(c.Proxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if c.CACert != "" || c.Insecure {
+		tlsConfig := &tls.Config{InsecureSkipVerify: c.Insecure}
+		if c.CACert != "" {
+			pool, err := loadCACert(c.CACert)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig.RootCAs = pool
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	userAgent := c.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	return &http.Client{Transport: &headerTransport{base: transport, userAgent: userAgent}, Timeout: timeout}, nil
+}
+
+// ParseProxy 解析代理地址，未指定协议时按 http 处理
+func ParseProxy(s string) (*url.URL, error) {
+	if !strings.Contains(s, "://") {
+		s = "http://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("解析代理地址失败: %v", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("不支持的代理协议 %s，可选: http、https、socks5", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("代理地址缺少主机: %s", s)
+	}
+	return u, nil
+}
+
+// loadCACert 在系统证书的基础上加入指定的CA证书
+func loadCACert(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取CA证书失败: %v", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("CA证书 %s 中没有有效的PEM证书", path)
+	}
+	return pool, nil
+}
+
+// headerTransport 设置请求的 User-Agent，并补充请求中没有设置的默认请求头
+type headerTransport struct {
+	base      http.RoundTripper
+	userAgent string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	for k, v := range defaultHeaders {
+		if req.Header.Get(k) == "" {
+			req.Header.Set(k, v)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
@@ -1,73 +1,71 @@
 package hunter
 
 import (
+	"context"
 	"encoding/base64"
-	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"math"
-	"os"
-	"strings"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
 	"time"
 
-	"github.com/yaxigin/mto/pkg/config"
-
-	"github.com/olekukonko/tablewriter"
-	"github.com/parnurzeal/gorequest"
-	"github.com/projectdiscovery/gologger"
-	"gopkg.in/yaml.v2"
+	"github.com/yaxigin/mto/pkg/asset"
+	"github.com/yaxigin/mto/pkg/engine"
+	"github.com/yaxigin/mto/pkg/query"
+	"github.com/yaxigin/mto/pkg/ratelimit"
 )
 
-type Config struct {
-	Hunter struct {
-		Key string `yaml:"key"`
-	}
-}
-
 type HunterResponse struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 	Data    struct {
-		Total       int    `json:"total"`
-		Time        int    `json:"time"`
-		Page        int    `json:"page"`
-		Size        int    `json:"size"`
-		AccountType string `json:"account_type"`
-		Arr         []struct {
-			IsRisk         string `json:"is_risk"`
-			URL            string `json:"url"`
-			IP             string `json:"ip"`
-			Port           int    `json:"port"`
-			WebTitle       string `json:"web_title"`
-			Domain         string `json:"domain"`
-			IsRiskProtocol string `json:"is_risk_protocol"`
-			Protocol       string `json:"protocol"`
-			BaseProtocol   string `json:"base_protocol"`
-			StatusCode     int    `json:"status_code"`
-			Component      []struct {
-				Name    string `json:"name"`
-				Version string `json:"version"`
-			} `json:"component"`
-			OS        string `json:"os"`
-			Company   string `json:"company"`
-			Number    string `json:"number"`
-			Country   string `json:"country"`
-			Province  string `json:"province"`
-			City      string `json:"city"`
-			UpdatedAt string `json:"updated_at"`
-			IsWeb     string `json:"is_web"`
-			AsOrg     string `json:"as_org"`
-			ISP       string `json:"isp"`
-			Banner    string `json:"banner"`
-			VulList   string `json:"vul_list"`
-			Header    string `json:"header"`
-		} `json:"arr"`
-		ConsumeQuota string `json:"consume_quota"`
-		RestQuota    string `json:"rest_quota"`
-		SyntaxPrompt string `json:"syntax_prompt"`
+		Total        int          `json:"total"`
+		Time         int          `json:"time"`
+		Page         int          `json:"page"`
+		Size         int          `json:"size"`
+		AccountType  string       `json:"account_type"`
+		Arr          []HunterItem `json:"arr"`
+		ConsumeQuota string       `json:"consume_quota"`
+		RestQuota    string       `json:"rest_quota"`
+		SyntaxPrompt string       `json:"syntax_prompt"`
 	} `json:"data"`
 }
 
+// HunterItem 单条资产数据
+type HunterItem struct {
+	IsRisk         string `json:"is_risk"`
+	URL            string `json:"url"`
+	IP             string `json:"ip"`
+	Port           int    `json:"port"`
+	WebTitle       string `json:"web_title"`
+	Domain         string `json:"domain"`
+	IsRiskProtocol string `json:"is_risk_protocol"`
+	Protocol       string `json:"protocol"`
+	BaseProtocol   string `json:"base_protocol"`
+	StatusCode     int    `json:"status_code"`
+	Component      []struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"component"`
+	OS        string `json:"os"`
+	Company   string `json:"company"`
+	Number    string `json:"number"`
+	Country   string `json:"country"`
+	Province  string `json:"province"`
+	City      string `json:"city"`
+	UpdatedAt string `json:"updated_at"`
+	IsWeb     string `json:"is_web"`
+	AsOrg     string `json:"as_org"`
+	ISP       string `json:"isp"`
+	Banner    string `json:"banner"`
+	VulList   string `json:"vul_list"`
+	Header    string `json:"header"`
+}
+
 // 计算时间范围，返回空字符串表示不使用时间范围
 func calculateTimeRange(months int) (string, string) {
 	// 如果是0，返回空字符串，API请求将不包含时间范围
@@ -96,382 +94,320 @@ func calculateTimeRange(months int) (string, string) {
 	return startTimeStr + "(", endTimeStr
 }
 
-// HUCMD 处理单个查询
-func HUCMD(search string, months int, h bool, onlyIP bool) error {
-	conf := Config{}
-	content, err := os.ReadFile(config.GetConfigPath())
-	if err != nil {
-		return fmt.Errorf("配置文件读取错误: %v", err)
-	}
-	if err := yaml.Unmarshal(content, &conf); err != nil {
-		return fmt.Errorf("解析config.yaml出错: %v", err)
-	}
+// Client Hunter API 客户端，实现 engine.Engine 接口
+// 客户端不读取配置文件也不写终端，日志通过 engine.ClientOptions.Logger 输出
+type Client struct {
+	key     string
+	opts    engine.ClientOptions
+	log     engine.Logger
+	limiter *ratelimit.Limiter
+}
 
-	// 处理查询语句
-	// 保存原始查询语句以便输出
-	originalQuery := search
-
-	// 检查查询语句是否包含逻辑运算符
-	if strings.Contains(search, "&&") || strings.Contains(search, "||") {
-		// 处理复杂查询（包含逻辑运算符）
-		search = processComplexQuery(search)
-	} else if strings.Contains(search, "=") {
-		// 处理简单查询（单个键值对）
-		search = processSimpleQuery(search)
-	}
+// NewClient 使用API密钥创建客户端
+func NewClient(key string, opts engine.ClientOptions) *Client {
+	return &Client{key: key, opts: opts, log: opts.Log(), limiter: opts.Limiter("hunter", defaultRate)}
+}
 
-	gologger.Info().Msgf("原始查询语句: %s", originalQuery)
-	gologger.Info().Msgf("处理后的查询语句: %s", search)
+func init() {
+	engine.Register("hunter", func(key string, opts engine.ClientOptions) engine.Engine {
+		return NewClient(key, opts)
+	})
+}
 
-	// Base64编码
-	searchBase64 := base64.URLEncoding.EncodeToString([]byte(search))
-	fmt.Println("base64编码后的查询语句:", searchBase64)
+// Name 返回引擎名称
+func (c *Client) Name() string {
+	return "hunter"
+}
 
-	// 构建基础URL
-	baseURL := fmt.Sprintf("https://hunter.qianxin.com/openApi/search?api-key=%s&search=%s&page=1&page_size=100&is_web=3",
-		conf.Hunter.Key, searchBase64)
+// Description 返回模块简介
+func (c *Client) Description() string {
+	return "从hunter获取资产信息。"
+}
+
+// Syntax 返回Hunter语法参考
+func (c *Client) Syntax() string {
+	return syntaxHelp
+}
+
+// apiURL Hunter 查询接口
+const apiURL = "https://hunter.qianxin.com/openApi/search"
+
+// buildParams 构建查询参数，search 为已规范化的查询语句，page 和 pageSize 由调用方指定
+func buildParams(search string, months, page, pageSize int) url.Values {
+	params := url.Values{
+		"search":    {base64.URLEncoding.EncodeToString([]byte(search))},
+		"page":      {strconv.Itoa(page)},
+		"page_size": {strconv.Itoa(pageSize)},
+		"is_web":    {"3"},
+	}
 
 	// 计算时间范围
 	startTime, endTime := calculateTimeRange(months)
 	// 只有当startTime和endTime不为空时才添加时间范围参数
 	if startTime != "" && endTime != "" {
-		baseURL += fmt.Sprintf("&start_time=%s&end_time=%s", startTime, endTime)
+		params.Set("start_time", startTime)
+		params.Set("end_time", endTime)
 	}
-	//fmt.Println(baseURL)
+	return params
+}
 
-	// 发起第一次请求
-	var response HunterResponse
-	if err := huntermakeRequest(baseURL, &response); err != nil {
-		return err
+// prepareQuery 检查查询语句并补全引号
+func (c *Client) prepareQuery(search string, opts engine.Options) (string, error) {
+	if search == "" {
+		return "", fmt.Errorf("查询语句不能为空")
 	}
-
-	// 处理结果，传入 h 参数
-	results := processResults(response, h)
-
-	// 如果total大于100，需要翻页
-	if response.Data.Total > 100 {
-		totalPages := int(math.Ceil(float64(response.Data.Total) / 100.0))
-		gologger.Info().Msgf("总共有 %d 页数据", totalPages)
-
-		for page := 2; page <= totalPages; page++ {
-			pageURL := fmt.Sprintf("%s&page=%d", baseURL, page)
-			var pageResponse HunterResponse
-			if err := huntermakeRequest(pageURL, &pageResponse); err != nil {
-				gologger.Warning().Msgf("获取第 %d 页失败: %v", page, err)
-				continue
-			}
-			results = append(results, processResults(pageResponse, h)...)
-		}
+	if c.key == "" {
+		return "", fmt.Errorf("Hunter API密钥未配置")
 	}
-
-	// 输出结果
-	if onlyIP {
-		// for _, result := range results {
-		// 	fmt.Println(result[0]) // IP
-		// }
-		uniqueIP := deduplicateIP(results)
-		for _, ip := range uniqueIP {
-			fmt.Println(ip)
-		}
-	} else if h {
-		// for _, result := range results {
-		// 	fmt.Println(result[5]) // URL
-		// }
-		uniqueURL := deduplicateURLs(results)
-		for _, url := range uniqueURL {
-			fmt.Println(url)
-		}
-	} else {
-		data(results)
+	if err := engine.Preflight(query.Hunter, search, opts, c.log); err != nil {
+		return "", err
 	}
-
-	//data(results)
-
-	// 只有在使用 -f 参数时才写文件
-	// if outputFile != "" {
-	// 	if err := WriteToHunterCSV(results, outputFile); err != nil {
-	// 		return fmt.Errorf("写入文件失败: %v", err)
-	// 	}
-	// }
-
-	return nil
+	return query.Normalize(query.Hunter, search)
 }
 
-// HUPILIANG 处理批量查询
-func HUPILIANG(search string, months int, outputFile string) error {
-	conf := Config{}
-	content, err := os.ReadFile(config.GetConfigPath())
+// Search 执行查询并返回全部资产
+func (c *Client) Search(ctx context.Context, search string, opts engine.Options) ([]asset.Asset, error) {
+	c.log.Infof("原始查询语句: %s", search)
+	search, err := c.prepareQuery(search, opts)
 	if err != nil {
-		return fmt.Errorf("配置文件读取错误: %v", err)
+		return nil, err
 	}
-	if err := yaml.Unmarshal(content, &conf); err != nil {
-		return fmt.Errorf("解析config.yaml出错: %v", err)
+	c.log.Infof("处理后的查询语句: %s", search)
+
+	// 断点续传时从上次的页码继续
+	startPage := 1
+	if opts.Cursor != "" {
+		startPage, err = strconv.Atoi(opts.Cursor)
+		if err != nil || startPage < 1 {
+			return nil, fmt.Errorf("无效的翻页位置: %s", opts.Cursor)
+		}
+		c.log.Infof("从第 %d 页继续获取", startPage)
 	}
 
-	// Base64编码
-	searchBase64 := base64.StdEncoding.EncodeToString([]byte(search))
-
-	// 构建基础URL
-	baseURL := fmt.Sprintf("https://hunter.qianxin.com/openApi/search?api-key=%s&search=%s&page=1&page_size=100&is_web=3",
-		conf.Hunter.Key, searchBase64)
+	// 指定了最大结果数量时只获取前 limit 条，数量不足一页时缩小分页
+	limit := opts.MaxResults
+	pageSize := defaultPageSize
+	if limit > 0 {
+		pageSize = min(pageSize, limit)
+	}
 
-	// 计算时间范围
-	startTime, endTime := calculateTimeRange(months)
-	// 只有当startTime和endTime不为空时才添加时间范围参数
-	if startTime != "" && endTime != "" {
-		baseURL += fmt.Sprintf("&start_time=%s&end_time=%s", startTime, endTime)
+	// 临时错误在 makeRequest 中按退避策略重试，其他错误直接返回
+	var response HunterResponse
+	if err := c.makeRequest(ctx, buildParams(search, opts.Months, startPage, pageSize), &response); err != nil {
+		return nil, err
 	}
-	//fmt.Println(baseURL)
 
 	// 收集所有结果
-	var allResults [][]string
-
-	// 发起第一次请求
-	var response HunterResponse
-	if err := huntermakeRequest(baseURL, &response); err != nil {
-		// 第一页失败时重试
-		time.Sleep(2 * time.Second)
-		if err := huntermakeRequest(baseURL, &response); err != nil {
-			return err
-		}
+	total := response.Data.Total
+	if limit > 0 {
+		total = min(total, limit)
 	}
+	allResults := truncate(processResults(response), startPage, pageSize, limit)
+	totalPages := int(math.Ceil(float64(total) / float64(pageSize)))
+	opts.Report(allResults, nextPage(startPage, totalPages))
 
-	// 处理结果并收集
-	results := processResults(response, true)
-	allResults = append(allResults, results...)
+	// 如果total大于100，需要翻页
+	if totalPages > startPage {
+		c.log.Infof("总共有 %d 页数据", totalPages)
 
-	// 输出第一页的 URL
-	for _, result := range results {
-		fmt.Println(result[5]) // URL
-	}
+		for page := startPage + 1; page <= totalPages; page++ {
+			// 被取消或某一页失败时返回已获取的结果和停止的页码，不跳过失败的页
+			if err := ctx.Err(); err != nil {
+				return allResults, engine.Stop(ctx, err, len(allResults), fmt.Sprintf("第 %d 页", page), strconv.Itoa(page))
+			}
+
+			var pageResponse HunterResponse
+			if err := c.makeRequest(ctx, buildParams(search, opts.Months, page, pageSize), &pageResponse); err != nil {
+				return allResults, engine.Stop(ctx, err, len(allResults), fmt.Sprintf("第 %d 页", page), strconv.Itoa(page))
+			}
 
-	// 写入第一页数据
-	if outputFile != "" {
-		if err := WriteToHunterCSV(results, outputFile); err != nil {
-			return fmt.Errorf("写入第1页数据失败: %v", err)
+			pageResults := truncate(processResults(pageResponse), page, pageSize, limit)
+			allResults = append(allResults, pageResults...)
+			response = pageResponse
+			opts.Report(pageResults, nextPage(page, totalPages))
+			c.log.Infof("已处理第 %d/%d 页", page, totalPages)
 		}
 	}
 
-	// 如果total大于100，需要翻页
-	if response.Data.Total > 100 {
-		totalPages := int(math.Ceil(float64(response.Data.Total) / 100.0))
-		gologger.Info().Msgf("总共有 %d 页数据", totalPages)
-
-		for page := 2; page <= totalPages; page++ {
-			// 增加频率控制到2秒
-			time.Sleep(2 * time.Second)
+	// 最后一页的响应中包含剩余积分，如 "剩余积分：399"
+	if response.Data.RestQuota != "" {
+		c.log.Infof("%s", response.Data.RestQuota)
+	}
 
-			pageURL := fmt.Sprintf("%s&page=%d", baseURL, page)
-			var pageResponse HunterResponse
+	return allResults, nil
+}
 
-			// 发起请求，最多重试3次，每次间隔2秒
-			var err error
-			var pageResults [][]string
-			success := false
-
-			for retry := range 3 {
-				err = huntermakeRequest(pageURL, &pageResponse)
-				if err == nil {
-					// 处理结果
-					pageResults = processResults(pageResponse, true)
-					if len(pageResults) > 0 {
-						success = true
-						break
-					}
-				}
-				gologger.Warning().Msgf("第 %d 页第 %d 次重试", page, retry+1)
-				time.Sleep(2 * time.Second)
-			}
+// accountQuery 查询账号信息时使用的查询语句，不会匹配到任何资产，因此不消耗积分
+const accountQuery = `ip="255.255.255.255"`
 
-			if !success {
-				gologger.Warning().Msgf("获取第 %d 页失败，跳过: %v", page, err)
-				continue
-			}
+// quotaPattern 从 rest_quota 中提取数值，如 "剩余积分：499"
+var quotaPattern = regexp.MustCompile(`-?\d+`)
 
-			// 输出当前页的 URL
-			for _, result := range pageResults {
-				fmt.Println(result[5]) // URL
-			}
+// Account 通过一次无结果的查询获取账号类型和剩余积分
+func (c *Client) Account(ctx context.Context) (engine.Account, error) {
+	if c.key == "" {
+		return engine.Account{}, fmt.Errorf("Hunter API密钥未配置")
+	}
 
-			// 写入当前页数据
-			if outputFile != "" {
-				if err := WriteToHunterCSV(pageResults, outputFile); err != nil {
-					gologger.Warning().Msgf("写入第 %d 页数据失败: %v", page, err)
-					// 写入失败时重试一次
-					time.Sleep(1 * time.Second)
-					if err := WriteToHunterCSV(pageResults, outputFile); err != nil {
-						continue
-					}
-				}
-			}
+	var response HunterResponse
+	if err := c.makeRequest(ctx, buildParams(accountQuery, 0, 1, 1), &response); err != nil {
+		return engine.Account{}, err
+	}
 
-			gologger.Info().Msgf("已处理第 %d/%d 页", page, totalPages)
-		}
+	quota := -1
+	if m := quotaPattern.FindString(response.Data.RestQuota); m != "" {
+		quota, _ = strconv.Atoi(m)
 	}
+	return engine.Account{
+		Engine:    "hunter",
+		Key:       engine.MaskKey(c.key),
+		Level:     response.Data.AccountType,
+		Quota:     quota,
+		QuotaUnit: "积分",
+		QueryLeft: -1,
+	}, nil
+}
 
-	return nil
+// defaultPageSize 每页获取的结果数量，Hunter API 每页最多 100 条
+const defaultPageSize = 100
+
+// truncate 去掉第 page 页中超出 limit 的结果，limit 不大于0时不限制
+func truncate(results []asset.Asset, page, pageSize, limit int) []asset.Asset {
+	if limit <= 0 {
+		return results
+	}
+	return results[:min(len(results), max(limit-(page-1)*pageSize, 0))]
 }
 
-// huntermakeRequest 发送HTTP请求
-func huntermakeRequest(url string, response *HunterResponse) error {
-	request := gorequest.New()
-	resp, body, errs := request.Get(url).
-		Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; WOW64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/55.0.2883.87 Safari/537.36").
-		End()
+// nextPage 返回下一页的页码，已经是最后一页时返回空字符串
+func nextPage(page, totalPages int) string {
+	if page >= totalPages {
+		return ""
+	}
+	return strconv.Itoa(page + 1)
+}
 
-	if len(errs) > 0 {
-		return errs[0]
+// Estimate 估算获取数据的开销，Hunter 默认获取全部结果，每条返回的结果消耗 1 积分
+// 指定了最大结果数量时最后一页返回的多余结果同样消耗积分
+func (c *Client) Estimate(total int, opts engine.Options) engine.Estimate {
+	fetch, pageSize := total, defaultPageSize
+	if opts.MaxResults > 0 {
+		fetch = min(total, opts.MaxResults)
+		pageSize = min(pageSize, opts.MaxResults)
+	}
+	pages := engine.Pages(fetch, pageSize)
+	return engine.Estimate{
+		Total: total,
+		Fetch: fetch,
+		Pages: pages,
+		Cost:  min(total, pages*pageSize),
+		Unit:  "积分",
 	}
+}
 
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("请求失败，状态码: %d", resp.StatusCode)
+// Count 只获取查询结果总数
+func (c *Client) Count(ctx context.Context, search string, opts engine.Options) (int, error) {
+	search, err := c.prepareQuery(search, opts)
+	if err != nil {
+		return 0, err
 	}
 
-	if err := json.Unmarshal([]byte(body), response); err != nil {
-		return fmt.Errorf("解析响应失败: %v", err)
+	var response HunterResponse
+	if err := c.makeRequest(ctx, buildParams(search, opts.Months, 1, 1), &response); err != nil {
+		return 0, err
 	}
+	return response.Data.Total, nil
+}
 
-	return nil
+// defaultRate 未配置 rate 时的请求限速，单个密钥两次请求之间至少间隔2秒，避免触发频率限制
+var defaultRate = ratelimit.Rate{Every: 2 * time.Second, Burst: 1}
+
+// errorCodes Hunter 错误码对应的错误类型，未收录的错误码根据错误信息判断
+var errorCodes = map[string]error{
+	"401":   engine.ErrAuth,      // 令牌无效或过期
+	"403":   engine.ErrAuth,      // 没有权限
+	"429":   engine.ErrRateLimit, // 请求过于频繁
+	"40204": engine.ErrQuota,     // 积分不足
 }
 
-// processResults 处理API返回的结果
-func processResults(response HunterResponse, _ bool) [][]string {
-	var results [][]string
-	for _, item := range response.Data.Arr {
-		// CSV文件格式 - 只包含指定字段
-		result := []string{
-			item.IP,                            // IP
-			fmt.Sprintf("%d", item.Port),       // Port
-			item.Domain,                        // Domain
-			item.Protocol,                      // Protocol
-			item.BaseProtocol,                  // Base Protocol
-			item.URL,                           // URL
-			item.WebTitle,                      // Web Title
-			fmt.Sprintf("%d", item.StatusCode), // Status Code
-			item.Company,                       // Company
-			item.Number,                        // Number
-			item.Country,                       // Country
-			item.IsWeb,                         // Is Web
-			item.ISP,                           // ISP
-		}
-		results = append(results, result)
-	}
-	return results
+// makeRequest 发送HTTP请求，临时错误按 ClientOptions.Backoff 重试
+func (c *Client) makeRequest(ctx context.Context, params url.Values, response *HunterResponse) error {
+	return engine.Retry(ctx, c.opts.Backoff, c.log, func() error {
+		return c.doRequest(ctx, params, response)
+	})
 }
 
-// data 在命令行输出表格
-func data(results [][]string) {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"IP", "Domain", "Port", "Protocol", "URL", "Web Title"})
-
-	// 设置表格样式
-	table.SetHeaderColor(
-		tablewriter.Colors{tablewriter.FgHiRedColor, tablewriter.Bold, tablewriter.BgBlackColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.BgGreenColor},
-		tablewriter.Colors{tablewriter.BgRedColor, tablewriter.FgWhiteColor},
-		tablewriter.Colors{tablewriter.BgCyanColor, tablewriter.FgWhiteColor},
-		tablewriter.Colors{tablewriter.BgCyanColor, tablewriter.FgWhiteColor},
-		tablewriter.Colors{tablewriter.BgCyanColor, tablewriter.FgWhiteColor},
-	)
-
-	table.SetColumnColor(
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiBlackColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiBlackColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiRedColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiBlackColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgBlackColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgBlackColor},
-	)
-
-	// 准备表格数据
-	for _, row := range results {
-		// 只取需要显示的字段
-		displayRow := []string{
-			row[0], // IP
-			row[2], // Domain
-			row[1], // Port
-			row[3], // Protocol
-			row[5], // URL
-			row[6], // Web Title
-		}
-		table.Append(displayRow)
+// doRequest 发送一次请求，API密钥作为 api-key 参数附加到请求中
+func (c *Client) doRequest(ctx context.Context, params url.Values, response *HunterResponse) error {
+	if err := c.limiter.Wait(ctx, c.key); err != nil {
+		return err
 	}
 
-	table.Render()
-}
+	params.Set("api-key", c.key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.opts.URL(apiURL)+"?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("构建请求失败: %v", err)
+	}
 
-// WriteToHunterCSV 将结果写入CSV文件
-func WriteToHunterCSV(results [][]string, outputFile string) error {
-	// 检查文件是否存在
-	var f *os.File
-	//var err error
-	if _, err := os.Stat(outputFile); os.IsNotExist(err) {
-		// 文件不存在，创建新文件
-		f, err = os.Create(outputFile)
-		if err != nil {
-			return fmt.Errorf("创建文件失败: %v", err)
-		}
-		// 写入 UTF-8 BOM
-		f.WriteString("\xEF\xBB\xBF")
-		// 写入表头
-		writer := csv.NewWriter(f)
-		headers := []string{
-			"IP", "Port", "Domain", "Protocol", "Base Protocol", "URL", "Web Title",
-			"Status Code", "Company", "Number", "Country", "Is Web", "ISP",
-		}
-		if err := writer.Write(headers); err != nil {
-			return fmt.Errorf("写入表头失败: %v", err)
-		}
-		writer.Flush()
-	} else {
-		// 文件存在，追加模式打开
-		f, err = os.OpenFile(outputFile, os.O_APPEND|os.O_WRONLY, 0644)
-		if err != nil {
-			return fmt.Errorf("打开文件失败: %v", err)
-		}
+	resp, err := c.opts.HTTP().Do(req)
+	if err != nil {
+		// 错误信息中的URL包含API密钥，不直接返回
+		return engine.RequestError(ctx, "hunter", err)
 	}
-	defer f.Close()
+	defer resp.Body.Close()
 
-	// 写入数据
-	writer := csv.NewWriter(f)
-	defer writer.Flush()
+	if resp.StatusCode != 200 {
+		return engine.NewAPIError("hunter", resp.StatusCode, "", "", nil)
+	}
 
-	for _, result := range results {
-		if err := writer.Write(result); err != nil {
-			return fmt.Errorf("写入数据行失败: %v", err)
-		}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+
+	// 检查 API 错误响应
+	if response.Code != 200 {
+		return engine.NewAPIError("hunter", resp.StatusCode, strconv.Itoa(response.Code), response.Message, errorCodes)
 	}
 
 	return nil
 }
 
-// 添加处理组件信息的函数
-// URL去重函数
-func deduplicateURLs(results [][]string) []string {
-	seen := make(map[string]bool)
-	var uniqueURLs []string
-
-	for _, result := range results {
-		url := result[5] // URL在第8列
-		if url != "" && !seen[url] {
-			seen[url] = true
-			uniqueURLs = append(uniqueURLs, url)
+// processResults 将API返回的结果映射为资产
+func processResults(response HunterResponse) []asset.Asset {
+	var results []asset.Asset
+	for _, item := range response.Data.Arr {
+		var components []string
+		for _, c := range item.Component {
+			if c.Version != "" {
+				components = append(components, c.Name+":"+c.Version)
+			} else {
+				components = append(components, c.Name)
+			}
 		}
-	}
-	return uniqueURLs
-}
-
-// ip去重函数
-func deduplicateIP(results [][]string) []string {
-	seen := make(map[string]bool)
-	var uniqueIP []string
 
-	for _, result := range results {
-		url := result[0] // URL在第8列
-		if url != "" && !seen[url] {
-			seen[url] = true
-			uniqueIP = append(uniqueIP, url)
+		host := asset.HostFromURL(item.URL)
+		if host == "" && item.IP != "" {
+			host = net.JoinHostPort(item.IP, strconv.Itoa(item.Port))
 		}
+		raw, _ := json.Marshal(item)
+
+		results = append(results, asset.Asset{
+			IP:         item.IP,
+			Port:       item.Port,
+			Domain:     item.Domain,
+			Host:       host,
+			URL:        item.URL,
+			Title:      item.WebTitle,
+			Protocol:   item.Protocol,
+			ICP:        item.Number,
+			Org:        item.Company,
+			ISP:        item.ISP,
+			Country:    item.Country,
+			Components: components,
+			Source:     "hunter",
+			LastSeen:   asset.ParseTime(item.UpdatedAt),
+			Raw:        raw,
+		})
 	}
-	return uniqueIP
+	return results
 }
@@ -0,0 +1,89 @@
+package hunter
+
+// syntaxHelp Hunter 语法参考
+const syntaxHelp = `
+Hunter 语法参考:
+
+特色功能:
+  ip.tag="CDN"                 - 查询包含IP标签的资产
+  web.similar="baidu.com:443"  - 查询与指定网站特征相似的资产
+  web.similar_icon="1726273.." - 查询网站icon相似的资产
+  web.similar_id="3322dfb4.."  - 查询与指定网页相似的资产
+  web.tag="登录页面"           - 查询包含资产标签的资产
+  web.is_vul=true             - 查询存在历史漏洞的资产
+  icp.is_exception=true       - 搜索ICP备案异常的资产
+
+域名信息:
+  domain.suffix="qianxin.com"  - 搜索指定主域的网站
+  domain.status="clientDeleteProhibited" - 搜索域名状态
+  domain.whois_server="whois.markmonitor.com" - 搜索whois服务器
+  domain.name_server="ns1.qq.com" - 搜索名称服务器
+  domain.created_date="2022-06-01" - 搜索域名创建时间
+  domain.expires_date="2022-06-01" - 搜索域名到期时间
+  domain.updated_date="2022-06-01" - 搜索域名更新时间
+  domain.cname="xxx.com"      - 搜索指定CNAME记录
+  is_domain.cname=true        - 搜索含CNAME解析记录的网站
+
+网站信息:
+  is_web=true                 - 搜索web资产
+  web.icon="22eeab7.."       - 查询网站icon相同的资产
+  web.title="北京"           - 搜索网站标题
+  web.body="网络空间测绘"     - 搜索网页内容
+  header.server="nginx"       - 搜索服务器类型
+  header.status_code="200"    - 搜索HTTP状态码
+
+证书信息:
+  cert.is_trust=true          - 搜索证书可信的资产
+  cert.subject.suffix="xxx"   - 搜索证书使用者
+  cert.issuer="DigiCert"      - 搜索证书颁发者
+  cert.is_expired=true        - 搜索已过期证书
+
+ICP备案:
+  icp.number="京ICP备xxx号"   - 搜索ICP备案号
+  icp.web_name="公司名"       - 搜索ICP备案网站名
+  icp.name="公司名"          - 搜索ICP备案单位名
+  icp.type="企业"           - 搜索ICP备案主体类型
+  icp.industry="软件服务"    - 搜索ICP备案行业
+
+基础查询:
+  ip="1.1.1.1"               - 搜索指定IP
+  ip="1.1.1.1/24"            - 搜索指定C段
+  ip.port="80"               - 搜索指定端口
+  ip.port_count>"2"          - 搜索开放端口数量
+  ip.country="CN"            - 搜索国家
+  ip.province="北京"         - 搜索省份
+  ip.city="北京"             - 搜索城市
+  ip.isp="电信"             - 搜索运营商
+  ip.os="Windows"           - 搜索操作系统
+
+时间范围:
+  after="2021-01-01"         - 某时间后的资产
+  before="2021-12-31"        - 某时间前的资产
+  after="2021-01-01" && before="2021-12-31" - 时间区间
+
+运算符:
+  &&    - 与运算
+  ||    - 或运算
+  =     - 等于
+  !=    - 不等于
+  >     - 大于
+  <     - 小于
+
+示例:
+1. 搜索中国境内的CDN资产:
+   ip.country="CN" && ip.tag="CDN"
+
+2. 搜索开放多个端口的Web服务器:
+   is_web=true && ip.port_count>"3"
+
+3. 搜索某域名下的可信证书资产:
+   domain.suffix="example.com" && cert.is_trust=true
+
+4. 搜索2021年的资产:
+   after="2021-01-01" && before="2021-12-31"
+
+注意事项:
+1. 时间格式为 YYYY-MM-DD
+2. 支持 = != > < 等比较运算符
+3. 多个条件使用 && 和 || 组合
+4. 部分特色功能需要对应会员等级`
@@ -0,0 +1,232 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yaxigin/mto/pkg/asset"
+	"github.com/yaxigin/mto/pkg/config"
+	"github.com/yaxigin/mto/pkg/httpclient"
+	"github.com/yaxigin/mto/pkg/ratelimit"
+)
+
+// Config 配置文件中的 notify 部分
+type Config struct {
+	BatchSize   int      `yaml:"batch_size"`   // 每条消息最多列出的资产数量，默认 20
+	MaxMessages int      `yaml:"max_messages"` // 每次通知最多发送的消息数量，默认 5，超出的资产只计数不列出
+	Targets     []Target `yaml:"targets"`
+
+	// HTTP 发送通知使用的代理、超时等配置，非空字段覆盖全局 http 部分
+	HTTP httpclient.Config `yaml:"http"`
+}
+
+// Target 一个通知目标
+type Target struct {
+	Name    string            `yaml:"name"`    // 日志中显示的名称，默认为类型
+	Type    string            `yaml:"type"`    // webhook、dingtalk、feishu、wecom
+	URL     string            `yaml:"url"`     // Webhook 地址
+	Secret  string            `yaml:"secret"`  // 钉钉/飞书机器人的加签密钥，未开启加签时留空
+	Headers map[string]string `yaml:"headers"` // 通用 webhook 额外的请求头
+}
+
+const (
+	defaultBatchSize   = 20
+	defaultMaxMessages = 5
+)
+
+// Event 一次需要通知的运行结果
+type Event struct {
+	Title   string        // 如 "fofa 批量查询完成"
+	Source  string        // 查询文件
+	Summary []string      // 统计信息，每项一行
+	Assets  []asset.Asset // 新发现的资产
+}
+
+// Message 拆分后的一条消息
+type Message struct {
+	Title   string
+	Source  string
+	Summary []string
+	Assets  []asset.Asset // 本条消息列出的资产
+	Total   int           // 本次通知的资产总数
+	Omitted int           // 超出 MaxMessages 未列出的资产数量
+}
+
+// sender 各类机器人的发送实现
+type sender interface {
+	send(ctx context.Context, m Message) error
+}
+
+// target 带限速的通知目标
+type target struct {
+	name    string
+	sender  sender
+	limiter *ratelimit.Bucket
+}
+
+// Notifier 将运行结果发送到配置的全部通知目标
+type Notifier struct {
+	batchSize   int
+	maxMessages int
+	targets     []target
+}
+
+// Load 读取配置文件中的通知配置，没有配置通知目标时返回 nil
+func Load() (*Notifier, error) {
+	c, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	var conf struct {
+		Notify Config `yaml:"notify"`
+	}
+	if err := c.Unmarshal(&conf); err != nil {
+		return nil, err
+	}
+	if len(conf.Notify.Targets) == 0 {
+		return nil, nil
+	}
+	// mto config encrypt 会加密通知目标的地址、加签密钥和请求头
+	for i := range conf.Notify.Targets {
+		if err := decryptTarget(c, &conf.Notify.Targets[i]); err != nil {
+			return nil, fmt.Errorf("第 %d 个通知目标: %v", i+1, err)
+		}
+	}
+	conf.Notify.HTTP = c.HTTP.Merge(conf.Notify.HTTP)
+	return New(conf.Notify)
+}
+
+// decryptTarget 解密通知目标中加密保存的值
+func decryptTarget(c *config.Config, t *Target) error {
+	var err error
+	if t.URL, err = c.DecryptValue(t.URL); err != nil {
+		return err
+	}
+	if t.Secret, err = c.DecryptValue(t.Secret); err != nil {
+		return err
+	}
+	for k, v := range t.Headers {
+		if t.Headers[k], err = c.DecryptValue(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// New 根据配置创建通知器
+func New(c Config) (*Notifier, error) {
+	client, err := httpclient.Get(c.HTTP)
+	if err != nil {
+		return nil, fmt.Errorf("notify.http: %v", err)
+	}
+
+	n := &Notifier{batchSize: c.BatchSize, maxMessages: c.MaxMessages}
+	if n.batchSize <= 0 {
+		n.batchSize = defaultBatchSize
+	}
+	if n.maxMessages <= 0 {
+		n.maxMessages = defaultMaxMessages
+	}
+
+	for i, t := range c.Targets {
+		if t.URL == "" {
+			return nil, fmt.Errorf("第 %d 个通知目标未配置 url", i+1)
+		}
+		name := t.Name
+		if name == "" {
+			name = t.Type
+		}
+
+		var s sender
+		switch strings.ToLower(t.Type) {
+		case "webhook":
+			s = &webhook{client: client, url: t.URL, headers: t.Headers}
+		case "dingtalk":
+			s = &dingtalk{client: client, url: t.URL, secret: t.Secret}
+		case "feishu":
+			s = &feishu{client: client, url: t.URL, secret: t.Secret}
+		case "wecom":
+			s = &wecom{client: client, url: t.URL}
+		default:
+			return nil, fmt.Errorf("第 %d 个通知目标的类型 %q 不支持，可选: webhook、dingtalk、feishu、wecom", i+1, t.Type)
+		}
+		n.targets = append(n.targets, target{
+			name:    name,
+			sender:  s,
+			limiter: ratelimit.NewBucket(rates[strings.ToLower(t.Type)]),
+		})
+	}
+	return n, nil
+}
+
+// rates 各平台机器人的消息频率限制，钉钉和企业微信每分钟 20 条，飞书每分钟 100 条
+var rates = map[string]ratelimit.Rate{
+	"webhook":  {Every: 200 * time.Millisecond, Burst: 5},
+	"dingtalk": {Every: 3 * time.Second, Burst: 20},
+	"feishu":   {Every: 600 * time.Millisecond, Burst: 5},
+	"wecom":    {Every: 3 * time.Second, Burst: 20},
+}
+
+// Notify 将资产分批拆成多条消息发送到全部目标，没有新资产时不发送
+// 一个目标发送失败不影响其他目标，返回合并后的错误；ctx 取消时停止发送
+func (n *Notifier) Notify(ctx context.Context, ev Event) error {
+	if n == nil || len(ev.Assets) == 0 {
+		return nil
+	}
+
+	messages := n.split(ev)
+	var errs []error
+	for _, t := range n.targets {
+		for i, m := range messages {
+			if err := t.limiter.Wait(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("%s 第 %d/%d 条消息未发送: %v", t.name, i+1, len(messages), err))
+				return errors.Join(errs...)
+			}
+			if err := t.sender.send(ctx, m); err != nil {
+				errs = append(errs, fmt.Errorf("%s 第 %d/%d 条消息发送失败: %v", t.name, i+1, len(messages), err))
+				break
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// interruptTimeout 运行被中断后发送通知最多等待的时间
+const interruptTimeout = 30 * time.Second
+
+// SendContext 返回发送通知使用的 ctx
+// ctx 已被取消（运行被中断）时仍然发送本次的结果，最多等待 interruptTimeout，再次按 Ctrl-C 立即退出
+func SendContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx.Err() == nil {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(context.WithoutCancel(ctx), interruptTimeout)
+}
+
+// split 按 batchSize 拆分资产，最多 maxMessages 条消息
+func (n *Notifier) split(ev Event) []Message {
+	total := len(ev.Assets)
+	listed := min(total, n.batchSize*n.maxMessages)
+
+	var messages []Message
+	for start := 0; start < listed; start += n.batchSize {
+		end := min(start+n.batchSize, listed)
+		messages = append(messages, Message{
+			Title:   ev.Title,
+			Source:  ev.Source,
+			Summary: ev.Summary,
+			Assets:  ev.Assets[start:end],
+			Total:   total,
+		})
+	}
+	if len(messages) > 1 {
+		for i := range messages {
+			messages[i].Title = fmt.Sprintf("%s (%d/%d)", ev.Title, i+1, len(messages))
+		}
+	}
+	messages[len(messages)-1].Omitted = total - listed
+	return messages
+}
@@ -0,0 +1,260 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yaxigin/mto/pkg/asset"
+)
+
+// stripURL 去掉 *url.Error 中的地址，地址中可能有 access_token、sign 等参数，不能出现在日志中
+func stripURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// postJSON 以JSON格式发送请求，resp 不为空时解析响应
+func postJSON(ctx context.Context, client *http.Client, u string, headers map[string]string, payload, resp any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("构建请求体失败: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("构建请求失败: %v", stripURL(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	r, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %v", stripURL(err))
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode < 200 || r.StatusCode >= 300 {
+		return fmt.Errorf("请求失败，状态码: %d", r.StatusCode)
+	}
+	if resp != nil {
+		if err := json.NewDecoder(r.Body).Decode(resp); err != nil {
+			return fmt.Errorf("解析响应失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// assetLine 单个资产在消息中显示的内容
+func assetLine(a asset.Asset) string {
+	parts := []string{a.Key()}
+	if a.Title != "" {
+		parts = append(parts, a.Title)
+	}
+	if engines := a.Engines(); len(engines) > 0 {
+		parts = append(parts, "("+strings.Join(engines, "|")+")")
+	}
+	return strings.Join(parts, " ")
+}
+
+// markdown 钉钉和企业微信使用的 Markdown 内容
+func markdown(m Message) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s\n", m.Title)
+	if m.Source != "" {
+		fmt.Fprintf(&b, "> %s\n\n", m.Source)
+	}
+	for _, s := range m.Summary {
+		fmt.Fprintf(&b, "- %s\n", s)
+	}
+	fmt.Fprintf(&b, "\n**新资产 %d 条**\n\n", m.Total)
+	for _, a := range m.Assets {
+		if a.URL != "" {
+			fmt.Fprintf(&b, "- [%s](%s)\n", assetLine(a), a.URL)
+		} else {
+			fmt.Fprintf(&b, "- %s\n", assetLine(a))
+		}
+	}
+	if m.Omitted > 0 {
+		fmt.Fprintf(&b, "\n其余 %d 条资产未列出\n", m.Omitted)
+	}
+	return b.String()
+}
+
+// plain 飞书文本消息使用的纯文本内容
+func plain(m Message) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", m.Title)
+	if m.Source != "" {
+		fmt.Fprintf(&b, "%s\n", m.Source)
+	}
+	for _, s := range m.Summary {
+		fmt.Fprintf(&b, "%s\n", s)
+	}
+	fmt.Fprintf(&b, "新资产 %d 条:\n", m.Total)
+	for _, a := range m.Assets {
+		line := assetLine(a)
+		if a.URL != "" {
+			line += " " + a.URL
+		}
+		fmt.Fprintf(&b, "%s\n", line)
+	}
+	if m.Omitted > 0 {
+		fmt.Fprintf(&b, "其余 %d 条资产未列出\n", m.Omitted)
+	}
+	return b.String()
+}
+
+// hmacBase64 计算 HMAC-SHA256 并进行 base64 编码
+func hmacBase64(key, data string) string {
+	h := hmac.New(sha256.New, []byte(key))
+	h.Write([]byte(data))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// webhook 通用 JSON webhook，请求体包含标题、统计信息和本条消息的资产
+type webhook struct {
+	client  *http.Client
+	url     string
+	headers map[string]string
+}
+
+func (w *webhook) send(ctx context.Context, m Message) error {
+	payload := struct {
+		Title   string        `json:"title"`
+		Source  string        `json:"source,omitempty"`
+		Summary []string      `json:"summary,omitempty"`
+		Total   int           `json:"total"`
+		Omitted int           `json:"omitted,omitempty"`
+		Assets  []asset.Asset `json:"assets"`
+		Text    string        `json:"text"`
+	}{m.Title, m.Source, m.Summary, m.Total, m.Omitted, withoutRaw(m.Assets), plain(m)}
+	return postJSON(ctx, w.client, w.url, w.headers, payload, nil)
+}
+
+// withoutRaw 去掉引擎的原始数据，减小请求体
+func withoutRaw(assets []asset.Asset) []asset.Asset {
+	result := make([]asset.Asset, len(assets))
+	for i, a := range assets {
+		a.Raw = nil
+		result[i] = a
+	}
+	return result
+}
+
+// codeResponse 钉钉和企业微信的响应
+type codeResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// dingtalk 钉钉自定义机器人
+type dingtalk struct {
+	client *http.Client
+	url    string
+	secret string
+}
+
+// signedURL 开启加签时在地址后加上 timestamp 和 sign 参数
+// 签名为 HMAC-SHA256(secret, timestamp+"\n"+secret)，时间戳单位为毫秒
+func (d *dingtalk) signedURL() (string, error) {
+	if d.secret == "" {
+		return d.url, nil
+	}
+	u, err := url.Parse(d.url)
+	if err != nil {
+		return "", fmt.Errorf("解析地址失败: %v", stripURL(err))
+	}
+	ts := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	q := u.Query()
+	q.Set("timestamp", ts)
+	q.Set("sign", hmacBase64(d.secret, ts+"\n"+d.secret))
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func (d *dingtalk) send(ctx context.Context, m Message) error {
+	u, err := d.signedURL()
+	if err != nil {
+		return err
+	}
+	payload := map[string]any{
+		"msgtype":  "markdown",
+		"markdown": map[string]string{"title": m.Title, "text": markdown(m)},
+	}
+	var resp codeResponse
+	if err := postJSON(ctx, d.client, u, nil, payload, &resp); err != nil {
+		return err
+	}
+	if resp.ErrCode != 0 {
+		return fmt.Errorf("%d %s", resp.ErrCode, resp.ErrMsg)
+	}
+	return nil
+}
+
+// feishu 飞书自定义机器人
+type feishu struct {
+	client *http.Client
+	url    string
+	secret string
+}
+
+func (f *feishu) send(ctx context.Context, m Message) error {
+	payload := map[string]any{
+		"msg_type": "text",
+		"content":  map[string]string{"text": plain(m)},
+	}
+	// 签名以 timestamp+"\n"+secret 为密钥对空内容计算 HMAC-SHA256，时间戳单位为秒
+	if f.secret != "" {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		payload["timestamp"] = ts
+		payload["sign"] = hmacBase64(ts+"\n"+f.secret, "")
+	}
+
+	var resp struct {
+		Code int    `json:"code"`
+		Msg  string `json:"msg"`
+	}
+	if err := postJSON(ctx, f.client, f.url, nil, payload, &resp); err != nil {
+		return err
+	}
+	if resp.Code != 0 {
+		return fmt.Errorf("%d %s", resp.Code, resp.Msg)
+	}
+	return nil
+}
+
+// wecom 企业微信群机器人
+type wecom struct {
+	client *http.Client
+	url    string
+}
+
+func (w *wecom) send(ctx context.Context, m Message) error {
+	payload := map[string]any{
+		"msgtype":  "markdown",
+		"markdown": map[string]string{"content": markdown(m)},
+	}
+	var resp codeResponse
+	if err := postJSON(ctx, w.client, w.url, nil, payload, &resp); err != nil {
+		return err
+	}
+	if resp.ErrCode != 0 {
+		return fmt.Errorf("%d %s", resp.ErrCode, resp.ErrMsg)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yaxigin/mto/pkg/asset"
+)
+
+// sign 按平台文档计算签名，与 hmacBase64 的实现互相独立
+func sign(key, data string) string {
+	h := hmac.New(sha256.New, []byte(key))
+	h.Write([]byte(data))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// testMessage 测试发送的消息
+var testMessage = Message{Title: "测试", Assets: []asset.Asset{{IP: "1.1.1.1", Port: 80}}, Total: 1}
+
+func TestDingtalkSign(t *testing.T) {
+	tests := []struct {
+		name     string
+		secret   string
+		wantSign bool
+	}{
+		{"未开启加签", "", false},
+		{"开启加签", "SECtest", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var query url.Values
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				query = r.URL.Query()
+				w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+			}))
+			defer srv.Close()
+
+			d := &dingtalk{client: srv.Client(), url: srv.URL + "/robot/send?access_token=token", secret: tt.secret}
+			if err := d.send(context.Background(), testMessage); err != nil {
+				t.Fatal(err)
+			}
+
+			if query.Get("access_token") != "token" {
+				t.Errorf("access_token = %q, 原地址中的参数应保留", query.Get("access_token"))
+			}
+			ts := query.Get("timestamp")
+			if !tt.wantSign {
+				if ts != "" || query.Has("sign") {
+					t.Errorf("未开启加签时不应有 timestamp 和 sign: %v", query)
+				}
+				return
+			}
+
+			// 时间戳单位为毫秒
+			ms, err := strconv.ParseInt(ts, 10, 64)
+			if err != nil || time.Since(time.UnixMilli(ms)).Abs() > time.Minute {
+				t.Errorf("timestamp = %q, 应为当前时间的毫秒数", ts)
+			}
+			if want := sign(tt.secret, ts+"\n"+tt.secret); query.Get("sign") != want {
+				t.Errorf("sign = %q, want %q", query.Get("sign"), want)
+			}
+		})
+	}
+}
+
+func TestFeishuSign(t *testing.T) {
+	tests := []struct {
+		name     string
+		secret   string
+		wantSign bool
+	}{
+		{"未开启加签", "", false},
+		{"开启加签", "feishu-secret", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]any
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&body)
+				w.Write([]byte(`{"code":0,"msg":"success"}`))
+			}))
+			defer srv.Close()
+
+			f := &feishu{client: srv.Client(), url: srv.URL, secret: tt.secret}
+			if err := f.send(context.Background(), testMessage); err != nil {
+				t.Fatal(err)
+			}
+
+			ts, _ := body["timestamp"].(string)
+			if !tt.wantSign {
+				if _, ok := body["sign"]; ok || ts != "" {
+					t.Errorf("未开启加签时不应有 timestamp 和 sign: %v", body)
+				}
+				return
+			}
+
+			// 时间戳单位为秒，签名以 timestamp+"\n"+secret 为密钥对空内容计算
+			sec, err := strconv.ParseInt(ts, 10, 64)
+			if err != nil || time.Since(time.Unix(sec, 0)).Abs() > time.Minute {
+				t.Errorf("timestamp = %q, 应为当前时间的秒数", ts)
+			}
+			if want := sign(ts+"\n"+tt.secret, ""); body["sign"] != want {
+				t.Errorf("sign = %v, want %q", body["sign"], want)
+			}
+		})
+	}
+}
+
+func TestSendError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"errcode":310000,"errmsg":"sign not match"}`))
+	}))
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	defer srv.Close()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		url     string
+		wantErr string
+	}{
+		{"平台返回错误码", context.Background(), srv.URL + "/robot/send?access_token=secret-token", "310000 sign not match"},
+		{"连接失败时不输出地址", context.Background(), closed.URL + "/robot/send?access_token=secret-token", "请求失败"},
+		{"已取消", canceled, srv.URL + "/robot/send?access_token=secret-token", "context canceled"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &dingtalk{client: srv.Client(), url: tt.url, secret: "SECtest"}
+			err := d.send(tt.ctx, testMessage)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("send() error = %v, want %q", err, tt.wantErr)
+			}
+			for _, leak := range []string{"secret-token", "sign=", "timestamp="} {
+				if strings.Contains(err.Error(), leak) {
+					t.Errorf("错误信息中包含 %s: %v", leak, err)
+				}
+			}
+		})
+	}
+}
+
+func TestNotifyCanceled(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer srv.Close()
+
+	n, err := New(Config{Targets: []Target{{Type: "webhook", URL: srv.URL}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := n.Notify(ctx, Event{Title: "测试", Assets: testMessage.Assets}); err == nil {
+		t.Error("ctx 已取消时 Notify() 应返回错误")
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("ctx 已取消时发送了 %d 个请求", got)
+	}
+
+	// 中断后使用 SendContext 仍然发送本次的结果
+	sendCtx, cancelSend := SendContext(ctx)
+	defer cancelSend()
+	if err := n.Notify(sendCtx, Event{Title: "测试", Assets: testMessage.Assets}); err != nil {
+		t.Fatal(err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("SendContext 发送了 %d 个请求, want 1", got)
+	}
+}
@@ -0,0 +1,122 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yaxigin/mto/pkg/asset"
+)
+
+// CSVHeader 统一的CSV表头，与 CSVRecord 的字段顺序一致
+var CSVHeader = []string{
+	"IP", "Port", "Domain", "Host", "URL", "Title", "Server", "Protocol",
+	"ICP", "Org", "ISP", "Country", "Components", "Source", "LastSeen",
+}
+
+// CSVRecord 将资产转换为CSV行
+func CSVRecord(a asset.Asset) []string {
+	var lastSeen string
+	if a.LastSeen != nil {
+		lastSeen = a.LastSeen.Format(time.DateTime)
+	}
+	return []string{
+		a.IP, a.PortString(), a.Domain, a.Host, a.URL, a.Title, a.Server, a.Protocol,
+		a.ICP, a.Org, a.ISP, a.Country, strings.Join(a.Components, ","), strings.Join(a.Engines(), "|"), lastSeen,
+	}
+}
+
+// BatchHeader 批量查询输出的表头，在 CSVHeader 之后增加产生该行的查询语句
+var BatchHeader = append(slices.Clone(CSVHeader), "Query")
+
+// CSVWriter 批量查询的CSV输出，整个运行期间只打开一次文件，只写一次表头
+// 可以被多个协程同时使用，每次写入的行不会交错
+type CSVWriter struct {
+	mu sync.Mutex
+	f  *os.File
+	w  *csv.Writer
+}
+
+// NewCSVWriter 打开输出文件，appendMode 为 true 时以追加模式打开，否则清空文件，文件为空时写入BOM和表头
+// 追加时检查已有文件的表头是否一致，避免把不同格式的数据写进同一个文件
+func NewCSVWriter(outputFile string, appendMode bool) (*CSVWriter, error) {
+	flag := os.O_APPEND | os.O_CREATE | os.O_RDWR
+	if !appendMode {
+		flag = os.O_CREATE | os.O_RDWR | os.O_TRUNC
+	}
+	f, err := os.OpenFile(outputFile, flag, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %v", err)
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("读取文件信息失败: %v", err)
+	}
+
+	w := &CSVWriter{f: f, w: csv.NewWriter(f)}
+	if fi.Size() == 0 {
+		f.WriteString("\xEF\xBB\xBF") // UTF-8 BOM
+		if err := w.w.Write(BatchHeader); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("写入表头失败: %v", err)
+		}
+		w.w.Flush()
+		return w, w.w.Error()
+	}
+
+	if err := checkHeader(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// checkHeader 检查已有文件的表头与 BatchHeader 是否一致
+func checkHeader(f *os.File) error {
+	header, err := csv.NewReader(io.NewSectionReader(f, 0, 1<<20)).Read()
+	if err != nil {
+		return fmt.Errorf("读取 %s 的表头失败: %v", f.Name(), err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\xEF\xBB\xBF")
+	}
+	if !slices.Equal(header, BatchHeader) {
+		return fmt.Errorf("%s 的表头与当前输出格式不一致，请指定新的输出文件", f.Name())
+	}
+	return nil
+}
+
+// Write 写入一组资产，query 为产生这些资产的查询语句
+// 每次写入后立即刷新到文件，中断时已写入的数据不会丢失
+func (w *CSVWriter) Write(assets []asset.Asset, query string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, a := range assets {
+		if err := w.w.Write(append(CSVRecord(a), query)); err != nil {
+			return fmt.Errorf("写入数据行失败: %v", err)
+		}
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// Close 关闭输出文件
+func (w *CSVWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.w.Flush()
+	if err := w.w.Error(); err != nil {
+		w.f.Close()
+		return err
+	}
+	return w.f.Close()
+}
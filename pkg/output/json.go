@@ -0,0 +1,171 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/yaxigin/mto/pkg/asset"
+)
+
+// queryAsset 批量查询输出的JSON记录，在资产字段之外记录产生它的查询语句
+type queryAsset struct {
+	asset.Asset
+	Query string `json:"query"`
+}
+
+// JSON 以JSON数组输出资产，没有结果时输出空数组
+func JSON(w io.Writer, assets []asset.Asset) error {
+	if assets == nil {
+		assets = []asset.Asset{}
+	}
+	data, err := json.MarshalIndent(assets, "", "  ")
+	if err != nil {
+		return fmt.Errorf("生成JSON失败: %v", err)
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// JSONL 每行输出一个资产
+func JSONL(w io.Writer, assets []asset.Asset) error {
+	enc := json.NewEncoder(w)
+	for _, a := range assets {
+		if err := enc.Encode(a); err != nil {
+			return fmt.Errorf("生成JSON失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// JSONWriter 批量查询的JSON输出，整个运行期间只打开一次文件
+// lines 为 true 时每行一条记录(JSON Lines)，否则所有记录组成一个JSON数组
+type JSONWriter struct {
+	mu    sync.Mutex
+	f     *os.File
+	lines bool
+	count int // 数组中已有的记录数
+}
+
+// NewJSONWriter 打开输出文件，appendMode 为 true 时在已有内容之后继续追加，否则清空文件
+// JSON数组文件会去掉末尾的 ]，关闭时再补上；中途退出时缺少 ] 的文件也可以继续追加
+func NewJSONWriter(outputFile string, lines, appendMode bool) (*JSONWriter, error) {
+	flag := os.O_CREATE | os.O_RDWR
+	if !appendMode {
+		flag |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(outputFile, flag, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %v", err)
+	}
+
+	w := &JSONWriter{f: f, lines: lines}
+	if err := w.seekEnd(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// seekEnd 定位到追加写入的位置
+func (w *JSONWriter) seekEnd() error {
+	content, err := io.ReadAll(w.f)
+	if err != nil {
+		return fmt.Errorf("读取 %s 失败: %v", w.f.Name(), err)
+	}
+	if w.lines {
+		_, err := w.f.Seek(0, io.SeekEnd)
+		return err
+	}
+
+	trimmed := bytes.TrimRight(content, " \t\r\n")
+	switch {
+	case len(trimmed) == 0:
+		_, err = w.f.WriteString("[\n")
+		return err
+	case trimmed[len(trimmed)-1] == ']':
+		trimmed = bytes.TrimRight(trimmed[:len(trimmed)-1], " \t\r\n")
+	}
+
+	var last byte
+	if len(trimmed) > 0 {
+		last = trimmed[len(trimmed)-1]
+	}
+	if last != '[' && last != '}' {
+		return fmt.Errorf("%s 不是有效的JSON数组，请指定新的输出文件", w.f.Name())
+	}
+
+	if err := w.f.Truncate(int64(len(trimmed))); err != nil {
+		return fmt.Errorf("写入 %s 失败: %v", w.f.Name(), err)
+	}
+	if _, err := w.f.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+	if last == '[' {
+		_, err = w.f.WriteString("\n")
+		return err
+	}
+	w.count = 1
+	return nil
+}
+
+// Write 写入一组资产，query 为产生这些资产的查询语句
+func (w *JSONWriter) Write(assets []asset.Asset, query string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var buf bytes.Buffer
+	for _, a := range assets {
+		var (
+			data []byte
+			err  error
+		)
+		if w.lines {
+			data, err = json.Marshal(queryAsset{a, query})
+		} else {
+			data, err = json.MarshalIndent(queryAsset{a, query}, "  ", "  ")
+		}
+		if err != nil {
+			return fmt.Errorf("生成JSON失败: %v", err)
+		}
+
+		switch {
+		case w.lines:
+		case w.count > 0:
+			buf.WriteString(",\n  ")
+		default:
+			buf.WriteString("  ")
+		}
+		buf.Write(data)
+		if w.lines {
+			buf.WriteByte('\n')
+		}
+		w.count++
+	}
+
+	if _, err := w.f.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("写入数据失败: %v", err)
+	}
+	return nil
+}
+
+// Close 补全JSON数组并关闭文件
+func (w *JSONWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.lines {
+		end := "]\n"
+		if w.count > 0 {
+			end = "\n]\n"
+		}
+		if _, err := w.f.WriteString(end); err != nil {
+			w.f.Close()
+			return fmt.Errorf("写入数据失败: %v", err)
+		}
+	}
+	return w.f.Close()
+}
@@ -0,0 +1,92 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/yaxigin/mto/pkg/asset"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// Mode 终端输出方式
+type Mode int
+
+const (
+	ModeTable Mode = iota // 表格输出所有信息
+	ModeLinks             // 只输出去重后的链接
+	ModeIPs               // 只输出去重后的IP
+	ModeJSON              // JSON数组，包含引擎返回的全部字段
+	ModeJSONL             // JSON Lines，每行一个资产
+)
+
+// Print 按指定方式输出资产
+func Print(w io.Writer, assets []asset.Asset, mode Mode) {
+	switch mode {
+	case ModeIPs:
+		for _, ip := range asset.UniqueIPs(assets) {
+			fmt.Fprintln(w, ip)
+		}
+	case ModeLinks:
+		for _, url := range asset.UniqueURLs(assets) {
+			fmt.Fprintln(w, url)
+		}
+	case ModeJSON:
+		JSON(w, assets)
+	case ModeJSONL:
+		JSONL(w, assets)
+	default:
+		Table(w, assets)
+	}
+}
+
+// Table 以表格形式输出资产，合并了多个引擎的结果时额外输出来源列
+func Table(w io.Writer, assets []asset.Asset) {
+	merged := false
+	for _, a := range assets {
+		if len(a.Sources) > 0 {
+			merged = true
+			break
+		}
+	}
+
+	headers := []string{"IP", "Domain", "Port", "Protocol", "URL", "Title", "Server"}
+	headerColors := []tablewriter.Colors{
+		{tablewriter.Bold, tablewriter.BgGreenColor},
+		{tablewriter.FgHiRedColor, tablewriter.Bold, tablewriter.BgBlackColor},
+		{tablewriter.BgRedColor, tablewriter.FgWhiteColor},
+		{tablewriter.BgCyanColor, tablewriter.FgWhiteColor},
+		{tablewriter.BgCyanColor, tablewriter.FgWhiteColor},
+		{tablewriter.BgCyanColor, tablewriter.FgWhiteColor},
+		{tablewriter.BgCyanColor, tablewriter.FgWhiteColor},
+	}
+	columnColors := []tablewriter.Colors{
+		{tablewriter.Bold, tablewriter.FgHiBlackColor},
+		{tablewriter.Bold, tablewriter.FgHiBlackColor},
+		{tablewriter.Bold, tablewriter.FgHiRedColor},
+		{tablewriter.Bold, tablewriter.FgHiBlackColor},
+		{tablewriter.Bold, tablewriter.FgBlackColor},
+		{tablewriter.Bold, tablewriter.FgBlackColor},
+		{tablewriter.Bold, tablewriter.FgBlackColor},
+	}
+	if merged {
+		headers = append(headers, "Source")
+		headerColors = append(headerColors, tablewriter.Colors{tablewriter.BgCyanColor, tablewriter.FgWhiteColor})
+		columnColors = append(columnColors, tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiBlackColor})
+	}
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(headers)
+	table.SetHeaderColor(headerColors...)
+	table.SetColumnColor(columnColors...)
+
+	for _, a := range assets {
+		row := []string{a.IP, a.Domain, a.PortString(), a.Protocol, a.URL, a.Title, a.Server}
+		if merged {
+			row = append(row, strings.Join(a.Engines(), ","))
+		}
+		table.Append(row)
+	}
+	table.Render()
+}
@@ -0,0 +1,49 @@
+package output
+
+import (
+	"fmt"
+
+	"github.com/yaxigin/mto/pkg/asset"
+)
+
+// Format 文件输出格式
+type Format int
+
+const (
+	FormatCSV   Format = iota // 带BOM的CSV，便于Excel打开
+	FormatJSON                // JSON数组
+	FormatJSONL               // JSON Lines，每行一条记录
+)
+
+// Ext 返回输出格式对应的文件扩展名
+func (f Format) Ext() string {
+	switch f {
+	case FormatJSON:
+		return ".json"
+	case FormatJSONL:
+		return ".jsonl"
+	default:
+		return ".csv"
+	}
+}
+
+// Writer 批量查询结果输出，整个运行期间只打开一次文件，可被多个协程同时使用
+type Writer interface {
+	// Write 写入一组资产，query 为产生这些资产的查询语句
+	Write(assets []asset.Asset, query string) error
+	// Close 刷新并关闭文件
+	Close() error
+}
+
+// NewWriter 按输出格式打开输出文件，appendMode 为 true 时在已有内容之后继续写入，否则清空文件
+func NewWriter(format Format, outputFile string, appendMode bool) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return NewCSVWriter(outputFile, appendMode)
+	case FormatJSON:
+		return NewJSONWriter(outputFile, false, appendMode)
+	case FormatJSONL:
+		return NewJSONWriter(outputFile, true, appendMode)
+	}
+	return nil, fmt.Errorf("未知的输出格式: %d", format)
+}
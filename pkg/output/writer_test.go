@@ -0,0 +1,194 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yaxigin/mto/pkg/asset"
+)
+
+// writeFile 在临时目录中创建输出文件，content 为 nil 时不创建
+func writeFile(t *testing.T, name string, content []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if content != nil {
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+// writeAssets 打开输出文件并依次写入每个IP一个资产，查询语句为IP本身
+func writeAssets(format Format, path string, appendMode bool, ips ...string) error {
+	w, err := NewWriter(format, path, appendMode)
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if err := w.Write([]asset.Asset{{IP: ip, Port: 80}}, ip); err != nil {
+			w.Close()
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// queries 返回输出文件中每条记录的查询语句
+func queries(t *testing.T, format Format, content []byte) []string {
+	t.Helper()
+	var result []string
+	switch format {
+	case FormatJSON:
+		var records []queryAsset
+		if err := json.Unmarshal(content, &records); err != nil {
+			t.Fatalf("输出不是有效的JSON数组: %v\n%s", err, content)
+		}
+		for _, r := range records {
+			result = append(result, r.Query)
+		}
+	case FormatJSONL:
+		for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+			if line == "" {
+				continue
+			}
+			var r queryAsset
+			if err := json.Unmarshal([]byte(line), &r); err != nil {
+				t.Fatalf("解析JSON行失败: %v\n%s", err, line)
+			}
+			result = append(result, r.Query)
+		}
+	default:
+		if !bytes.HasPrefix(content, []byte("\xEF\xBB\xBF")) {
+			t.Errorf("CSV文件应以BOM开头")
+		}
+		records, err := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(content, []byte("\xEF\xBB\xBF")))).ReadAll()
+		if err != nil {
+			t.Fatalf("解析CSV失败: %v", err)
+		}
+		for i, r := range records {
+			if i == 0 {
+				if strings.Join(r, ",") != strings.Join(BatchHeader, ",") {
+					t.Errorf("表头 = %v, want %v", r, BatchHeader)
+				}
+				continue
+			}
+			result = append(result, r[len(r)-1])
+		}
+	}
+	return result
+}
+
+func TestWriterAppend(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		append bool
+		first  []string // 第一次运行写入的记录，为 nil 时输出文件不存在
+		second []string
+		want   []string
+	}{
+		{name: "CSV新文件", format: FormatCSV, second: []string{"a", "b"}, want: []string{"a", "b"}},
+		{name: "CSV清空已有文件", format: FormatCSV, first: []string{"a"}, second: []string{"b"}, want: []string{"b"}},
+		{name: "CSV追加", format: FormatCSV, append: true, first: []string{"a"}, second: []string{"b"}, want: []string{"a", "b"}},
+		{name: "JSON新文件", format: FormatJSON, second: []string{"a", "b"}, want: []string{"a", "b"}},
+		{name: "JSON没有结果", format: FormatJSON, second: []string{}, want: nil},
+		{name: "JSON清空已有文件", format: FormatJSON, first: []string{"a"}, second: []string{"b"}, want: []string{"b"}},
+		{name: "JSON追加", format: FormatJSON, append: true, first: []string{"a"}, second: []string{"b", "c"}, want: []string{"a", "b", "c"}},
+		{name: "JSON追加到空数组", format: FormatJSON, append: true, first: []string{}, second: []string{"b"}, want: []string{"b"}},
+		{name: "JSONL清空已有文件", format: FormatJSONL, first: []string{"a"}, second: []string{"b"}, want: []string{"b"}},
+		{name: "JSONL追加", format: FormatJSONL, append: true, first: []string{"a"}, second: []string{"b"}, want: []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, "result"+tt.format.Ext(), nil)
+			if tt.first != nil {
+				if err := writeAssets(tt.format, path, false, tt.first...); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := writeAssets(tt.format, path, tt.append, tt.second...); err != nil {
+				t.Fatal(err)
+			}
+
+			content, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := queries(t, tt.format, content); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("记录 = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONWriterSeekEnd(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		wantErr bool
+	}{
+		{name: "空文件", content: "", want: []string{"b"}},
+		{name: "只有空白", content: "\n \n", want: []string{"b"}},
+		{name: "空数组", content: "[]\n", want: []string{"b"}},
+		{name: "完整的数组", content: "[\n  {\"ip\": \"1.1.1.1\", \"query\": \"a\"}\n]\n", want: []string{"a", "b"}},
+		{name: "中途退出缺少 ]", content: "[\n  {\"ip\": \"1.1.1.1\", \"query\": \"a\"}", want: []string{"a", "b"}},
+		{name: "只有 [", content: "[\n", want: []string{"b"}},
+		{name: "CRLF换行", content: "[\r\n  {\"ip\": \"1.1.1.1\", \"query\": \"a\"}\r\n]\r\n", want: []string{"a", "b"}},
+		{name: "不是JSON数组", content: "ip,port\n1.1.1.1,80\n", wantErr: true},
+		{name: "不完整的记录", content: "[\n  {\"ip\": \"1.1.1.1\",", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, "result.json", []byte(tt.content))
+			err := writeAssets(FormatJSON, path, true, "b")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewJSONWriter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			content, rerr := os.ReadFile(path)
+			if rerr != nil {
+				t.Fatal(rerr)
+			}
+			if err != nil {
+				// 无法追加时不修改原文件
+				if string(content) != tt.content {
+					t.Errorf("文件被修改:\n%s", content)
+				}
+				return
+			}
+			if got := queries(t, FormatJSON, content); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("记录 = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVWriterHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "带BOM的表头", content: "\xEF\xBB\xBF" + strings.Join(BatchHeader, ",") + "\n"},
+		{name: "没有BOM的表头", content: strings.Join(BatchHeader, ",") + "\n"},
+		{name: "旧版表头", content: "IP,Port,Title\n1.1.1.1,80,a\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, "result.csv", []byte(tt.content))
+			w, err := NewCSVWriter(path, true)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewCSVWriter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				w.Close()
+			}
+		})
+	}
+}
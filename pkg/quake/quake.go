@@ -2,31 +2,28 @@ package quake
 
 import (
 	"bytes"
-	"encoding/csv"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
-	"strings"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
 	"time"
 
-	"github.com/yaxigin/mto/pkg/config"
-
-	"github.com/olekukonko/tablewriter"
-	"github.com/parnurzeal/gorequest"
-	"gopkg.in/yaml.v2"
+	"github.com/yaxigin/mto/pkg/asset"
+	"github.com/yaxigin/mto/pkg/engine"
+	"github.com/yaxigin/mto/pkg/query"
+	"github.com/yaxigin/mto/pkg/ratelimit"
 )
 
 const (
 	//filePath = "./config/config.yml"
-	apiURL = "https://quake.360.net/api/v3/search/quake_service"
+	apiURL      = "https://quake.360.net/api/v3/search/quake_service"
+	userInfoURL = "https://quake.360.net/api/v3/user/info"
 )
 
-type Config struct {
-	Quake struct {
-		Key string `yaml:"key"`
-	}
-}
-
 type QuakeRequest struct {
 	Query     string `json:"query"`
 	Start     int    `json:"start"`
@@ -50,6 +47,19 @@ type QuakeResponse struct {
 	} `json:"meta"`
 }
 
+// QuakeUser 用户信息接口返回的 data
+type QuakeUser struct {
+	User struct {
+		Username string `json:"username"`
+		Email    string `json:"email"`
+	} `json:"user"`
+	Credit           int `json:"credit"`            // 月度积分
+	PersistentCredit int `json:"persistent_credit"` // 长效积分
+	Role             []struct {
+		Fullname string `json:"fullname"`
+	} `json:"role"`
+}
+
 //
 
 // 计算时间范围
@@ -73,159 +83,313 @@ func calculateTimeRange(months int) (string, string) {
 	return startTime.Format("2006-01-02 15:04:05"), endTime.Format("2006-01-02 15:04:05")
 }
 
-func QUCMD(query string, months int, h bool, onlyIP bool) error {
-	conf := Config{}
-	content, err := os.ReadFile(config.GetConfigPath())
-	if err != nil {
-		return fmt.Errorf("配置文件读取错误: %v", err)
+// Client Quake API 客户端，实现 engine.Engine 接口
+// 客户端不读取配置文件也不写终端，日志通过 engine.ClientOptions.Logger 输出
+type Client struct {
+	key     string
+	opts    engine.ClientOptions
+	log     engine.Logger
+	limiter *ratelimit.Limiter
+}
+
+// NewClient 使用API密钥创建客户端
+func NewClient(key string, opts engine.ClientOptions) *Client {
+	return &Client{key: key, opts: opts, log: opts.Log(), limiter: opts.Limiter("quake", defaultRate)}
+}
+
+func init() {
+	engine.Register("quake", func(key string, opts engine.ClientOptions) engine.Engine {
+		return NewClient(key, opts)
+	})
+}
+
+// Name 返回引擎名称
+func (c *Client) Name() string {
+	return "quake"
+}
+
+// Description 返回模块简介
+func (c *Client) Description() string {
+	return "从360 Quake获取资产信息。"
+}
+
+// Syntax 返回Quake语法参考
+func (c *Client) Syntax() string {
+	return syntaxHelp
+}
+
+// prepareQuery 检查查询语句并补全引号
+func (c *Client) prepareQuery(s string, opts engine.Options) (string, error) {
+	if s == "" {
+		return "", fmt.Errorf("查询语句不能为空")
 	}
-	if err := yaml.Unmarshal(content, &conf); err != nil {
-		return fmt.Errorf("解析config.yaml出错: %v", err)
+	if c.key == "" {
+		return "", fmt.Errorf("Quake API密钥未配置")
+	}
+	if err := engine.Preflight(query.Quake, s, opts, c.log); err != nil {
+		return "", err
 	}
 
-	startTime, endTime := calculateTimeRange(months)
+	normalized, err := query.Normalize(query.Quake, s)
+	if err != nil {
+		return "", err
+	}
+
+	c.log.Infof("原始查询语句: %s", s)
+	c.log.Infof("处理后的查询语句: %s", normalized)
 
-	// 处理查询语句
-	// 保存原始查询语句以便输出
-	originalQuery := query
+	return normalized, nil
+}
+
+const (
+	pageSize   = 3000  // 每次请求获取的结果数量
+	maxResults = 10000 // Quake API 最多获取前 10000 条结果
+)
 
-	// 检查查询语句是否包含逻辑运算符
-	// Quake 使用 AND 和 OR 作为逻辑运算符，而不是 && 和 ||
-	if strings.Contains(query, " AND ") || strings.Contains(query, " OR ") {
-		// 处理复杂查询（包含逻辑运算符）
-		query = processComplexQuery(query)
-	} else if strings.Contains(query, ":") {
-		// 处理简单查询（单个键值对）
-		query = processSimpleQuery(query)
+// Search 执行查询并返回全部资产
+func (c *Client) Search(ctx context.Context, s string, opts engine.Options) ([]asset.Asset, error) {
+	normalized, err := c.prepareQuery(s, opts)
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("原始查询语句: %s\n", originalQuery)
-	fmt.Printf("处理后的查询语句: %s\n", query)
+	startTime, endTime := calculateTimeRange(opts.Months)
 
 	// 构建请求体
 	reqBody := QuakeRequest{
-		Query:     query,
+		Query:     normalized,
 		Start:     0,
-		Size:      3000,
+		Size:      pageSize,
 		Latest:    true,
 		StartTime: startTime,
 		EndTime:   endTime,
 	}
-	fmt.Println(reqBody)
+	// 断点续传时从上次的位置继续
+	if opts.Cursor != "" {
+		start, err := strconv.Atoi(opts.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("无效的翻页位置: %s", opts.Cursor)
+		}
+		reqBody.Start = start
+		c.log.Infof("从第 %d 条结果继续获取", start)
+	}
+
+	// 最多获取前 maxResults 条，指定了最大结果数量时只获取前 limit 条
+	// 最后一页只请求剩余的数量，保证 Start+Size 不超过上限
+	limit := maxResults
+	if opts.MaxResults > 0 {
+		limit = min(limit, opts.MaxResults)
+	}
+
 	// 发起请求
-	var results [][]string
+	var results []asset.Asset
 	for {
+		if reqBody.Start >= limit {
+			break
+		}
+		reqBody.Size = min(pageSize, limit-reqBody.Start)
+		if err := ctx.Err(); err != nil {
+			return results, engine.Stop(ctx, err, len(results), fmt.Sprintf("第 %d 条", reqBody.Start), strconv.Itoa(reqBody.Start))
+		}
+
 		var response QuakeResponse
-		if err := makeRequest(conf.Quake.Key, reqBody, &response); err != nil {
-			// 检查是否是数据限制错误
-			if err.Error() == "API错误: q2001 - 网页查询最大允许查询10000条数据。" {
-				fmt.Println("\n注意: 已达到查询上限(10000条数据)，只显示已获取的结果。")
+		if err := c.makeRequest(ctx, reqBody, &response); err != nil {
+			// 达到数据上限时返回已获取的结果
+			if errors.Is(err, engine.ErrResultCap) {
+				c.log.Warningf("已达到查询上限(%d条数据)，只返回已获取的结果", maxResults)
 				break
 			}
-			return err
+			return results, engine.Stop(ctx, err, len(results), fmt.Sprintf("第 %d 条", reqBody.Start), strconv.Itoa(reqBody.Start))
 		}
 
 		// 处理结果
 		pageResults := processResults(response)
 		results = append(results, pageResults...)
-
-		//fmt.Println(results)
+		c.log.Infof("当前已获取 %d 条结果，查询总数量: %d", len(results), response.Meta.Pagination.Total)
 
 		// 检查是否需要翻页
-		if len(results) >= response.Meta.Pagination.Total || len(pageResults) == 0 {
+		next := reqBody.Start + reqBody.Size
+		if reqBody.Start+len(pageResults) >= response.Meta.Pagination.Total || len(pageResults) == 0 {
+			opts.Report(pageResults, "")
 			break
 		}
 
-		// 检查是否即将超过10000条限制
-		if reqBody.Start+reqBody.Size >= 10000 {
-			fmt.Println("\n注意: 已达到查询上限(10000条数据)，只显示已获取的结果。")
+		if next >= limit {
+			opts.Report(pageResults, "")
+			if limit < maxResults {
+				c.log.Infof("已获取指定的最大结果数量(%d条)", limit)
+			} else {
+				c.log.Warningf("已达到查询上限(%d条数据)，只返回已获取的结果", maxResults)
+			}
 			break
 		}
 
-		reqBody.Start += reqBody.Size
+		opts.Report(pageResults, strconv.Itoa(next))
+		reqBody.Start = next
 	}
 
-	// 输出结果
-	if onlyIP {
-		// for _, result := range results {
-		// 	fmt.Println(result[0]) // IP
-		// }
-		uniqueIP := deduplicateIP(results)
-		for _, ip := range uniqueIP {
-			fmt.Println(ip)
-		}
-	} else if h {
-		uniqueURLs := deduplicateURLs(results)
-		for _, url := range uniqueURLs {
-			fmt.Println(url)
-		}
+	return results, nil
+}
 
-		// for _, result := range results {
-		// 	fmt.Println(result[7]) // URL
-		// }
-	} else {
-		data(results)
+// Estimate 估算获取数据的开销，Quake 最多获取 10000 条结果或指定的最大结果数量，按返回的数据量消耗积分
+func (c *Client) Estimate(total int, opts engine.Options) engine.Estimate {
+	fetch := min(total, maxResults)
+	if opts.MaxResults > 0 {
+		fetch = min(fetch, opts.MaxResults)
+	}
+	return engine.Estimate{
+		Total: total,
+		Fetch: fetch,
+		Pages: engine.Pages(fetch, pageSize),
+		Cost:  fetch,
+		Unit:  "积分",
 	}
+}
 
-	return nil
+// Count 只获取查询结果总数
+func (c *Client) Count(ctx context.Context, s string, opts engine.Options) (int, error) {
+	normalized, err := c.prepareQuery(s, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	startTime, endTime := calculateTimeRange(opts.Months)
+	reqBody := QuakeRequest{
+		Query:     normalized,
+		Start:     0,
+		Size:      1,
+		Latest:    true,
+		StartTime: startTime,
+		EndTime:   endTime,
+	}
+
+	var response QuakeResponse
+	if err := c.makeRequest(ctx, reqBody, &response); err != nil {
+		return 0, err
+	}
+	return response.Meta.Pagination.Total, nil
+}
+
+// Account 查询API密钥对应的用户信息，不消耗积分
+func (c *Client) Account(ctx context.Context) (engine.Account, error) {
+	if c.key == "" {
+		return engine.Account{}, fmt.Errorf("Quake API密钥未配置")
+	}
+
+	var response QuakeResponse
+	err := engine.Retry(ctx, c.opts.Backoff, c.log, func() error {
+		return c.doRequest(ctx, http.MethodGet, c.opts.URL(userInfoURL), nil, &response)
+	})
+	if err != nil {
+		return engine.Account{}, err
+	}
+
+	// data 在查询接口中是资产列表，这里是用户信息，重新解析为 QuakeUser
+	var user QuakeUser
+	data, _ := json.Marshal(response.Data)
+	if err := json.Unmarshal(data, &user); err != nil {
+		return engine.Account{}, fmt.Errorf("解析用户信息失败: %v", err)
+	}
+
+	account := engine.Account{
+		Engine:    "quake",
+		Key:       engine.MaskKey(c.key),
+		User:      user.User.Username,
+		Quota:     user.Credit + user.PersistentCredit,
+		QuotaUnit: "积分",
+		QueryLeft: -1,
+	}
+	if account.User == "" {
+		account.User = user.User.Email
+	}
+	for _, r := range user.Role {
+		if account.Level != "" {
+			account.Level += "、"
+		}
+		account.Level += r.Fullname
+	}
+	return account, nil
 }
 
-func makeRequest(key string, reqBody QuakeRequest, response *QuakeResponse) error {
+// defaultRate 未配置 rate 时的请求限速，单个密钥两次请求之间至少间隔3秒
+var defaultRate = ratelimit.Rate{Every: 3 * time.Second, Burst: 1}
+
+// errorCodes Quake 错误码对应的错误类型，未收录的错误码根据错误信息判断
+// q2001 既用于超过 10000 条的数据上限，也用于其他查询错误，不在这里收录
+var errorCodes = map[string]error{
+	"q3005": engine.ErrRateLimit, // API请求频率过快
+	"t6003": engine.ErrAuth,      // API认证失败
+	"u3007": engine.ErrQuota,     // 积分不足
+}
+
+// makeRequest 发送查询请求并解析响应，临时错误按 ClientOptions.Backoff 重试
+func (c *Client) makeRequest(ctx context.Context, reqBody QuakeRequest, response *QuakeResponse) error {
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
 		return fmt.Errorf("构建请求体失败: %v", err)
 	}
+	return engine.Retry(ctx, c.opts.Backoff, c.log, func() error {
+		return c.doRequest(ctx, http.MethodPost, c.opts.URL(apiURL), jsonBody, response)
+	})
+}
 
-	// 添加3秒延时
-	time.Sleep(3 * time.Second)
+// doRequest 发送一次请求，API密钥通过 X-QuakeToken 请求头传递，body 为空时不发送请求体
+func (c *Client) doRequest(ctx context.Context, method, api string, jsonBody []byte, response *QuakeResponse) error {
+	if err := c.limiter.Wait(ctx, c.key); err != nil {
+		return err
+	}
 
-	request := gorequest.New()
-	resp, body, errs := request.Post(apiURL).
-		Set("X-QuakeToken", key).
-		Set("Content-Type", "application/json").
-		Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36").
-		Set("Accept", "application/json, text/plain, */*").
-		Set("Accept-Language", "zh-CN,zh;q=0.9").
-		Set("Connection", "keep-alive").
-		Send(bytes.NewBuffer(jsonBody).String()).
-		End()
+	var reqBody io.Reader
+	if jsonBody != nil {
+		reqBody = bytes.NewReader(jsonBody)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, api, reqBody)
+	if err != nil {
+		return fmt.Errorf("构建请求失败: %v", err)
+	}
+	req.Header.Set("X-QuakeToken", c.key)
+	if jsonBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 
-	if len(errs) > 0 {
-		return errs[0]
+	resp, err := c.opts.HTTP().Do(req)
+	if err != nil {
+		return engine.RequestError(ctx, "quake", err)
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return fmt.Errorf("请求失败，状态码: %d", resp.StatusCode)
+		return engine.NewAPIError("quake", resp.StatusCode, "", "", nil)
 	}
 
-	// 输出请求和响应信息
-	// fmt.Printf("Request Body: %s\n", jsonBody)
-	// fmt.Printf("Response Status: %d\n", resp.StatusCode)
-	// fmt.Printf("Response Body: %s\n", body)
-
-	if err := json.Unmarshal([]byte(body), response); err != nil {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return engine.RequestError(ctx, "quake", err)
+	}
+	if err := json.Unmarshal(body, response); err != nil {
 		return fmt.Errorf("解析响应失败: %v\n响应内容: %s", err, body)
 	}
 
 	// 检查 API 错误响应
+	var code string
 	switch v := response.Code.(type) {
 	case float64:
-		if v != 0 {
-			return fmt.Errorf("API错误: %v - %s", v, response.Message)
-		}
+		code = strconv.FormatFloat(v, 'f', -1, 64)
 	case string:
-		if v != "0" {
-			return fmt.Errorf("API错误: %v - %s", v, response.Message)
-		}
+		code = v
 	default:
 		return fmt.Errorf("未知的响应码类型: %v", v)
 	}
+	if code != "0" {
+		return engine.NewAPIError("quake", resp.StatusCode, code, response.Message, errorCodes)
+	}
 
 	return nil
 }
 
-func processResults(response QuakeResponse) [][]string {
-	var results [][]string
+// processResults 将API返回的结果映射为资产
+func processResults(response QuakeResponse) []asset.Asset {
+	var results []asset.Asset
 
 	// 检查 Data 的类型
 	data, ok := response.Data.([]any)
@@ -241,37 +405,53 @@ func processResults(response QuakeResponse) [][]string {
 		}
 
 		// 处理组件信息
-		var components string
+		var components []string
 		if comps, ok := itemMap["components"].([]any); ok {
 			for _, c := range comps {
-				comp := c.(map[string]any)
-				if version, ok := comp["version"].(string); ok && version != "" {
-					components += fmt.Sprintf("%s:%s, ", comp["product_name_cn"], version)
+				comp, ok := c.(map[string]any)
+				if !ok {
+					continue
+				}
+				name := getStringValue(comp, "product_name_cn")
+				if version := getStringValue(comp, "version"); version != "" {
+					components = append(components, name+":"+version)
 				} else {
-					components += fmt.Sprintf("%s, ", comp["product_name_cn"])
+					components = append(components, name)
 				}
 			}
 		}
 
 		// 获取基本信息
-		ip := getStringValue(itemMap, "ip")
-		domain := getStringValue(itemMap, "domain")
-		port := fmt.Sprintf("%v", itemMap["port"])
-		protocol := getStringValue(itemMap, "transport")
+		a := asset.Asset{
+			IP:         getStringValue(itemMap, "ip"),
+			Domain:     getStringValue(itemMap, "domain"),
+			Protocol:   getStringValue(itemMap, "transport"),
+			Org:        getStringValue(itemMap, "org"),
+			Components: components,
+			Source:     "quake",
+			LastSeen:   asset.ParseTime(getStringValue(itemMap, "time")),
+		}
+		if port, ok := itemMap["port"].(float64); ok {
+			a.Port = int(port)
+		}
 
 		// 获取服务信息
-		var serverName, title, loadURL, licence, unit, isp string
 		if service, ok := itemMap["service"].(map[string]any); ok {
+			if name := getStringValue(service, "name"); name != "" {
+				a.Protocol = name
+			}
 			if http, ok := service["http"].(map[string]any); ok {
-				serverName = getStringValue(http, "server")
-				title = getStringValue(http, "title")
+				a.Server = getStringValue(http, "server")
+				a.Title = getStringValue(http, "title")
 				if urls, ok := http["http_load_url"].([]any); ok && len(urls) > 0 {
-					loadURL = fmt.Sprintf("%v", urls[0])
+					a.URL = fmt.Sprintf("%v", urls[0])
 				}
 				if icp, ok := http["icp"].(map[string]any); ok {
-					licence = getStringValue(icp, "licence")
+					a.ICP = getStringValue(icp, "licence")
 					if mainLicence, ok := icp["main_licence"].(map[string]any); ok {
-						unit = getStringValue(mainLicence, "unit")
+						if unit := getStringValue(mainLicence, "unit"); unit != "" {
+							a.Org = unit
+						}
 					}
 				}
 			}
@@ -279,23 +459,21 @@ func processResults(response QuakeResponse) [][]string {
 
 		// 获取位置信息
 		if location, ok := itemMap["location"].(map[string]any); ok {
-			isp = getStringValue(location, "isp")
+			a.ISP = getStringValue(location, "isp")
+			a.Country = getStringValue(location, "country_cn")
 		}
 
-		result := []string{
-			ip,
-			domain,
-			port,
-			protocol,
-			fmt.Sprintf("%s:%s", ip, port),
-			title,
-			serverName + " " + components,
-			loadURL,
-			licence,
-			unit,
-			isp,
+		a.Host = asset.HostFromURL(a.URL)
+		if a.Host == "" && a.IP != "" {
+			host := a.IP
+			if a.Domain != "" {
+				host = a.Domain
+			}
+			a.Host = net.JoinHostPort(host, a.PortString())
 		}
-		results = append(results, result)
+		a.Raw, _ = json.Marshal(itemMap)
+
+		results = append(results, a)
 	}
 	return results
 }
@@ -307,216 +485,3 @@ func getStringValue(m map[string]any, key string) string {
 	}
 	return ""
 }
-
-// URL去重函数
-func deduplicateURLs(results [][]string) []string {
-	seen := make(map[string]bool)
-	var uniqueURLs []string
-
-	for _, result := range results {
-		url := result[7] // URL在第8列
-		if url != "" && !seen[url] {
-			seen[url] = true
-			uniqueURLs = append(uniqueURLs, url)
-		}
-	}
-	return uniqueURLs
-}
-
-// URL去重函数
-func deduplicateIP(results [][]string) []string {
-	seen := make(map[string]bool)
-	var uniqueIP []string
-
-	for _, result := range results {
-		url := result[0] // URL在第8列
-		if url != "" && !seen[url] {
-			seen[url] = true
-			uniqueIP = append(uniqueIP, url)
-		}
-	}
-	return uniqueIP
-}
-
-// 修改 data 函数，移除 ISP 和 Unit 字段
-func data(results [][]string) {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"IP", "Domain", "Port", "Protocol", "URL", "Title", "ICP"})
-
-	table.SetHeaderColor(tablewriter.Colors{tablewriter.Bold, tablewriter.BgGreenColor},
-		tablewriter.Colors{tablewriter.FgHiRedColor, tablewriter.Bold, tablewriter.BgBlackColor},
-		tablewriter.Colors{tablewriter.BgRedColor, tablewriter.FgWhiteColor},
-		tablewriter.Colors{tablewriter.BgCyanColor, tablewriter.FgWhiteColor},
-		tablewriter.Colors{tablewriter.BgCyanColor, tablewriter.FgWhiteColor},
-		tablewriter.Colors{tablewriter.BgCyanColor, tablewriter.FgWhiteColor},
-		tablewriter.Colors{tablewriter.BgCyanColor, tablewriter.FgWhiteColor})
-
-	table.SetColumnColor(tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiBlackColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiBlackColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiRedColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiBlackColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgBlackColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgBlackColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgBlackColor})
-
-	// 重新排序字段，只用于显示
-	var displayResults [][]string
-	for _, r := range results {
-		// 原始顺序: IP[0], Domain[1], Port[2], Protocol[3], Host[4], Title[5], Server[6], URL[7], ICP[8], Unit[9]
-		// 新顺序: IP, Domain, Port, Protocol, URL, Title, ICP
-		displayResults = append(displayResults, []string{
-			r[0], // IP
-			r[1], // Domain
-			r[2], // Port
-			r[3], // Protocol
-			r[7], // URL
-			r[5], // Title
-			r[8], // ICP
-		})
-	}
-
-	table.AppendBulk(displayResults)
-	table.Render()
-}
-
-// 批量查询
-func QUF(query string, outputFile string, months int) error {
-	conf := Config{}
-	content, err := os.ReadFile(config.GetConfigPath())
-	if err != nil {
-		return fmt.Errorf("配置文件读取错误: %v", err)
-	}
-	if err := yaml.Unmarshal(content, &conf); err != nil {
-		return fmt.Errorf("解析config.yaml出错: %v", err)
-	}
-
-	startTime, endTime := calculateTimeRange(months)
-
-	reqBody := QuakeRequest{
-		Query:     query,
-		Start:     0,
-		Size:      3000,
-		Latest:    true,
-		StartTime: startTime,
-		EndTime:   endTime,
-	}
-
-	var response QuakeResponse
-	if err := makeRequest(conf.Quake.Key, reqBody, &response); err != nil {
-		return err
-	}
-
-	// 检查是否有数据
-	dataArray, ok := response.Data.([]any)
-	if !ok || len(dataArray) == 0 {
-		fmt.Printf("未找到结果: %s\n", query)
-		return nil
-	}
-
-	results := processResults(response)
-	if len(results) == 0 {
-		fmt.Printf("未找到结果: %s\n", query)
-		return nil
-	}
-
-	// 对URL去重后输出
-	uniqueURLs := deduplicateURLs(results)
-	for _, url := range uniqueURLs {
-		fmt.Println(url)
-	}
-
-	// 直接写入CSV文件，不去重，但不输出提示
-	if err := appendToCSV(results, outputFile, false); err != nil {
-		return err
-	}
-
-	// 如果有多页，继续查询
-	total := response.Meta.Pagination.Total
-	for reqBody.Start+len(results) < total {
-		reqBody.Start += reqBody.Size
-
-		// 检查是否即将超过10000条限制
-		if reqBody.Start >= 10000 {
-			fmt.Println("\n注意: 已达到查询上限(10000条数据)，只显示已获取的结果。")
-			break
-		}
-
-		if err := makeRequest(conf.Quake.Key, reqBody, &response); err != nil {
-			// 检查是否是数据限制错误
-			if err.Error() == "API错误: q2001 - 网页查询最大允许查询10000条数据。" {
-				fmt.Println("\n注意: 已达到查询上限(10000条数据)，只显示已获取的结果。")
-				break
-			}
-			return err
-		}
-
-		results = processResults(response)
-		if len(results) == 0 {
-			break
-		}
-
-		// 对URL去重后输出
-		uniqueURLs = deduplicateURLs(results)
-		for _, url := range uniqueURLs {
-			fmt.Println(url)
-		}
-
-		// 判断是否是最后一页
-		isLastPage := reqBody.Start+len(results) >= total
-
-		// 写入CSV文件，只在最后一页输出提示
-		if err := appendToCSV(results, outputFile, isLastPage); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-// 修改 appendToCSV 函数
-func appendToCSV(results [][]string, outputFile string, _ bool) error {
-	if outputFile == "" {
-		outputFile = "quake.csv"
-	}
-
-	// 以追加模式打开文件
-	f, err := os.OpenFile(outputFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
-	if err != nil {
-		return fmt.Errorf("打开文件失败: %v", err)
-	}
-	defer f.Close()
-
-	writer := csv.NewWriter(f)
-	defer writer.Flush()
-
-	// 如果文件是新创建的，写入表头和BOM
-	if fi, err := f.Stat(); err == nil && fi.Size() == 0 {
-		f.WriteString("\xEF\xBB\xBF") // UTF-8 BOM
-		writer.Write([]string{"IP", "Domain", "Port", "Protocol", "Host", "URL", "Title", "Server", "ICP", "Unit", "ISP"})
-	}
-
-	// 写入数据，保持原始顺序
-	for _, r := range results {
-		if err := writer.Write([]string{
-			r[0],  // IP
-			r[1],  // Domain
-			r[2],  // Port
-			r[3],  // Protocol
-			r[7],  // URL
-			r[5],  // Title
-			r[6],  // Server
-			r[8],  // ICP
-			r[9],  // Unit
-			r[10], // ISP
-		}); err != nil {
-			return fmt.Errorf("写入数据失败: %v", err)
-		}
-	}
-
-	// 只在需要时显示提示
-	// if showPrompt {
-	// 	fmt.Printf("\n结果已保存到文件: %s\n", outputFile)
-	// }
-
-	return nil
-}
@@ -0,0 +1,205 @@
+package quake
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yaxigin/mto/pkg/engine"
+	"github.com/yaxigin/mto/pkg/ratelimit"
+)
+
+// reply 测试服务器的响应，code 不为0时返回错误
+type reply struct {
+	code any
+	msg  string
+}
+
+// fakeServer 模拟查询接口，按 Start/Size 返回 total 条结果中的一段，记录每次请求的 Start/Size
+type fakeServer struct {
+	total int
+	reply *reply // 不为空时所有请求都返回该错误
+
+	mu       sync.Mutex
+	requests []string
+}
+
+func (s *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req QuakeRequest
+	json.NewDecoder(r.Body).Decode(&req)
+	s.mu.Lock()
+	s.requests = append(s.requests, fmt.Sprintf("%d+%d", req.Start, req.Size))
+	s.mu.Unlock()
+
+	if s.reply != nil {
+		json.NewEncoder(w).Encode(map[string]any{"code": s.reply.code, "message": s.reply.msg})
+		return
+	}
+	var data []map[string]any
+	for i := req.Start; i < min(req.Start+req.Size, s.total); i++ {
+		data = append(data, map[string]any{"ip": fmt.Sprintf("10.0.%d.%d", i/256, i%256), "port": 80})
+	}
+	resp := map[string]any{"code": 0, "message": "Successful.", "data": data}
+	resp["meta"] = map[string]any{"pagination": map[string]any{"total": s.total}}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// newTestClient 创建请求测试服务器的客户端，不限速也不重试
+func newTestClient(url string) *Client {
+	return NewClient("test-key", engine.ClientOptions{
+		BaseURL: url,
+		Backoff: engine.Backoff{Attempts: 1},
+		Rate:    ratelimit.Config{Interval: time.Nanosecond},
+	})
+}
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name string
+		code any
+		msg  string
+		want error
+	}{
+		{"数据上限", "q2001", "网页查询最大允许查询10000条数据", engine.ErrResultCap},
+		{"q2001的语法错误", "q2001", "查询语法错误", engine.ErrSyntax},
+		{"q2001的其他错误", "q2001", "查询参数错误", nil},
+		{"请求过快", "q3005", "API请求频率过快", engine.ErrRateLimit},
+		{"认证失败", "t6003", "API认证失败", engine.ErrAuth},
+		{"积分不足", "u3007", "您的积分不足", engine.ErrQuota},
+		{"数字错误码", 1, "查询失败", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(&fakeServer{reply: &reply{tt.code, tt.msg}})
+			defer srv.Close()
+
+			_, err := newTestClient(srv.URL).Count(context.Background(), `ip:"1.1.1.1"`, engine.Options{NoLint: true})
+			var apiErr *engine.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("Count() error = %v, 应为 *engine.APIError", err)
+			}
+			if apiErr.Kind != tt.want {
+				t.Errorf("Kind = %v, want %v", apiErr.Kind, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   reply
+		wantErr bool
+	}{
+		{"数据上限时返回已获取的结果", reply{"q2001", "网页查询最大允许查询10000条数据"}, false},
+		{"q2001的其他错误不能当作成功", reply{"q2001", "查询参数错误"}, true},
+		{"语法错误", reply{"q2001", "查询语法错误"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(&fakeServer{reply: &tt.reply})
+			defer srv.Close()
+
+			_, err := newTestClient(srv.URL).Search(context.Background(), `ip:"1.1.1.1"`, engine.Options{NoLint: true})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Search() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSearchPagination(t *testing.T) {
+	tests := []struct {
+		name         string
+		total        int
+		opts         engine.Options
+		wantRequests []string
+		wantResults  int
+		wantCursors  []string
+	}{
+		{
+			name:         "一页",
+			total:        100,
+			wantRequests: []string{"0+3000"},
+			wantResults:  100,
+			wantCursors:  []string{""},
+		},
+		{
+			name:         "最后一页不超过10000条",
+			total:        20000,
+			wantRequests: []string{"0+3000", "3000+3000", "6000+3000", "9000+1000"},
+			wantResults:  10000,
+			wantCursors:  []string{"3000", "6000", "9000", ""},
+		},
+		{
+			name:         "指定最大结果数量",
+			total:        20000,
+			opts:         engine.Options{MaxResults: 4000},
+			wantRequests: []string{"0+3000", "3000+1000"},
+			wantResults:  4000,
+			wantCursors:  []string{"3000", ""},
+		},
+		{
+			name:         "最大结果数量小于一页",
+			total:        20000,
+			opts:         engine.Options{MaxResults: 500},
+			wantRequests: []string{"0+500"},
+			wantResults:  500,
+			wantCursors:  []string{""},
+		},
+		{
+			name:         "最大结果数量超过上限",
+			total:        20000,
+			opts:         engine.Options{MaxResults: 50000, Cursor: "9000"},
+			wantRequests: []string{"9000+1000"},
+			wantResults:  1000,
+			wantCursors:  []string{""},
+		},
+		{
+			name:         "从断点继续",
+			total:        5000,
+			opts:         engine.Options{Cursor: "3000"},
+			wantRequests: []string{"3000+3000"},
+			wantResults:  2000,
+			wantCursors:  []string{""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeServer{total: tt.total}
+			srv := httptest.NewServer(fake)
+			defer srv.Close()
+
+			var cursors []string
+			opts := tt.opts
+			opts.NoLint = true
+			opts.OnPage = func(p engine.Page) { cursors = append(cursors, p.Next) }
+
+			results, err := newTestClient(srv.URL).Search(context.Background(), `ip:"10.0.0.0/8"`, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(fake.requests, tt.wantRequests) {
+				t.Errorf("请求 = %v, want %v", fake.requests, tt.wantRequests)
+			}
+			if len(results) != tt.wantResults {
+				t.Errorf("获取 %d 条结果, want %d", len(results), tt.wantResults)
+			}
+			if !slices.Equal(cursors, tt.wantCursors) {
+				t.Errorf("翻页位置 = %v, want %v", cursors, tt.wantCursors)
+			}
+
+			// 估算与实际获取的数量一致
+			if e := newTestClient(srv.URL).Estimate(tt.total, engine.Options{MaxResults: tt.opts.MaxResults}); tt.opts.Cursor == "" && e.Fetch != tt.wantResults {
+				t.Errorf("Estimate().Fetch = %d, want %d", e.Fetch, tt.wantResults)
+			}
+		})
+	}
+}
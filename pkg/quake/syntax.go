@@ -0,0 +1,67 @@
+package quake
+
+// syntaxHelp Quake 语法参考
+const syntaxHelp = `
+Quake 语法参考:
+
+基础语法:
+  app:"Apache"              - Apache服务器产品
+  country:"CN"             - 搜索国家地区资产
+  country_cn:"中国"         - 搜索中文国家名称
+  province:"beijing"       - 搜索英文省份名称
+  province_cn:"北京"        - 搜索中文省份名称
+  city:"changsha"          - 搜索英文城市名称
+  city_cn:"长沙"           - 搜索中文城市名称
+
+资产搜索:
+  ip:"8.8.8.8"            - 搜索IPv4地址
+  ip:"2600:3c00::f03c:91ff:fefc:574a" - 搜索IPv6地址
+  ip:52.2.254.36/24       - 搜索CIDR地址段
+  host:"google.com"       - 搜索域名
+  icp:"京ICP备08010314号"  - 搜索ICP备案号
+  port:80                 - 搜索端口
+  ports:80,8080,9999      - 搜索多个端口
+  hostname:google.com      - 搜索主机名
+  service:"ssh"           - 搜索服务协议
+  os:"RouterOS"           - 搜索操作系统
+
+网站相关:
+  title:"Cisco"           - 搜索网页标题
+  body:"奇虎"             - 搜索网页内容
+  headers:"ThinkPHP"      - 搜索HTTP头
+  ssl:"google"            - 搜索SSL证书
+  response:"220 ProFTPD"  - 搜索端口响应
+
+组织信息:
+  org:"No.31,Jin-rong"    - 组织名称
+  asn:"12345"            - ASN号码
+  isp:"China Mobile"      - 运营商
+
+运算符:
+  and  - 与运算
+  or   - 或运算
+  not  - 非运算
+  ()   - 优先级
+
+时间范围:
+  -m 0  - 搜索最近1年数据
+  -m 1  - 搜索最近1个月数据
+  -m 2  - 搜索最近2个月数据
+  -m 3  - 搜索最近3个月数据(默认)
+
+示例:
+1. 搜索中国境内的Apache服务器:
+   country:"CN" and app:"Apache"
+
+2. 搜索某个IP段的Web服务:
+   ip:192.168.1.1/24 and port:80
+
+3. 搜索指定时间范围内的数据:
+   使用 -m 参数,如 -m 1 表示最近1个月
+
+注意事项:
+1. 台湾是中国的一个省,使用 province_cn:"台湾省"
+2. 香港和澳门是中国的城市,使用 city_cn:"香港/澳门"
+3. Web服务器搜索使用 server 头,如 "Server: Microsoft-IIS/7.5"
+4. 使用 or 表示或运算,不要使用 | 或 ||
+5. --size/--time 等参数前不要加 and`
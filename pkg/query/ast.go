@@ -0,0 +1,59 @@
+package query
+
+// Node 查询语法树节点
+type Node interface {
+	node()
+}
+
+// LogicOp 逻辑运算符
+type LogicOp int
+
+const (
+	And LogicOp = iota
+	Or
+)
+
+// BinaryExpr 逻辑与/逻辑或表达式
+type BinaryExpr struct {
+	Op          LogicOp
+	Left, Right Node
+}
+
+// NotExpr 逻辑非表达式（仅 Quake 支持）
+type NotExpr struct {
+	X Node
+}
+
+// ParenExpr 括号表达式，保留用户书写的分组
+type ParenExpr struct {
+	X Node
+}
+
+// Condition 单个查询条件，Field 为空时表示全文搜索
+type Condition struct {
+	Field  string
+	Op     string // 比较运算符，如 = != *= :
+	Value  string // 去掉引号和转义后的值
+	Quoted bool   // 用户书写时是否带引号
+	Range  bool   // Quake 范围值，如 [80 TO 90]，原样输出
+}
+
+func (*BinaryExpr) node() {}
+func (*NotExpr) node()    {}
+func (*ParenExpr) node()  {}
+func (*Condition) node()  {}
+
+// Walk 按出现顺序遍历语法树中的全部条件
+func Walk(n Node, fn func(c *Condition)) {
+	switch n := n.(type) {
+	case *BinaryExpr:
+		Walk(n.Left, fn)
+		Walk(n.Right, fn)
+	case *NotExpr:
+		Walk(n.X, fn)
+	case *ParenExpr:
+		Walk(n.X, fn)
+	case *Condition:
+		fn(n)
+	}
+}
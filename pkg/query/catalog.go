@@ -0,0 +1,101 @@
+package query
+
+import "slices"
+
+// ValueType 字段值类型
+type ValueType int
+
+const (
+	TypeString ValueType = iota
+	TypeInt
+	TypeBool
+	TypeDate
+	TypeIP
+)
+
+// String 返回值类型名称
+func (t ValueType) String() string {
+	switch t {
+	case TypeInt:
+		return "int"
+	case TypeBool:
+		return "bool"
+	case TypeDate:
+		return "date"
+	case TypeIP:
+		return "ip"
+	default:
+		return "string"
+	}
+}
+
+// Field 字段定义
+type Field struct {
+	Name  string    `json:"name"`
+	Type  ValueType `json:"type"`
+	Ops   []string  `json:"ops"`             // 允许的比较运算符
+	Level string    `json:"level,omitempty"` // 需要的会员等级，空表示所有用户可用
+	Desc  string    `json:"desc"`
+}
+
+// MarshalText 以类型名称输出，便于生成可读的JSON
+func (t ValueType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// catalogs 各方言的字段目录
+var catalogs = map[Dialect][]Field{
+	FOFA:   fofaFields,
+	Hunter: hunterFields,
+	Quake:  quakeFields,
+}
+
+// Catalog 返回方言的字段目录
+func Catalog(d Dialect) []Field {
+	return slices.Clone(catalogs[d])
+}
+
+// LookupField 在方言的字段目录中查找字段
+func LookupField(d Dialect, name string) (Field, bool) {
+	for _, f := range catalogs[d] {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// field 构造字段定义，未指定运算符时按方言和值类型取默认值
+func field(d Dialect, name string, t ValueType, desc string, level ...string) Field {
+	f := Field{Name: name, Type: t, Desc: desc, Ops: defaultOps(d, t)}
+	if len(level) > 0 {
+		f.Level = level[0]
+	}
+	return f
+}
+
+// defaultOps 各方言中不同值类型默认允许的运算符
+func defaultOps(d Dialect, t ValueType) []string {
+	switch d {
+	case FOFA:
+		switch t {
+		case TypeString:
+			return []string{"=", "==", "!=", "*="}
+		case TypeDate:
+			return []string{"="}
+		default:
+			return []string{"=", "==", "!="}
+		}
+	case Hunter:
+		switch t {
+		case TypeInt:
+			return []string{"=", "==", "!=", ">", "<"}
+		case TypeDate:
+			return []string{"="}
+		default:
+			return []string{"=", "==", "!="}
+		}
+	default:
+		return []string{":"}
+	}
+}
@@ -0,0 +1,63 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect 查询语法方言
+type Dialect int
+
+const (
+	FOFA   Dialect = iota // title="x" && port="80"
+	Hunter                // web.title="x" && ip.port="80"
+	Quake                 // title:"x" AND port:80
+)
+
+// String 返回方言对应的引擎名称
+func (d Dialect) String() string {
+	switch d {
+	case FOFA:
+		return "fofa"
+	case Hunter:
+		return "hunter"
+	case Quake:
+		return "quake"
+	default:
+		return fmt.Sprintf("Dialect(%d)", int(d))
+	}
+}
+
+// ParseDialect 根据引擎名称获取方言
+func ParseDialect(name string) (Dialect, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "fofa":
+		return FOFA, nil
+	case "hunter":
+		return Hunter, nil
+	case "quake":
+		return Quake, nil
+	default:
+		return 0, fmt.Errorf("未知的查询语法: %s", name)
+	}
+}
+
+// operators 各方言支持的比较运算符
+var operators = map[Dialect][]string{
+	FOFA:   {"=", "==", "!=", "*="},
+	Hunter: {"=", "==", "!=", ">", "<"},
+	Quake:  {":"},
+}
+
+// Operators 返回方言支持的比较运算符
+func (d Dialect) Operators() []string {
+	return operators[d]
+}
+
+// andOr 返回方言中逻辑与、逻辑或的写法
+func (d Dialect) andOr() (string, string) {
+	if d == Quake {
+		return "AND", "OR"
+	}
+	return "&&", "||"
+}
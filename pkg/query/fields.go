@@ -0,0 +1,171 @@
+package query
+
+// fofaFields FOFA 字段目录
+var fofaFields = []Field{
+	// 基础查询
+	field(FOFA, "ip", TypeIP, "IP地址或C段"),
+	field(FOFA, "port", TypeInt, "端口"),
+	field(FOFA, "domain", TypeString, "根域名"),
+	field(FOFA, "host", TypeString, "主机名"),
+	field(FOFA, "os", TypeString, "操作系统"),
+	field(FOFA, "server", TypeString, "Web服务器"),
+	field(FOFA, "asn", TypeInt, "自治系统号"),
+	field(FOFA, "org", TypeString, "所属组织"),
+	field(FOFA, "icon_hash", TypeInt, "网站图标哈希"),
+	field(FOFA, "fid", TypeString, "网站指纹"),
+	field(FOFA, "cname", TypeString, "CNAME记录"),
+	field(FOFA, "jarm", TypeString, "JARM指纹"),
+
+	// 标记类
+	field(FOFA, "app", TypeString, "FOFA规则"),
+	field(FOFA, "product", TypeString, "产品名称"),
+	field(FOFA, "category", TypeString, "产品分类"),
+	field(FOFA, "type", TypeString, "资产类型(service/subdomain)"),
+	field(FOFA, "cloud_name", TypeString, "云服务商"),
+	field(FOFA, "is_cloud", TypeBool, "是否为云服务资产"),
+	field(FOFA, "is_domain", TypeBool, "是否为域名资产"),
+	field(FOFA, "is_ipv6", TypeBool, "是否为IPv6资产"),
+	field(FOFA, "is_fraud", TypeBool, "是否为仿冒资产", "专业版"),
+	field(FOFA, "is_honeypot", TypeBool, "是否为蜜罐资产", "专业版"),
+
+	// 协议类
+	field(FOFA, "protocol", TypeString, "协议名称"),
+	field(FOFA, "banner", TypeString, "协议返回信息"),
+	field(FOFA, "base_protocol", TypeString, "传输层协议(tcp/udp)"),
+
+	// 网站类
+	field(FOFA, "title", TypeString, "网站标题"),
+	field(FOFA, "header", TypeString, "响应头"),
+	field(FOFA, "body", TypeString, "网页内容"),
+	field(FOFA, "header_hash", TypeString, "响应头哈希"),
+	field(FOFA, "body_hash", TypeString, "网页内容哈希"),
+	field(FOFA, "js_name", TypeString, "JS文件名"),
+	field(FOFA, "status_code", TypeInt, "HTTP状态码"),
+	field(FOFA, "icp", TypeString, "ICP备案号"),
+
+	// 地理位置
+	field(FOFA, "country", TypeString, "国家"),
+	field(FOFA, "region", TypeString, "省份/地区"),
+	field(FOFA, "city", TypeString, "城市"),
+
+	// 证书类
+	field(FOFA, "cert", TypeString, "证书信息"),
+	field(FOFA, "cert.subject", TypeString, "证书持有者"),
+	field(FOFA, "cert.subject.org", TypeString, "证书持有者组织"),
+	field(FOFA, "cert.issuer", TypeString, "证书颁发者"),
+	field(FOFA, "cert.issuer.org", TypeString, "证书颁发者组织"),
+	field(FOFA, "cert.domain", TypeString, "证书域名"),
+	field(FOFA, "cert.is_valid", TypeBool, "证书是否有效"),
+	field(FOFA, "cert.is_expired", TypeBool, "证书是否过期"),
+	field(FOFA, "cert.sn", TypeString, "证书序列号"),
+
+	// 时间类
+	field(FOFA, "after", TypeDate, "某时间后更新的资产"),
+	field(FOFA, "before", TypeDate, "某时间前更新的资产"),
+}
+
+// hunterFields Hunter 字段目录
+var hunterFields = []Field{
+	// 特色功能
+	field(Hunter, "ip.tag", TypeString, "IP标签", "高级会员"),
+	field(Hunter, "web.similar", TypeString, "网站特征相似", "高级会员"),
+	field(Hunter, "web.similar_icon", TypeString, "网站icon相似", "高级会员"),
+	field(Hunter, "web.similar_id", TypeString, "网页相似", "高级会员"),
+	field(Hunter, "web.tag", TypeString, "资产标签", "高级会员"),
+	field(Hunter, "web.is_vul", TypeBool, "是否存在历史漏洞", "高级会员"),
+	field(Hunter, "icp.is_exception", TypeBool, "ICP备案是否异常", "高级会员"),
+
+	// 域名信息
+	field(Hunter, "domain", TypeString, "域名"),
+	field(Hunter, "domain.suffix", TypeString, "主域"),
+	field(Hunter, "domain.status", TypeString, "域名状态"),
+	field(Hunter, "domain.whois_server", TypeString, "whois服务器"),
+	field(Hunter, "domain.name_server", TypeString, "名称服务器"),
+	field(Hunter, "domain.created_date", TypeDate, "域名创建时间"),
+	field(Hunter, "domain.expires_date", TypeDate, "域名到期时间"),
+	field(Hunter, "domain.updated_date", TypeDate, "域名更新时间"),
+	field(Hunter, "domain.cname", TypeString, "CNAME记录"),
+	field(Hunter, "is_domain.cname", TypeBool, "是否含CNAME解析记录"),
+
+	// 网站信息
+	field(Hunter, "is_web", TypeBool, "是否为web资产"),
+	field(Hunter, "web.icon", TypeString, "网站icon"),
+	field(Hunter, "web.title", TypeString, "网站标题"),
+	field(Hunter, "web.body", TypeString, "网页内容"),
+	field(Hunter, "header", TypeString, "响应头"),
+	field(Hunter, "header.server", TypeString, "服务器类型"),
+	field(Hunter, "header.status_code", TypeInt, "HTTP状态码"),
+	field(Hunter, "header.content_length", TypeInt, "响应内容长度"),
+	field(Hunter, "app.name", TypeString, "组件名称"),
+	field(Hunter, "protocol", TypeString, "协议名称"),
+	field(Hunter, "protocol.banner", TypeString, "协议返回信息"),
+
+	// 证书信息
+	field(Hunter, "cert", TypeString, "证书信息"),
+	field(Hunter, "cert.subject", TypeString, "证书使用者"),
+	field(Hunter, "cert.subject.suffix", TypeString, "证书使用者主域"),
+	field(Hunter, "cert.issuer", TypeString, "证书颁发者"),
+	field(Hunter, "cert.is_trust", TypeBool, "证书是否可信"),
+	field(Hunter, "cert.is_expired", TypeBool, "证书是否过期"),
+
+	// ICP备案
+	field(Hunter, "icp.number", TypeString, "ICP备案号"),
+	field(Hunter, "icp.web_name", TypeString, "ICP备案网站名"),
+	field(Hunter, "icp.name", TypeString, "ICP备案单位名"),
+	field(Hunter, "icp.type", TypeString, "ICP备案主体类型"),
+	field(Hunter, "icp.industry", TypeString, "ICP备案行业"),
+
+	// 基础查询
+	field(Hunter, "ip", TypeIP, "IP地址或C段"),
+	field(Hunter, "ip.port", TypeInt, "端口"),
+	field(Hunter, "ip.port_count", TypeInt, "开放端口数量"),
+	field(Hunter, "ip.country", TypeString, "国家"),
+	field(Hunter, "ip.province", TypeString, "省份"),
+	field(Hunter, "ip.city", TypeString, "城市"),
+	field(Hunter, "ip.isp", TypeString, "运营商"),
+	field(Hunter, "ip.os", TypeString, "操作系统"),
+	field(Hunter, "as.number", TypeInt, "自治系统号"),
+	field(Hunter, "as.org", TypeString, "自治系统组织"),
+
+	// 时间范围
+	field(Hunter, "after", TypeDate, "某时间后的资产"),
+	field(Hunter, "before", TypeDate, "某时间前的资产"),
+}
+
+// quakeFields Quake 字段目录
+var quakeFields = []Field{
+	// 地理位置
+	field(Quake, "country", TypeString, "国家地区"),
+	field(Quake, "country_cn", TypeString, "中文国家名称"),
+	field(Quake, "province", TypeString, "英文省份名称"),
+	field(Quake, "province_cn", TypeString, "中文省份名称"),
+	field(Quake, "city", TypeString, "英文城市名称"),
+	field(Quake, "city_cn", TypeString, "中文城市名称"),
+
+	// 资产搜索
+	field(Quake, "ip", TypeIP, "IP地址或CIDR地址段"),
+	field(Quake, "host", TypeString, "域名"),
+	field(Quake, "domain", TypeString, "域名"),
+	field(Quake, "hostname", TypeString, "主机名"),
+	field(Quake, "icp", TypeString, "ICP备案号"),
+	field(Quake, "port", TypeInt, "端口"),
+	field(Quake, "ports", TypeString, "多个端口，逗号分隔"),
+	field(Quake, "service", TypeString, "服务协议"),
+	field(Quake, "transport", TypeString, "传输层协议"),
+	field(Quake, "os", TypeString, "操作系统"),
+	field(Quake, "app", TypeString, "产品组件"),
+	field(Quake, "is_ipv6", TypeBool, "是否为IPv6资产"),
+	field(Quake, "is_latest", TypeBool, "是否为最新数据"),
+
+	// 网站相关
+	field(Quake, "title", TypeString, "网页标题"),
+	field(Quake, "body", TypeString, "网页内容"),
+	field(Quake, "headers", TypeString, "HTTP头"),
+	field(Quake, "ssl", TypeString, "SSL证书"),
+	field(Quake, "response", TypeString, "端口响应"),
+
+	// 组织信息
+	field(Quake, "org", TypeString, "组织名称"),
+	field(Quake, "asn", TypeInt, "ASN号码"),
+	field(Quake, "isp", TypeString, "运营商"),
+}
@@ -0,0 +1,86 @@
+package query
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Format 将语法树按指定方言输出为查询语句，不带引号的值会自动补全引号
+func Format(d Dialect, n Node) string {
+	var b strings.Builder
+	format(&b, d, n, Or)
+	return b.String()
+}
+
+// Normalize 解析并重新输出查询语句，用于发送请求前补全引号、统一格式
+func Normalize(d Dialect, src string) (string, error) {
+	n, err := Parse(d, src)
+	if err != nil {
+		return "", err
+	}
+	return Format(d, n), nil
+}
+
+// format 输出节点，parent 为外层的逻辑运算符，优先级更低的子表达式需要加括号
+func format(b *strings.Builder, d Dialect, n Node, parent LogicOp) {
+	and, or := d.andOr()
+	switch n := n.(type) {
+	case *BinaryExpr:
+		// 逻辑或嵌套在逻辑与中时需要括号
+		paren := n.Op == Or && parent == And
+		if paren {
+			b.WriteString("(")
+		}
+		format(b, d, n.Left, n.Op)
+		if n.Op == And {
+			b.WriteString(" " + and + " ")
+		} else {
+			b.WriteString(" " + or + " ")
+		}
+		format(b, d, n.Right, n.Op)
+		if paren {
+			b.WriteString(")")
+		}
+	case *NotExpr:
+		b.WriteString("NOT ")
+		if _, ok := n.X.(*BinaryExpr); ok {
+			b.WriteString("(")
+			format(b, d, n.X, Or)
+			b.WriteString(")")
+		} else {
+			format(b, d, n.X, And)
+		}
+	case *ParenExpr:
+		b.WriteString("(")
+		format(b, d, n.X, Or)
+		b.WriteString(")")
+	case *Condition:
+		b.WriteString(n.Field)
+		b.WriteString(n.Op)
+		b.WriteString(formatValue(d, n))
+	}
+}
+
+// formatValue 输出条件的值
+// 用户带引号的值保持引号；不带引号的值除布尔值、Quake 数字和范围外都补全引号
+func formatValue(d Dialect, c *Condition) string {
+	if c.Range {
+		return c.Value
+	}
+	if !c.Quoted {
+		if c.Value == "true" || c.Value == "false" {
+			return c.Value
+		}
+		if _, err := strconv.Atoi(c.Value); err == nil && d == Quake {
+			return c.Value
+		}
+	}
+	return quote(c.Value)
+}
+
+// quote 为值加双引号，转义其中的双引号和反斜杠
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,85 @@
+package query
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		d    Dialect
+		src  string
+		want string
+	}{
+		{"补全引号", FOFA, `title=login && port=80`, `title="login" && port="80"`},
+		{"统一空格", Hunter, `web.title="a"&&ip.port="80"`, `web.title="a" && ip.port="80"`},
+		{"保留括号", FOFA, `(a="1" || b="2") && c="3"`, `(a="1" || b="2") && c="3"`},
+		{"保留多余的括号", FOFA, `(a="1") && ((b="2"))`, `(a="1") && ((b="2"))`},
+		{"转义引号", FOFA, `title="say \"hi\""`, `title="say \"hi\""`},
+		{"转义反斜杠", FOFA, `body="a\\b"`, `body="a\\b"`},
+		{"单引号改为双引号", FOFA, `title='a'`, `title="a"`},
+		{"值中的运算符", FOFA, `title="a && b" || body="x=y"`, `title="a && b" || body="x=y"`},
+		{"布尔值", FOFA, `is_honeypot=false`, `is_honeypot=false`},
+		{"全文搜索", FOFA, `nginx && port="80"`, `"nginx" && port="80"`},
+		{"中文全文搜索", FOFA, `登录 && port="80"`, `"登录" && port="80"`},
+		{"精确匹配和模糊匹配", FOFA, `title=="a" && title*="b*" && port!="80"`, `title=="a" && title*="b*" && port!="80"`},
+		{"hunter比较", Hunter, `ip.port>80 && ip.port<1024`, `ip.port>"80" && ip.port<"1024"`},
+		{"quake数字不加引号", Quake, `port:80 AND title:login`, `port:80 AND title:"login"`},
+		{"quake关键字大写", Quake, `port:80 and not title:"x"`, `port:80 AND NOT title:"x"`},
+		{"quake范围", Quake, `port:[80 TO 90] OR port:{1 TO 10}`, `port:[80 TO 90] OR port:{1 TO 10}`},
+		{"quake NOT表达式", Quake, `NOT (port:80 OR port:443)`, `NOT (port:80 OR port:443)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.d, tt.src)
+			if err != nil {
+				t.Fatalf("Normalize(%s, %q) error: %v", tt.d, tt.src, err)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize(%s, %q) = %s, want %s", tt.d, tt.src, got, tt.want)
+			}
+
+			// 输出的查询再次解析后结构相同，再次格式化后不变
+			n1, _ := Parse(tt.d, tt.src)
+			n2, err := Parse(tt.d, got)
+			if err != nil {
+				t.Fatalf("Parse(%s, %q) error: %v", tt.d, got, err)
+			}
+			if again := Format(tt.d, n2); again != got {
+				t.Errorf("Format is not stable: %s => %s", got, again)
+			}
+			if a, b := shape(n1), shape(n2); a != b {
+				t.Errorf("round trip changed structure: %s => %s", a, b)
+			}
+		})
+	}
+}
+
+// shape 与 dump 相同但忽略值是否带引号，补全引号不改变查询的结构，会修改 n
+func shape(n Node) string {
+	Walk(n, func(c *Condition) { c.Quoted = true })
+	return dump(n)
+}
+
+func TestFormatPrecedence(t *testing.T) {
+	a := &Condition{Field: "a", Op: "=", Value: "1"}
+	b := &Condition{Field: "b", Op: "=", Value: "2"}
+	c := &Condition{Field: "c", Op: "=", Value: "3"}
+
+	tests := []struct {
+		name string
+		d    Dialect
+		n    Node
+		want string
+	}{
+		{"or嵌套在and中加括号", FOFA, &BinaryExpr{Op: And, Left: &BinaryExpr{Op: Or, Left: a, Right: b}, Right: c}, `(a="1" || b="2") && c="3"`},
+		{"and嵌套在or中不加括号", FOFA, &BinaryExpr{Op: Or, Left: &BinaryExpr{Op: And, Left: a, Right: b}, Right: c}, `a="1" && b="2" || c="3"`},
+		{"NOT表达式加括号", Quake, &NotExpr{X: &BinaryExpr{Op: And, Left: a, Right: b}}, `NOT (a=1 AND b=2)`},
+		{"NOT条件不加括号", Quake, &NotExpr{X: a}, `NOT a=1`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Format(tt.d, tt.n); got != tt.want {
+				t.Errorf("Format() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
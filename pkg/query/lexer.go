@@ -0,0 +1,289 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tokenKind 词法单元类型
+type tokenKind int
+
+const (
+	tokEOF    tokenKind = iota
+	tokIdent            // 字段名
+	tokOp               // 比较运算符
+	tokString           // 带引号的字符串
+	tokBare             // 不带引号的值
+	tokRange            // Quake 范围值 [a TO b]
+	tokAnd
+	tokOr
+	tokNot
+	tokLParen
+	tokRParen
+)
+
+// token 词法单元
+type token struct {
+	kind tokenKind
+	text string // 字符串类型为去掉引号和转义后的值
+	pos  int    // 在原始查询中的字节偏移
+}
+
+// SyntaxError 查询语法错误
+type SyntaxError struct {
+	Query string
+	Pos   int
+	Msg   string
+}
+
+func (e *SyntaxError) Error() string {
+	// 按字符计算位置，便于定位中文查询中的错误
+	col := utf8.RuneCountInString(e.Query[:min(e.Pos, len(e.Query))]) + 1
+	return fmt.Sprintf("查询语法错误(第%d个字符): %s", col, e.Msg)
+}
+
+// allOperators 所有方言的比较运算符，按长度优先匹配
+var allOperators = []string{"==", "!=", "*=", ">=", "<=", "=", ">", "<", ":"}
+
+// lexer 词法分析器
+type lexer struct {
+	d      Dialect
+	src    string
+	pos    int
+	tokens []token
+}
+
+// tokenize 将查询语句切分为词法单元
+func tokenize(d Dialect, src string) ([]token, error) {
+	l := &lexer{d: d, src: src}
+	for {
+		l.skipSpace()
+		if l.pos >= len(l.src) {
+			l.emit(tokEOF, "", l.pos)
+			return l.tokens, nil
+		}
+		if err := l.lexTerm(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (l *lexer) errorf(pos int, format string, args ...any) error {
+	return &SyntaxError{Query: l.src, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (l *lexer) emit(kind tokenKind, text string, pos int) {
+	l.tokens = append(l.tokens, token{kind: kind, text: text, pos: pos})
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.src) {
+		r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+		if !unicode.IsSpace(r) {
+			return
+		}
+		l.pos += size
+	}
+}
+
+// keywordAt 判断 pos 处是否为独立的 Quake 关键字（AND/OR/NOT，不区分大小写）
+func (l *lexer) keywordAt(pos int) (tokenKind, int, bool) {
+	for _, kw := range []struct {
+		word string
+		kind tokenKind
+	}{{"AND", tokAnd}, {"OR", tokOr}, {"NOT", tokNot}} {
+		end := pos + len(kw.word)
+		if end > len(l.src) || !strings.EqualFold(l.src[pos:end], kw.word) {
+			continue
+		}
+		if end == len(l.src) || l.src[end] == '(' || isSpace(l.src[end]) {
+			return kw.kind, end, true
+		}
+	}
+	return 0, 0, false
+}
+
+// lexTerm 读取一个逻辑运算符、括号或完整的条件
+func (l *lexer) lexTerm() error {
+	start := l.pos
+	switch c := l.src[l.pos]; {
+	case c == '(':
+		l.pos++
+		l.emit(tokLParen, "(", start)
+		return nil
+	case c == ')':
+		l.pos++
+		l.emit(tokRParen, ")", start)
+		return nil
+	case strings.HasPrefix(l.src[l.pos:], "&&"), strings.HasPrefix(l.src[l.pos:], "||"):
+		op := l.src[l.pos : l.pos+2]
+		if l.d == Quake {
+			return l.errorf(start, "%s 不支持 %s，请使用 AND / OR", l.d, op)
+		}
+		l.pos += 2
+		if op == "&&" {
+			l.emit(tokAnd, op, start)
+		} else {
+			l.emit(tokOr, op, start)
+		}
+		return nil
+	case c == '"' || c == '\'':
+		s, err := l.lexString()
+		if err != nil {
+			return err
+		}
+		l.emit(tokString, s, start)
+		return nil
+	}
+
+	if kind, end, ok := l.keywordAt(l.pos); ok {
+		if l.d != Quake {
+			return l.errorf(start, "%s 不支持 %s，请使用 && / ||", l.d, strings.ToUpper(l.src[start:end]))
+		}
+		l.pos = end
+		l.emit(kind, strings.ToUpper(l.src[start:end]), start)
+		return nil
+	}
+
+	return l.lexCondition()
+}
+
+// lexCondition 读取 字段 运算符 值，单独的词没有运算符时为全文搜索，如 nginx
+func (l *lexer) lexCondition() error {
+	start := l.pos
+	for l.pos < len(l.src) && isIdentChar(l.src[l.pos]) {
+		l.pos++
+	}
+	if l.pos == start {
+		r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+		return l.errorf(start, "无法识别的字符 %q", r)
+	}
+	field := l.src[start:l.pos]
+
+	l.skipSpace()
+	opPos := l.pos
+	op := ""
+	for _, candidate := range allOperators {
+		if strings.HasPrefix(l.src[l.pos:], candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		// 后面紧跟逻辑运算符、右括号或已经结束时为全文搜索，否则多半是漏写了运算符
+		if l.termEnd(opPos) {
+			l.emit(tokBare, field, start)
+			return nil
+		}
+		return l.errorf(opPos, "字段 %s 后缺少比较运算符", field)
+	}
+	l.emit(tokIdent, field, start)
+	l.pos += len(op)
+	l.emit(tokOp, op, opPos)
+
+	l.skipSpace()
+	valuePos := l.pos
+	if l.pos >= len(l.src) {
+		return l.errorf(valuePos, "字段 %s 缺少值", field)
+	}
+	switch l.src[l.pos] {
+	case '"', '\'':
+		s, err := l.lexString()
+		if err != nil {
+			return err
+		}
+		l.emit(tokString, s, valuePos)
+	case '[', '{':
+		end := strings.IndexAny(l.src[l.pos:], "]}")
+		if end < 0 {
+			return l.errorf(valuePos, "范围值缺少结束括号")
+		}
+		l.pos += end + 1
+		l.emit(tokRange, l.src[valuePos:l.pos], valuePos)
+	default:
+		l.emit(tokBare, l.lexBare(), valuePos)
+	}
+	return nil
+}
+
+// termEnd 判断 pos 处是否为一个条件的结束位置：查询结束、右括号或逻辑运算符
+func (l *lexer) termEnd(pos int) bool {
+	if pos >= len(l.src) || l.src[pos] == ')' {
+		return true
+	}
+	if l.d == Quake {
+		_, _, ok := l.keywordAt(pos)
+		return ok
+	}
+	return strings.HasPrefix(l.src[pos:], "&&") || strings.HasPrefix(l.src[pos:], "||")
+}
+
+// lexString 读取带引号的字符串，支持反斜杠转义
+func (l *lexer) lexString() (string, error) {
+	start := l.pos
+	quote := l.src[l.pos]
+	l.pos++
+
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\\' && l.pos+1 < len(l.src):
+			b.WriteByte(l.src[l.pos+1])
+			l.pos += 2
+		case c == quote:
+			l.pos++
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+			l.pos++
+		}
+	}
+	return "", l.errorf(start, "引号未闭合")
+}
+
+// lexBare 读取不带引号的值，直到下一个逻辑运算符或未配对的右括号
+// 值中间的空格会保留，如 title=hello world 读取为 "hello world"
+func (l *lexer) lexBare() string {
+	start := l.pos
+	depth := 0
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c == '(' {
+			depth++
+		} else if c == ')' {
+			if depth == 0 {
+				break
+			}
+			depth--
+		}
+		if l.d == Quake {
+			if isSpace(c) {
+				next := l.pos
+				for next < len(l.src) && isSpace(l.src[next]) {
+					next++
+				}
+				if _, _, ok := l.keywordAt(next); ok {
+					break
+				}
+			}
+		} else if strings.HasPrefix(l.src[l.pos:], "&&") || strings.HasPrefix(l.src[l.pos:], "||") {
+			break
+		}
+		l.pos++
+	}
+	return strings.TrimRightFunc(l.src[start:l.pos], unicode.IsSpace)
+}
+
+// isSpace 判断ASCII空白字符，按字节扫描时不能把UTF-8的后续字节当作空白
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// isIdentChar 字段名和不带引号的全文搜索词允许的字符，非ASCII字符（UTF-8的各个字节）用于中文等全文搜索词，如 登录
+func isIdentChar(c byte) bool {
+	return c >= utf8.RuneSelf || c == '_' || c == '.' || c == '-' ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
@@ -0,0 +1,240 @@
+package query
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Severity 检查结果的严重程度
+type Severity int
+
+const (
+	SeverityError   Severity = iota // 查询无法执行或结果必然不符合预期
+	SeverityWarning                 // 查询可以执行，但可能不是用户想要的
+	SeverityInfo                    // 提示信息，如字段需要会员权限
+)
+
+// String 返回严重程度名称
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "错误"
+	case SeverityWarning:
+		return "警告"
+	default:
+		return "提示"
+	}
+}
+
+// MarshalText 以名称输出，便于生成可读的JSON
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Issue 查询检查发现的问题
+type Issue struct {
+	Severity Severity `json:"severity"`
+	Field    string   `json:"field,omitempty"` // 相关字段，语法错误时为空
+	Msg      string   `json:"msg"`
+
+	unknownField bool // 字段不在字段目录中，执行查询前的检查只作为警告
+}
+
+func (i Issue) String() string {
+	if i.Field == "" {
+		return fmt.Sprintf("[%s] %s", i.Severity, i.Msg)
+	}
+	return fmt.Sprintf("[%s] %s: %s", i.Severity, i.Field, i.Msg)
+}
+
+// LintError 查询未通过检查
+type LintError struct {
+	Query  string
+	Issues []Issue // 仅包含错误级别的问题
+}
+
+func (e *LintError) Error() string {
+	msgs := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		if issue.Field != "" {
+			msgs[i] = issue.Field + ": " + issue.Msg
+		} else {
+			msgs[i] = issue.Msg
+		}
+	}
+	return "查询检查未通过: " + strings.Join(msgs, "; ")
+}
+
+// Lint 离线检查查询语句，不发送任何请求
+// 检查语法、字段是否存在、运算符是否适用于字段以及值的格式
+func Lint(d Dialect, src string) []Issue {
+	n, err := Parse(d, src)
+	if err != nil {
+		return []Issue{{Severity: SeverityError, Msg: err.Error()}}
+	}
+
+	var issues []Issue
+	Walk(n, func(c *Condition) {
+		issues = append(issues, lintCondition(d, c)...)
+	})
+	return issues
+}
+
+// Check 执行查询前的检查，存在错误时返回 *SyntaxError 或 *LintError
+// 其余的警告和提示随结果返回，由调用方决定如何展示
+// 字段目录没有收录引擎的全部字段，未知字段在这里只是警告，不阻止查询；lint 命令中仍然是错误
+func Check(d Dialect, src string) ([]Issue, error) {
+	if _, err := Parse(d, src); err != nil {
+		return nil, err
+	}
+
+	var errs, others []Issue
+	for _, issue := range Lint(d, src) {
+		if issue.unknownField {
+			issue.Severity = SeverityWarning
+		}
+		if issue.Severity == SeverityError {
+			errs = append(errs, issue)
+		} else {
+			others = append(others, issue)
+		}
+	}
+	if len(errs) > 0 {
+		return others, &LintError{Query: src, Issues: errs}
+	}
+	return others, nil
+}
+
+// lintCondition 检查单个条件
+func lintCondition(d Dialect, c *Condition) []Issue {
+	// 全文搜索没有字段
+	if c.Field == "" {
+		return nil
+	}
+
+	f, ok := LookupField(d, c.Field)
+	if !ok {
+		msg := fmt.Sprintf("%s 未知字段", d)
+		if s := suggestField(d, c.Field); s != "" {
+			msg += fmt.Sprintf("，是否为 %s?", s)
+		}
+		return []Issue{{Severity: SeverityError, Field: c.Field, Msg: msg, unknownField: true}}
+	}
+
+	var issues []Issue
+	if !slices.Contains(f.Ops, c.Op) {
+		issues = append(issues, Issue{
+			Severity: SeverityError,
+			Field:    c.Field,
+			Msg:      fmt.Sprintf("不支持运算符 %s，可用运算符: %s", c.Op, strings.Join(f.Ops, " ")),
+		})
+	}
+	if issue, ok := lintValue(d, f, c); !ok {
+		issues = append(issues, issue)
+	}
+	if f.Level != "" {
+		issues = append(issues, Issue{
+			Severity: SeverityInfo,
+			Field:    c.Field,
+			Msg:      fmt.Sprintf("需要%s权限", f.Level),
+		})
+	}
+	return issues
+}
+
+// lintValue 按字段类型检查值的格式
+func lintValue(d Dialect, f Field, c *Condition) (Issue, bool) {
+	issue := Issue{Severity: SeverityError, Field: c.Field}
+
+	if c.Range {
+		if f.Type != TypeInt && f.Type != TypeDate {
+			issue.Severity = SeverityWarning
+			issue.Msg = fmt.Sprintf("%s 类型字段使用范围查询可能没有结果", f.Type)
+			return issue, false
+		}
+		return issue, true
+	}
+
+	v := strings.TrimSpace(c.Value)
+	switch f.Type {
+	case TypeInt:
+		if _, err := strconv.Atoi(v); err != nil {
+			issue.Msg = fmt.Sprintf("值应为整数: %q", c.Value)
+			return issue, false
+		}
+	case TypeBool:
+		if v != "true" && v != "false" {
+			issue.Msg = fmt.Sprintf("值应为 true 或 false: %q", c.Value)
+			return issue, false
+		}
+	case TypeIP:
+		if net.ParseIP(v) == nil {
+			if _, _, err := net.ParseCIDR(v); err != nil {
+				issue.Msg = fmt.Sprintf("值应为IP地址或CIDR地址段: %q", c.Value)
+				return issue, false
+			}
+		}
+	case TypeDate:
+		layouts := dateInputs[d]
+		if len(layouts) == 0 {
+			return issue, true
+		}
+		names := make([]string, len(layouts))
+		for i, layout := range layouts {
+			if _, err := time.Parse(layout, v); err == nil {
+				return issue, true
+			}
+			names[i] = layoutName(layout)
+		}
+		issue.Msg = fmt.Sprintf("日期格式错误: %q，应为 %s", c.Value, strings.Join(names, "、"))
+		// 兼容另一种常见写法时给出修正建议
+		if fixed, err := convertDate(v, d, d); err == nil {
+			issue.Msg += fmt.Sprintf("，如 %s", fixed)
+		}
+		return issue, false
+	}
+	return issue, true
+}
+
+// layoutName 返回日期格式的可读写法
+func layoutName(layout string) string {
+	r := strings.NewReplacer("2006", "YYYY", "01", "MM", "02", "DD")
+	return r.Replace(layout)
+}
+
+// suggestField 在字段目录中查找与输入最接近的字段名
+func suggestField(d Dialect, name string) string {
+	best, bestDist := "", 3 // 编辑距离超过2时不给出建议
+	for _, f := range catalogs[d] {
+		if dist := editDistance(name, f.Name); dist < bestDist {
+			best, bestDist = f.Name, dist
+		}
+	}
+	return best
+}
+
+// editDistance 计算两个字符串的编辑距离
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,84 @@
+package query
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintDate(t *testing.T) {
+	tests := []struct {
+		d    Dialect
+		src  string
+		want string // 错误信息中应包含的内容，为空表示通过检查
+	}{
+		{FOFA, `after="20230101"`, ""},
+		{FOFA, `after="2023-01-01"`, ""},
+		{FOFA, `after="2017" && before="2017-10-01"`, ""},
+		{FOFA, `after="2023/01/01"`, "应为 YYYYMMDD、YYYY-MM-DD、YYYY"},
+		{Hunter, `after="2023-01-01"`, ""},
+		{Hunter, `after="20230101"`, "如 2023-01-01"},
+		{Hunter, `after="2017"`, "应为 YYYY-MM-DD"},
+	}
+	for _, tt := range tests {
+		_, err := Check(tt.d, tt.src)
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("Check(%s, %q) error: %v", tt.d, tt.src, err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("Check(%s, %q) error = %v, want %q", tt.d, tt.src, err, tt.want)
+		}
+	}
+}
+
+func TestTranslateYear(t *testing.T) {
+	got, err := Translate(`after="2017"`, FOFA, Hunter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `after="2017-01-01"`; got.Query != want {
+		t.Errorf("Translate() = %s, want %s", got.Query, want)
+	}
+}
+
+func TestCheckUnknownField(t *testing.T) {
+	tests := []struct {
+		d   Dialect
+		src string
+	}{
+		{FOFA, `title="a" && some_new_field="x"`},
+		{Hunter, `web.some_new_field="x"`},
+		{Quake, `some_new_field:"x"`},
+	}
+	for _, tt := range tests {
+		// 执行查询前的检查只警告
+		issues, err := Check(tt.d, tt.src)
+		if err != nil {
+			t.Errorf("Check(%s, %q) error: %v", tt.d, tt.src, err)
+		}
+		if len(issues) != 1 || issues[0].Severity != SeverityWarning || !strings.Contains(issues[0].Msg, "未知字段") {
+			t.Errorf("Check(%s, %q) issues = %v, want one unknown field warning", tt.d, tt.src, issues)
+		}
+
+		// lint 命令中仍然是错误
+		issues = Lint(tt.d, tt.src)
+		if len(issues) != 1 || issues[0].Severity != SeverityError {
+			t.Errorf("Lint(%s, %q) = %v, want one error", tt.d, tt.src, issues)
+		}
+	}
+}
+
+func TestCatalogFields(t *testing.T) {
+	tests := []struct {
+		d   Dialect
+		src string
+	}{
+		{FOFA, `cert.is_expired=true`},
+		{FOFA, `header_hash="-123" || body_hash="456"`},
+		{Hunter, `header.content_length="100"`},
+	}
+	for _, tt := range tests {
+		if issues := Lint(tt.d, tt.src); len(issues) != 0 {
+			t.Errorf("Lint(%s, %q) = %v, want no issues", tt.d, tt.src, issues)
+		}
+	}
+}
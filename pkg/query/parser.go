@@ -0,0 +1,151 @@
+package query
+
+import (
+	"slices"
+	"strings"
+)
+
+// parser 递归下降语法分析器
+//
+//	expr      = and { OR and }
+//	and       = unary { AND unary }
+//	unary     = NOT unary | primary          (NOT 仅 Quake)
+//	primary   = "(" expr ")" | condition
+//	condition = field op value | string | word (单独的字符串或词为全文搜索)
+type parser struct {
+	d      Dialect
+	src    string
+	tokens []token
+	pos    int
+}
+
+// Parse 按指定方言解析查询语句
+func Parse(d Dialect, src string) (Node, error) {
+	if strings.TrimSpace(src) == "" {
+		return nil, &SyntaxError{Query: src, Msg: "查询语句不能为空"}
+	}
+
+	tokens, err := tokenize(d, src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{d: d, src: src, tokens: tokens}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "多余的内容 %q", p.src[tok.pos:])
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) errorf(tok token, msg string, args ...any) error {
+	l := &lexer{src: p.src}
+	return l.errorf(tok.pos, msg, args...)
+}
+
+func (p *parser) parseOr() (Node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: Or, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryExpr{Op: And, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	if p.peek().kind == tokNot {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &NotExpr{X: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokLParen:
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, p.errorf(closing, "缺少右括号")
+		}
+		return &ParenExpr{X: x}, nil
+	case tokString:
+		// 单独的字符串为全文搜索
+		return &Condition{Value: tok.text, Quoted: true}, nil
+	case tokBare:
+		// 单独的词同样为全文搜索，如 nginx
+		return &Condition{Value: tok.text}, nil
+	case tokIdent:
+		return p.parseCondition(tok)
+	case tokEOF:
+		return nil, p.errorf(tok, "查询语句不完整")
+	default:
+		return nil, p.errorf(tok, "此处不能出现 %q", tok.text)
+	}
+}
+
+func (p *parser) parseCondition(field token) (Node, error) {
+	op := p.next()
+	if !slices.Contains(p.d.Operators(), op.text) {
+		return nil, p.errorf(op, "%s 不支持运算符 %s，可用运算符: %s",
+			p.d, op.text, strings.Join(p.d.Operators(), " "))
+	}
+
+	value := p.next()
+	c := &Condition{Field: field.text, Op: op.text, Value: value.text}
+	switch value.kind {
+	case tokString:
+		c.Quoted = true
+	case tokBare:
+	case tokRange:
+		c.Range = true
+	default:
+		return nil, p.errorf(value, "字段 %s 缺少值", field.text)
+	}
+	return c, nil
+}
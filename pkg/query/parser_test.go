@@ -0,0 +1,164 @@
+package query
+
+import (
+	"strings"
+	"testing"
+)
+
+// dump 将语法树输出为便于比较的形式，如 (&& title="a" paren((|| port=80 port=443)))
+// 带引号的值输出引号，不带引号的值原样输出，用于区分 Quoted
+func dump(n Node) string {
+	switch n := n.(type) {
+	case *BinaryExpr:
+		op := "&&"
+		if n.Op == Or {
+			op = "||"
+		}
+		return "(" + op + " " + dump(n.Left) + " " + dump(n.Right) + ")"
+	case *NotExpr:
+		return "not(" + dump(n.X) + ")"
+	case *ParenExpr:
+		return "paren(" + dump(n.X) + ")"
+	case *Condition:
+		v := n.Value
+		if n.Quoted {
+			v = `"` + v + `"`
+		}
+		return n.Field + n.Op + v
+	}
+	return "?"
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		d    Dialect
+		src  string
+		want string
+	}{
+		// 逻辑运算符和括号
+		{"and", FOFA, `title="a" && port="80"`, `(&& title="a" port="80")`},
+		{"or", FOFA, `title="a" || title="b"`, `(|| title="a" title="b")`},
+		{"and优先于or", FOFA, `a="1" || b="2" && c="3"`, `(|| a="1" (&& b="2" c="3"))`},
+		{"括号", FOFA, `(title="a" || title="b") && port="80"`, `(&& paren((|| title="a" title="b")) port="80")`},
+		{"嵌套括号", FOFA, `((a="1"))`, `paren(paren(a="1"))`},
+		{"没有空格", Hunter, `web.title="a"&&(ip.port="80"||ip.port="443")`, `(&& web.title="a" paren((|| ip.port="80" ip.port="443")))`},
+
+		// 比较运算符
+		{"等于", FOFA, `title="a"`, `title="a"`},
+		{"精确匹配", FOFA, `title=="a"`, `title=="a"`},
+		{"不等于", FOFA, `port!="80"`, `port!="80"`},
+		{"模糊匹配", FOFA, `title*="log*"`, `title*="log*"`},
+		{"大于", Hunter, `ip.port>"80"`, `ip.port>"80"`},
+		{"小于", Hunter, `ip.port<"1024"`, `ip.port<"1024"`},
+		{"运算符两侧空格", FOFA, `title = "a"`, `title="a"`},
+
+		// 带引号的值
+		{"值中的&&", FOFA, `title="a && b" && port="80"`, `(&& title="a && b" port="80")`},
+		{"值中的||", FOFA, `title="a || b"`, `title="a || b"`},
+		{"值中的=", FOFA, `body="a=b"`, `body="a=b"`},
+		{"值中的空格", FOFA, `title="hello world"`, `title="hello world"`},
+		{"值中的括号", FOFA, `title="(a)" && port="80"`, `(&& title="(a)" port="80")`},
+		{"转义引号", FOFA, `title="say \"hi\""`, `title="say "hi""`},
+		{"单引号", FOFA, `title='a'`, `title="a"`},
+		{"中文", FOFA, `title="后台管理"`, `title="后台管理"`},
+
+		// 不带引号的值
+		{"不带引号", FOFA, `port=80`, `port=80`},
+		{"不带引号的空格", FOFA, `title=hello world && port=80`, `(&& title=hello world port=80)`},
+		{"不带引号的括号", FOFA, `(title=a(b) || port=80)`, `paren((|| title=a(b) port=80))`},
+
+		// 全文搜索
+		{"全文搜索字符串", FOFA, `"nginx"`, `"nginx"`},
+		{"全文搜索词", FOFA, `nginx`, `nginx`},
+		{"全文搜索组合", FOFA, `nginx && port="80"`, `(&& nginx port="80")`},
+		{"全文搜索括号", FOFA, `(nginx || apache)`, `paren((|| nginx apache))`},
+		{"中文全文搜索词", FOFA, `登录`, `登录`},
+		{"中文全文搜索组合", FOFA, `登录 && port="80"`, `(&& 登录 port="80")`},
+		{"中文和字母混合", FOFA, `后台admin || title="x"`, `(|| 后台admin title="x")`},
+
+		// Quake
+		{"quake", Quake, `title:"a" AND port:80`, `(&& title:"a" port:80)`},
+		{"quake小写关键字", Quake, `title:"a" and port:80 or port:443`, `(|| (&& title:"a" port:80) port:443)`},
+		{"quake NOT", Quake, `title:"a" AND NOT port:80`, `(&& title:"a" not(port:80))`},
+		{"quake NOT括号", Quake, `NOT (port:80 OR port:443)`, `not(paren((|| port:80 port:443)))`},
+		{"quake NOT NOT", Quake, `NOT NOT port:80`, `not(not(port:80))`},
+		{"quake范围", Quake, `port:[80 TO 90]`, `port:[80 TO 90]`},
+		{"quake开区间", Quake, `port:{80 TO 90}`, `port:{80 TO 90}`},
+		{"quake范围组合", Quake, `port:[80 TO 90] AND NOT port:85`, `(&& port:[80 TO 90] not(port:85))`},
+		{"quake值中的AND", Quake, `title:"A AND B"`, `title:"A AND B"`},
+		{"quake不带引号的值以关键字结束", Quake, `title:hello world AND port:80`, `(&& title:hello world port:80)`},
+		{"quake值中的ANDROID", Quake, `os:android`, `os:android`},
+		{"quake全文搜索", Quake, `nginx AND port:80`, `(&& nginx port:80)`},
+		{"quake中文全文搜索", Quake, `登录 AND NOT port:80`, `(&& 登录 not(port:80))`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := Parse(tt.d, tt.src)
+			if err != nil {
+				t.Fatalf("Parse(%s, %q) error: %v", tt.d, tt.src, err)
+			}
+			if got := dump(n); got != tt.want {
+				t.Errorf("Parse(%s, %q) = %s, want %s", tt.d, tt.src, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	n, err := Parse(Quake, `port:[80 TO 90]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, ok := n.(*Condition)
+	if !ok || !c.Range || c.Quoted {
+		t.Errorf("Parse(port:[80 TO 90]) = %#v, want Range condition", n)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		d    Dialect
+		src  string
+		want string // 错误信息中应包含的内容
+	}{
+		{"空查询", FOFA, `  `, "不能为空"},
+		{"引号未闭合", FOFA, `title="a`, "引号未闭合"},
+		{"缺少右括号", FOFA, `(title="a"`, "缺少右括号"},
+		{"多余的右括号", FOFA, `title="a")`, "多余的内容"},
+		{"缺少运算符", FOFA, `title "a"`, "缺少比较运算符"},
+		{"缺少值", FOFA, `title=`, "缺少值"},
+		{"不完整", FOFA, `title="a" &&`, "不完整"},
+		{"fofa不支持>", FOFA, `port>"80"`, "不支持运算符 >"},
+		{"hunter不支持*=", Hunter, `web.title*="a"`, "不支持运算符 *="},
+		{"quake不支持=", Quake, `title="a"`, "不支持运算符 ="},
+		{"fofa不支持AND", FOFA, `title="a" AND port="80"`, "不支持 AND"},
+		{"fofa不支持NOT", FOFA, `NOT port="80"`, "不支持 NOT"},
+		{"quake不支持&&", Quake, `title:"a" && port:80`, "不支持 &&"},
+		{"范围缺少括号", Quake, `port:[80 TO 90`, "缺少结束括号"},
+		{"无法识别的字符", FOFA, `title="a" && @`, "无法识别的字符"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.d, tt.src)
+			if err == nil {
+				t.Fatalf("Parse(%s, %q) error = nil, want %q", tt.d, tt.src, tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse(%s, %q) error = %v, want %q", tt.d, tt.src, err, tt.want)
+			}
+			if _, ok := err.(*SyntaxError); !ok {
+				t.Errorf("Parse(%s, %q) error type = %T, want *SyntaxError", tt.d, tt.src, err)
+			}
+		})
+	}
+}
+
+func TestSyntaxErrorPosition(t *testing.T) {
+	// 位置按字符计算，中文算一个字符
+	_, err := Parse(FOFA, `title="后台" && port "80"`)
+	if err == nil || !strings.Contains(err.Error(), "第20个字符") {
+		t.Errorf("error = %v, want position 第20个字符", err)
+	}
+}
@@ -0,0 +1,271 @@
+package query
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// fieldKind 字段值的类型，决定转换时是否需要处理值的格式
+type fieldKind int
+
+const (
+	kindText fieldKind = iota
+	kindDate           // 日期，FOFA 使用 YYYYMMDD，Hunter 使用 YYYY-MM-DD
+)
+
+// fieldMapping 同一含义的字段在各方言中的名称，空字符串表示没有对应字段
+type fieldMapping struct {
+	names map[Dialect]string
+	kind  fieldKind
+	note  string // 没有对应字段时的提示
+}
+
+// fieldMappings 字段对应表，反向查找时取第一个匹配项，因此有歧义的字段需要排在前面
+var fieldMappings = []fieldMapping{
+	{names: map[Dialect]string{FOFA: "ip", Hunter: "ip", Quake: "ip"}},
+	{names: map[Dialect]string{FOFA: "port", Hunter: "ip.port", Quake: "port"}},
+	{names: map[Dialect]string{FOFA: "domain", Hunter: "domain.suffix", Quake: "domain"}},
+	{names: map[Dialect]string{FOFA: "host", Hunter: "domain", Quake: "hostname"}},
+	{names: map[Dialect]string{FOFA: "title", Hunter: "web.title", Quake: "title"}},
+	{names: map[Dialect]string{FOFA: "body", Hunter: "web.body", Quake: "body"}},
+	{names: map[Dialect]string{FOFA: "header", Hunter: "header", Quake: "headers"}},
+	{names: map[Dialect]string{FOFA: "server", Hunter: "header.server"}, note: "请使用 headers:\"Server: xxx\""},
+	{names: map[Dialect]string{FOFA: "status_code", Hunter: "header.status_code"}},
+	{names: map[Dialect]string{FOFA: "app", Hunter: "app.name", Quake: "app"}},
+	{names: map[Dialect]string{FOFA: "product", Hunter: "app.name", Quake: "app"}},
+	{names: map[Dialect]string{FOFA: "protocol", Hunter: "protocol", Quake: "service"}},
+	{names: map[Dialect]string{FOFA: "banner", Hunter: "protocol.banner", Quake: "response"}},
+	{names: map[Dialect]string{FOFA: "os", Hunter: "ip.os", Quake: "os"}},
+	{names: map[Dialect]string{FOFA: "icp", Hunter: "icp.number", Quake: "icp"}},
+	{names: map[Dialect]string{FOFA: "cert", Hunter: "cert", Quake: "ssl"}},
+	{names: map[Dialect]string{FOFA: "cert.issuer", Hunter: "cert.issuer"}},
+	{names: map[Dialect]string{FOFA: "cert.subject", Hunter: "cert.subject"}},
+	{names: map[Dialect]string{FOFA: "country", Hunter: "ip.country", Quake: "country"}},
+	{names: map[Dialect]string{FOFA: "region", Hunter: "ip.province", Quake: "province"}},
+	{names: map[Dialect]string{FOFA: "city", Hunter: "ip.city", Quake: "city"}},
+	{names: map[Dialect]string{FOFA: "asn", Hunter: "as.number", Quake: "asn"}},
+	{names: map[Dialect]string{FOFA: "org", Hunter: "as.org", Quake: "org"}},
+	{names: map[Dialect]string{Hunter: "ip.isp", Quake: "isp"}},
+	{names: map[Dialect]string{FOFA: "after", Hunter: "after"}, kind: kindDate, note: "请使用 -m 参数限制时间范围"},
+	{names: map[Dialect]string{FOFA: "before", Hunter: "before"}, kind: kindDate, note: "请使用 -m 参数限制时间范围"},
+}
+
+// lookupField 查找字段在源方言中对应的映射
+func lookupField(d Dialect, field string) (fieldMapping, bool) {
+	for _, m := range fieldMappings {
+		if m.names[d] == field {
+			return m, true
+		}
+	}
+	return fieldMapping{}, false
+}
+
+// dateLayouts 各方言的日期格式，转换时输出该格式
+var dateLayouts = map[Dialect]string{
+	FOFA:   "20060102",
+	Hunter: "2006-01-02",
+}
+
+// dateInputs 各方言接受的日期写法，FOFA 还接受 YYYY-MM-DD 和只写年份，如 after="2017"
+var dateInputs = map[Dialect][]string{
+	FOFA:   {"20060102", "2006-01-02", "2006"},
+	Hunter: {"2006-01-02"},
+}
+
+// Translation 查询转换结果
+type Translation struct {
+	Query    string
+	Warnings []string // 语义不完全等价的转换
+}
+
+// UnsupportedError 查询中存在目标方言无法表达的条件
+type UnsupportedError struct {
+	From, To Dialect
+	Items    []string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("无法从 %s 转换到 %s: %s", e.From, e.To, strings.Join(e.Items, "; "))
+}
+
+// translator 保存一次转换过程中的状态
+type translator struct {
+	from, to    Dialect
+	warnings    []string
+	unsupported []string
+}
+
+// Translate 将查询语句从一种方言转换为另一种方言
+// 字段名、运算符和日期格式都会按目标方言转换，无法表达的条件以 *UnsupportedError 返回
+func Translate(src string, from, to Dialect) (*Translation, error) {
+	n, err := Parse(from, src)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &translator{from: from, to: to}
+	n = t.node(n)
+	if len(t.unsupported) > 0 {
+		return nil, &UnsupportedError{From: from, To: to, Items: t.unsupported}
+	}
+	return &Translation{Query: Format(to, n), Warnings: t.warnings}, nil
+}
+
+func (t *translator) warnf(format string, args ...any) {
+	t.warnings = append(t.warnings, fmt.Sprintf(format, args...))
+}
+
+func (t *translator) unsupportedf(format string, args ...any) {
+	t.unsupported = append(t.unsupported, fmt.Sprintf(format, args...))
+}
+
+// node 转换语法树节点
+func (t *translator) node(n Node) Node {
+	switch n := n.(type) {
+	case *BinaryExpr:
+		return &BinaryExpr{Op: n.Op, Left: t.node(n.Left), Right: t.node(n.Right)}
+	case *ParenExpr:
+		return &ParenExpr{X: t.node(n.X)}
+	case *NotExpr:
+		if t.to == Quake {
+			return &NotExpr{X: t.node(n.X)}
+		}
+		// FOFA/Hunter 没有 NOT，将取反下推到条件上
+		return t.node(t.negate(n.X))
+	case *Condition:
+		return t.condition(n)
+	}
+	return n
+}
+
+// negate 对表达式取反（德摩根定律），用于把 Quake 的 NOT 转换为 !=
+func (t *translator) negate(n Node) Node {
+	switch n := n.(type) {
+	case *BinaryExpr:
+		op := And
+		if n.Op == And {
+			op = Or
+		}
+		// 优先级由 Format 根据外层运算符自动加括号
+		return &BinaryExpr{Op: op, Left: t.negate(n.Left), Right: t.negate(n.Right)}
+	case *ParenExpr:
+		return &ParenExpr{X: t.negate(n.X)}
+	case *NotExpr:
+		return n.X
+	case *Condition:
+		c := *n
+		switch c.Op {
+		case ":", "=", "==":
+			c.Op = "!="
+		case "!=":
+			c.Op = "="
+		default:
+			t.unsupportedf("%s 无法取反", Format(t.from, n))
+		}
+		return &c
+	}
+	return n
+}
+
+// condition 转换单个条件的字段名、运算符和值
+func (t *translator) condition(src *Condition) Node {
+	c := *src
+	c.Quoted = false
+	text := Format(t.from, src)
+
+	// 全文搜索没有字段，原样保留
+	if c.Field != "" {
+		m, ok := lookupField(t.from, c.Field)
+		if !ok {
+			t.unsupportedf("%s: 未知字段 %s", text, c.Field)
+			return &c
+		}
+		field := m.names[t.to]
+		if field == "" {
+			msg := fmt.Sprintf("%s: %s 没有对应字段", text, t.to)
+			if m.note != "" {
+				msg += "，" + m.note
+			}
+			t.unsupported = append(t.unsupported, msg)
+			return &c
+		}
+		c.Field = field
+
+		if m.kind == kindDate {
+			value, err := convertDate(c.Value, t.from, t.to)
+			if err != nil {
+				t.unsupportedf("%s: %v", text, err)
+				return &c
+			}
+			c.Value = value
+		}
+	}
+
+	if c.Range && t.to != Quake {
+		t.unsupportedf("%s: %s 不支持范围查询", text, t.to)
+		return &c
+	}
+
+	return t.operator(&c, text)
+}
+
+// operator 转换比较运算符
+func (t *translator) operator(c *Condition, text string) Node {
+	switch t.to {
+	case Quake:
+		switch c.Op {
+		case "=", ":":
+			c.Op = ":"
+		case "==", "*=":
+			t.warnf("%s: Quake 没有 %s，已转换为 :", text, c.Op)
+			c.Op = ":"
+		case "!=":
+			c.Op = ":"
+			return &NotExpr{X: c}
+		case ">", "<":
+			// Quake 使用范围查询表示比较，范围包含边界值
+			t.warnf("%s: 已转换为包含边界的范围查询", text)
+			if c.Op == ">" {
+				c.Value = "[" + c.Value + " TO *]"
+			} else {
+				c.Value = "[* TO " + c.Value + "]"
+			}
+			c.Op = ":"
+			c.Range = true
+		}
+	case FOFA:
+		switch c.Op {
+		case ":":
+			c.Op = "="
+		case ">", "<":
+			t.unsupportedf("%s: fofa 不支持运算符 %s", text, c.Op)
+		}
+	case Hunter:
+		switch c.Op {
+		case ":":
+			c.Op = "="
+		case "*=":
+			t.warnf("%s: Hunter 没有 *=，已转换为 =（模糊匹配）", text)
+			c.Op = "="
+		}
+	}
+	return c
+}
+
+// convertDate 按目标方言的格式转换日期
+func convertDate(value string, from, to Dialect) (string, error) {
+	layout, ok := dateLayouts[to]
+	if !ok {
+		return "", fmt.Errorf("%s 不支持日期条件", to)
+	}
+
+	// 优先按源方言接受的写法解析，兼容用户书写的另一种格式
+	for _, l := range slices.Concat(dateInputs[from], []string{"20060102", "2006-01-02"}) {
+		if t, err := time.Parse(l, value); err == nil {
+			return t.Format(layout), nil
+		}
+	}
+	return "", fmt.Errorf("无法识别的日期: %s", value)
+}
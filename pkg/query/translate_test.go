@@ -0,0 +1,137 @@
+package query
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to Dialect
+		src      string
+		want     string
+		warnings int
+	}{
+		// 字段名
+		{"fofa到quake", FOFA, Quake, `title="login" && country="CN"`, `title:"login" AND country:"CN"`, 0},
+		{"fofa到hunter", FOFA, Hunter, `title="login" && port="80"`, `web.title="login" && ip.port="80"`, 0},
+		{"hunter到fofa", Hunter, FOFA, `ip.port="80" || web.title="x"`, `port="80" || title="x"`, 0},
+		{"quake到hunter", Quake, Hunter, `hostname:"a.com" AND service:"http"`, `domain="a.com" && protocol="http"`, 0},
+		{"有歧义的字段取第一项", Hunter, FOFA, `app.name="nginx"`, `app="nginx"`, 0},
+		{"括号", FOFA, Quake, `(port="80" || port="443") && title="x"`, `(port:80 OR port:443) AND title:"x"`, 0},
+		{"全文搜索", FOFA, Quake, `"nginx" && port="80"`, `"nginx" AND port:80`, 0},
+		{"全文搜索词", FOFA, Hunter, `nginx`, `"nginx"`, 0},
+		{"中文全文搜索词", FOFA, Quake, `登录 && port="80"`, `"登录" AND port:80`, 0},
+
+		// 日期
+		{"fofa日期到hunter", FOFA, Hunter, `after="20230101"`, `after="2023-01-01"`, 0},
+		{"hunter日期到fofa", Hunter, FOFA, `before="2023-12-31"`, `before="20231231"`, 0},
+		{"fofa中的另一种日期格式", FOFA, Hunter, `after="2023-01-01"`, `after="2023-01-01"`, 0},
+
+		// 运算符
+		{"不等于到quake", FOFA, Quake, `port!="80"`, `NOT port:80`, 0},
+		{"精确匹配到quake", FOFA, Quake, `title=="x"`, `title:"x"`, 1},
+		{"模糊匹配到quake", FOFA, Quake, `title*="log*"`, `title:"log*"`, 1},
+		{"模糊匹配到hunter", FOFA, Hunter, `title*="log"`, `web.title="log"`, 1},
+		{"大于到quake", Hunter, Quake, `ip.port>"80"`, `port:[80 TO *]`, 1},
+		{"小于到quake", Hunter, Quake, `ip.port<"1024"`, `port:[* TO 1024]`, 1},
+		{"quake冒号到fofa", Quake, FOFA, `port:80`, `port="80"`, 0},
+
+		// Quake NOT
+		{"NOT到fofa", Quake, FOFA, `NOT port:80`, `port!="80"`, 0},
+		{"NOT表达式到fofa", Quake, FOFA, `NOT (port:80 OR port:443)`, `(port!="80" && port!="443")`, 0},
+		{"NOT和表达式到hunter", Quake, Hunter, `title:"a" AND NOT (port:80 AND port:443)`, `web.title="a" && (ip.port!="80" || ip.port!="443")`, 0},
+		{"NOT NOT到fofa", Quake, FOFA, `NOT NOT port:80`, `port="80"`, 0},
+		{"NOT保留到quake", Quake, Quake, `NOT port:80`, `NOT port:80`, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Translate(tt.src, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("Translate(%q, %s, %s) error: %v", tt.src, tt.from, tt.to, err)
+			}
+			if got.Query != tt.want {
+				t.Errorf("Translate(%q, %s, %s) = %s, want %s", tt.src, tt.from, tt.to, got.Query, tt.want)
+			}
+			if len(got.Warnings) != tt.warnings {
+				t.Errorf("Translate(%q, %s, %s) warnings = %q, want %d", tt.src, tt.from, tt.to, got.Warnings, tt.warnings)
+			}
+			// 转换结果在目标方言中可以解析
+			if _, err := Parse(tt.to, got.Query); err != nil {
+				t.Errorf("Parse(%s, %q) error: %v", tt.to, got.Query, err)
+			}
+		})
+	}
+}
+
+func TestTranslateUnsupported(t *testing.T) {
+	tests := []struct {
+		name     string
+		from, to Dialect
+		src      string
+		want     string // 错误信息中应包含的内容
+	}{
+		{"未知字段", FOFA, Hunter, `foo="x"`, "未知字段 foo"},
+		{"没有对应字段", FOFA, Quake, `server="nginx"`, "quake 没有对应字段"},
+		{"没有对应字段的提示", FOFA, Quake, `server="nginx"`, `headers:"Server: xxx"`},
+		{"quake不支持日期", FOFA, Quake, `after="20230101"`, "quake 没有对应字段"},
+		{"无法识别的日期", FOFA, Hunter, `after="yesterday"`, "无法识别的日期"},
+		{"范围到fofa", Quake, FOFA, `port:[80 TO 90]`, "fofa 不支持范围查询"},
+		{"大于到fofa", Hunter, FOFA, `ip.port>"80"`, "fofa 不支持运算符 >"},
+		{"NOT范围", Quake, FOFA, `NOT ip:"1.1.1.1" AND NOT (port:[1 TO 2])`, "范围查询"},
+		{"收集全部问题", FOFA, Hunter, `foo="x" && bar="y"`, "未知字段 bar"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Translate(tt.src, tt.from, tt.to)
+			var ue *UnsupportedError
+			if !errors.As(err, &ue) {
+				t.Fatalf("Translate(%q, %s, %s) error = %v, want *UnsupportedError", tt.src, tt.from, tt.to, err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Translate(%q, %s, %s) error = %v, want %q", tt.src, tt.from, tt.to, err, tt.want)
+			}
+		})
+	}
+}
+
+// TestFieldMappings 对应表中每个字段都能转换到有对应字段的其他方言
+func TestFieldMappings(t *testing.T) {
+	dialects := []Dialect{FOFA, Hunter, Quake}
+	for _, m := range fieldMappings {
+		for _, from := range dialects {
+			name := m.names[from]
+			if name == "" {
+				continue
+			}
+			// 有歧义的字段只使用第一项
+			if first, _ := lookupField(from, name); first.names[FOFA] != m.names[FOFA] || first.names[Quake] != m.names[Quake] {
+				continue
+			}
+			value := `"x"`
+			if m.kind == kindDate {
+				value = `"2023-01-02"`
+			}
+			op := "="
+			if from == Quake {
+				op = ":"
+			}
+
+			for _, to := range dialects {
+				if to == from || m.names[to] == "" {
+					continue
+				}
+				got, err := Translate(name+op+value, from, to)
+				if err != nil {
+					t.Errorf("Translate(%s%s%s, %s, %s) error: %v", name, op, value, from, to, err)
+					continue
+				}
+				if !strings.HasPrefix(got.Query, m.names[to]) {
+					t.Errorf("Translate(%s%s%s, %s, %s) = %s, want field %s", name, op, value, from, to, got.Query, m.names[to])
+				}
+			}
+		}
+	}
+}
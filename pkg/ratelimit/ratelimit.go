@@ -0,0 +1,177 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Rate 令牌桶参数：每隔 Every 补充一个令牌，最多积累 Burst 个
+type Rate struct {
+	Every time.Duration
+	Burst int
+}
+
+// Bucket 令牌桶，可被多个协程同时使用
+type Bucket struct {
+	mu     sync.Mutex
+	rate   Rate
+	tokens float64
+	last   time.Time
+}
+
+// NewBucket 创建令牌桶，初始时令牌是满的
+func NewBucket(r Rate) *Bucket {
+	if r.Burst < 1 {
+		r.Burst = 1
+	}
+	return &Bucket{rate: r, tokens: float64(r.Burst), last: time.Now()}
+}
+
+// reserve 取走一个令牌，返回需要等待的时间
+// 令牌不足时预支，后来的调用者会排在后面等待，保证整体速率不超过限制
+func (b *Bucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.rate.Every <= 0 {
+		return 0
+	}
+
+	now := time.Now()
+	b.tokens += float64(now.Sub(b.last)) / float64(b.rate.Every)
+	b.tokens = min(b.tokens, float64(b.rate.Burst))
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens * float64(b.rate.Every))
+}
+
+// release 归还 reserve 取走的令牌，等待被取消时使用，避免后来的调用者多等
+func (b *Bucket) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.rate.Every <= 0 {
+		return
+	}
+	b.tokens = min(b.tokens+1, float64(b.rate.Burst))
+}
+
+// Wait 阻塞直到取得一个令牌，ctx 取消时立即返回 ctx 的错误，不占用令牌
+func (b *Bucket) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	d := b.reserve()
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		b.release()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Limiter 引擎级别的限速器，同时限制引擎的总请求速率和每个API密钥的请求速率
+type Limiter struct {
+	engine  *Bucket
+	perKey  Rate
+	mu      sync.Mutex
+	buckets map[string]*Bucket
+}
+
+// New 创建限速器，engine 限制引擎的总速率，perKey 限制单个密钥的速率
+func New(engine, perKey Rate) *Limiter {
+	return &Limiter{
+		engine:  NewBucket(engine),
+		perKey:  perKey,
+		buckets: make(map[string]*Bucket),
+	}
+}
+
+// bucket 返回密钥对应的令牌桶，不存在时创建
+func (l *Limiter) bucket(key string) *Bucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = NewBucket(l.perKey)
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// Wait 阻塞直到引擎和密钥都允许发送下一次请求，ctx 取消时立即返回 ctx 的错误
+func (l *Limiter) Wait(ctx context.Context, key string) error {
+	b := l.bucket(key)
+	if err := b.Wait(ctx); err != nil {
+		return err
+	}
+	if err := l.engine.Wait(ctx); err != nil {
+		// 请求没有发出，归还密钥的令牌
+		b.release()
+		return err
+	}
+	return nil
+}
+
+// Config 配置文件中引擎的限速配置，零值字段使用引擎的默认值
+type Config struct {
+	Interval       time.Duration `yaml:"interval"`        // 同一密钥两次请求的最小间隔，如 2s
+	Burst          int           `yaml:"burst"`           // 同一密钥允许连续发送的请求数
+	EngineInterval time.Duration `yaml:"engine_interval"` // 引擎全部密钥合计的最小请求间隔，为零时按密钥数量计算
+}
+
+// Rates 返回引擎的总速率和单个密钥的速率，def 为引擎默认的单个密钥速率，keys 为同时使用的密钥数量
+// 未配置 engine_interval 时引擎的总速率是单个密钥速率的 keys 倍
+func (c Config) Rates(def Rate, keys int) (engine, perKey Rate) {
+	perKey = def
+	if c.Interval > 0 {
+		perKey.Every = c.Interval
+	}
+	if c.Burst > 0 {
+		perKey.Burst = c.Burst
+	}
+
+	keys = max(keys, 1)
+	if c.EngineInterval > 0 {
+		return Rate{Every: c.EngineInterval, Burst: max(perKey.Burst, 1)}, perKey
+	}
+	return Rate{Every: perKey.Every / time.Duration(keys), Burst: max(perKey.Burst, 1) * keys}, perKey
+}
+
+// limiterKey 共用限速器的条件
+type limiterKey struct {
+	name           string
+	engine, perKey Rate
+}
+
+var (
+	mu       sync.Mutex
+	limiters = make(map[limiterKey]*Limiter)
+)
+
+// Get 返回引擎使用的限速器，同一引擎相同速率的客户端共用同一个限速器
+func Get(name string, engine, perKey Rate) *Limiter {
+	mu.Lock()
+	defer mu.Unlock()
+
+	k := limiterKey{name: name, engine: engine, perKey: perKey}
+	if l, ok := limiters[k]; ok {
+		return l
+	}
+	l := New(engine, perKey)
+	limiters[k] = l
+	return l
+}
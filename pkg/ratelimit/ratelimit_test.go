@@ -0,0 +1,149 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReserve(t *testing.T) {
+	tests := []struct {
+		name  string
+		rate  Rate
+		calls int
+		want  []bool // 每次调用是否需要等待
+	}{
+		{"不限速", Rate{Every: 0, Burst: 1}, 3, []bool{false, false, false}},
+		{"每次一个令牌", Rate{Every: time.Hour, Burst: 1}, 3, []bool{false, true, true}},
+		{"允许连续两次", Rate{Every: time.Hour, Burst: 2}, 3, []bool{false, false, true}},
+		{"Burst 为零时按一处理", Rate{Every: time.Hour}, 2, []bool{false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBucket(tt.rate)
+			var last time.Duration
+			for i := 0; i < tt.calls; i++ {
+				d := b.reserve()
+				if (d > 0) != tt.want[i] {
+					t.Fatalf("第 %d 次 reserve() = %v, 是否等待应为 %v", i+1, d, tt.want[i])
+				}
+				// 预支的令牌让后来的调用者排在后面
+				if d > 0 && d <= last {
+					t.Errorf("第 %d 次 reserve() = %v, 应大于上一次的 %v", i+1, d, last)
+				}
+				last = d
+			}
+		})
+	}
+}
+
+func TestWait(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		rate    Rate
+		drain   bool // 调用前取走初始令牌
+		ctx     func() (context.Context, context.CancelFunc)
+		wantErr bool
+		wait    time.Duration // 调用后下一个调用者需要等待的时间上限
+	}{
+		{
+			name:  "取得令牌",
+			rate:  Rate{Every: 20 * time.Millisecond, Burst: 1},
+			drain: true,
+			ctx:   func() (context.Context, context.CancelFunc) { return context.Background(), func() {} },
+			wait:  40 * time.Millisecond,
+		},
+		{
+			name:    "已取消时不占用令牌",
+			rate:    Rate{Every: time.Hour, Burst: 1},
+			ctx:     func() (context.Context, context.CancelFunc) { return canceled, func() {} },
+			wantErr: true,
+			wait:    0,
+		},
+		{
+			name:  "等待中取消时归还令牌",
+			rate:  Rate{Every: time.Hour, Burst: 1},
+			drain: true,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 10*time.Millisecond)
+			},
+			wantErr: true,
+			wait:    time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBucket(tt.rate)
+			if tt.drain {
+				b.reserve()
+			}
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+			if err := b.Wait(ctx); (err != nil) != tt.wantErr {
+				t.Fatalf("Wait() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if d := b.reserve(); d > tt.wait {
+				t.Errorf("Wait 之后 reserve() = %v, 应不超过 %v", d, tt.wait)
+			}
+		})
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+	l := New(Rate{Every: time.Hour, Burst: 1}, Rate{Every: time.Hour, Burst: 1})
+	if err := l.Wait(context.Background(), "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 引擎的令牌已用完，另一个密钥等待引擎时取消，密钥的令牌应归还
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, "b"); err == nil {
+		t.Fatal("Wait() 应返回 ctx 的错误")
+	}
+	if d := l.bucket("b").reserve(); d > 0 {
+		t.Errorf("取消后密钥 b 的 reserve() = %v, 令牌应已归还", d)
+	}
+}
+
+func TestRates(t *testing.T) {
+	def := Rate{Every: 2 * time.Second, Burst: 1}
+	tests := []struct {
+		name       string
+		config     Config
+		keys       int
+		wantEngine Rate
+		wantPerKey Rate
+	}{
+		{"默认值", Config{}, 0, def, def},
+		{"单个密钥", Config{}, 1, def, def},
+		{"总速率按密钥数量计算", Config{}, 2, Rate{Every: time.Second, Burst: 2}, def},
+		{"配置单个密钥的速率", Config{Interval: 3 * time.Second, Burst: 2}, 3, Rate{Every: time.Second, Burst: 6}, Rate{Every: 3 * time.Second, Burst: 2}},
+		{"配置总速率", Config{EngineInterval: 5 * time.Second}, 2, Rate{Every: 5 * time.Second, Burst: 1}, def},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, perKey := tt.config.Rates(def, tt.keys)
+			if engine != tt.wantEngine || perKey != tt.wantPerKey {
+				t.Errorf("Rates() = %v, %v, want %v, %v", engine, perKey, tt.wantEngine, tt.wantPerKey)
+			}
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	r := Rate{Every: time.Second, Burst: 1}
+	if Get("a", r, r) != Get("a", r, r) {
+		t.Error("相同引擎和速率应共用限速器")
+	}
+	if Get("a", r, r) == Get("b", r, r) {
+		t.Error("不同引擎不应共用限速器")
+	}
+	if Get("a", r, r) == Get("a", Rate{Every: 2 * time.Second, Burst: 1}, r) {
+		t.Error("不同速率不应共用限速器")
+	}
+}
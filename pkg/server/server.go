@@ -0,0 +1,293 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/yaxigin/mto/pkg/asset"
+	"github.com/yaxigin/mto/pkg/engine"
+	"github.com/yaxigin/mto/pkg/query"
+
+	"github.com/projectdiscovery/gologger"
+)
+
+// Config 服务参数
+type Config struct {
+	Token    string // 不为空时要求请求携带 Authorization: Bearer <Token>
+	MaxLimit int    // 单次请求最多返回的资产数量，0 表示不限制
+
+	// NewEngine 创建引擎客户端，API密钥由调用方在服务端提供
+	NewEngine func(name string) (engine.Engine, error)
+}
+
+// SearchRequest POST /search 的请求体
+type SearchRequest struct {
+	Engine  string `json:"engine"`   // 引擎名称，all 表示将 FOFA 写法的查询转换后在全部引擎上执行
+	Query   string `json:"query"`    // 查询语句，指定引擎时使用该引擎的原生语法
+	Limit   int    `json:"limit"`    // 最多返回的资产数量，0 表示不限制（受服务端 MaxLimit 约束）
+	Months  int    `json:"months"`   // 查询月份范围（hunter/quake）
+	UseNext bool   `json:"use_next"` // 使用连续翻页接口（fofa）
+	NoLint  bool   `json:"no_lint"`  // 跳过查询前的离线检查
+}
+
+// SearchResponse POST /search 的响应
+type SearchResponse struct {
+	Engine   string            `json:"engine"`
+	Queries  map[string]string `json:"queries"` // 各引擎实际执行的查询语句
+	Total    int               `json:"total"`
+	Warnings []string          `json:"warnings,omitempty"`
+	Errors   map[string]string `json:"errors,omitempty"` // all 模式下部分引擎失败时的错误
+	Assets   []asset.Asset     `json:"assets"`
+}
+
+// EngineInfo GET /engines 返回的引擎信息
+type EngineInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// errorResponse 出错时的响应
+type errorResponse struct {
+	Error  string        `json:"error"`
+	Issues []query.Issue `json:"issues,omitempty"`
+}
+
+// Server HTTP API 服务，API 密钥只在服务端读取，不会返回给客户端
+type Server struct {
+	cfg Config
+	mux *http.ServeMux
+}
+
+// New 创建服务
+func New(cfg Config) *Server {
+	s := &Server{cfg: cfg, mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /engines", s.handleEngines)
+	s.mux.HandleFunc("POST /search", s.handleSearch)
+	return s
+}
+
+// ServeHTTP 校验令牌后分发请求
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.cfg.Token != "" {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.Token)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "未授权"})
+			return
+		}
+	}
+	gologger.Info().Msgf("%s %s %s", r.RemoteAddr, r.Method, r.URL.Path)
+	s.mux.ServeHTTP(w, r)
+}
+
+// handleEngines 列出已注册的引擎
+func (s *Server) handleEngines(w http.ResponseWriter, r *http.Request) {
+	infos := []EngineInfo{}
+	for _, name := range engine.Names() {
+		info := EngineInfo{Name: name}
+		if d := engine.Describe(name); d != nil {
+			info.Description = d.Description()
+		}
+		infos = append(infos, info)
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+// handleSearch 执行查询，all 模式下在全部引擎上并发执行并合并去重
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	var req SearchRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("解析请求失败: %v", err)})
+		return
+	}
+	req.Query = strings.TrimSpace(req.Query)
+	if req.Query == "" {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "query 不能为空"})
+		return
+	}
+
+	limit := req.Limit
+	if s.cfg.MaxLimit > 0 && (limit <= 0 || limit > s.cfg.MaxLimit) {
+		limit = s.cfg.MaxLimit
+	}
+
+	queries, warnings, err := plan(req)
+	if err != nil {
+		resp := errorResponse{Error: err.Error()}
+		var lintErr *query.LintError
+		if errors.As(err, &lintErr) {
+			resp.Issues = lintErr.Issues
+		}
+		writeJSON(w, http.StatusBadRequest, resp)
+		return
+	}
+
+	// 查询已经在 plan 中检查过，引擎不再重复检查
+	opts := engine.Options{
+		Months:     req.Months,
+		MaxResults: limit,
+		UseNext:    req.UseNext,
+		NoLint:     true,
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results = make(map[string][]asset.Asset)
+		errs    = make(map[string]error)
+	)
+	for name, q := range queries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			eng, err := s.cfg.NewEngine(name)
+			var assets []asset.Asset
+			if err == nil {
+				assets, err = eng.Search(r.Context(), q, opts)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[name] = assets
+			if err != nil {
+				errs[name] = err
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 全部引擎都失败时返回错误
+	if len(errs) == len(queries) {
+		writeJSON(w, errorStatus(errs), errorResponse{Error: strings.Join(engineErrors(errs), "; ")})
+		return
+	}
+
+	// 按引擎名称顺序合并，保证输出稳定
+	var groups [][]asset.Asset
+	for _, name := range engine.Names() {
+		groups = append(groups, results[name])
+	}
+	assets := asset.Merge(groups...)
+	if limit > 0 && len(assets) > limit {
+		assets = assets[:limit]
+	}
+	if assets == nil {
+		assets = []asset.Asset{}
+	}
+
+	resp := SearchResponse{
+		Engine:   req.Engine,
+		Queries:  queries,
+		Total:    len(assets),
+		Warnings: warnings,
+		Assets:   assets,
+	}
+	if len(errs) > 0 {
+		resp.Errors = make(map[string]string, len(errs))
+		for name, err := range errs {
+			resp.Errors[name] = err.Error()
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// plan 确定每个引擎执行的查询语句，并进行离线检查
+func plan(req SearchRequest) (map[string]string, []string, error) {
+	queries := make(map[string]string)
+	var warnings []string
+
+	if req.Engine == "all" {
+		for _, name := range engine.Names() {
+			d, err := query.ParseDialect(name)
+			if err != nil {
+				continue
+			}
+			t, err := query.Translate(req.Query, query.FOFA, d)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("[%s] 跳过: %v", name, err))
+				continue
+			}
+			for _, w := range t.Warnings {
+				warnings = append(warnings, fmt.Sprintf("[%s] %s", name, w))
+			}
+			queries[name] = t.Query
+		}
+		if len(queries) == 0 {
+			return nil, warnings, fmt.Errorf("查询无法转换到任何引擎")
+		}
+	} else {
+		if !engine.Has(req.Engine) {
+			return nil, nil, fmt.Errorf("未知引擎 %q，可选: %s、all", req.Engine, strings.Join(engine.Names(), "、"))
+		}
+		queries[req.Engine] = req.Query
+	}
+
+	if req.NoLint {
+		return queries, warnings, nil
+	}
+	for name, q := range queries {
+		d, err := query.ParseDialect(name)
+		if err != nil {
+			continue
+		}
+		issues, err := query.Check(d, q)
+		if err != nil {
+			return nil, warnings, fmt.Errorf("[%s] %w", name, err)
+		}
+		for _, issue := range issues {
+			warnings = append(warnings, fmt.Sprintf("[%s] %s", name, issue))
+		}
+	}
+	return queries, warnings, nil
+}
+
+// engineErrors 按引擎名称顺序列出各引擎的错误
+func engineErrors(errs map[string]error) []string {
+	var msgs []string
+	for _, name := range engine.Names() {
+		if err, ok := errs[name]; ok {
+			msgs = append(msgs, fmt.Sprintf("[%s] %v", name, err))
+		}
+	}
+	return msgs
+}
+
+// errorStatus 全部引擎都失败时的状态码，各引擎的错误类型相同时使用对应的状态码，否则为 502
+func errorStatus(errs map[string]error) int {
+	status := 0
+	for _, err := range errs {
+		s := http.StatusBadGateway
+		switch {
+		case errors.Is(err, engine.ErrSyntax):
+			s = http.StatusBadRequest
+		case errors.Is(err, engine.ErrField):
+			s = http.StatusForbidden
+		case errors.Is(err, engine.ErrRateLimit):
+			s = http.StatusTooManyRequests
+		case errors.Is(err, engine.ErrQuota):
+			s = http.StatusPaymentRequired
+		}
+		if status != 0 && s != status {
+			return http.StatusBadGateway
+		}
+		status = s
+	}
+	return status
+}
+
+// writeJSON 输出JSON响应
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		gologger.Warning().Msgf("写入响应失败: %v", err)
+	}
+}
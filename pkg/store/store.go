@@ -0,0 +1,194 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yaxigin/mto/pkg/asset"
+
+	_ "modernc.org/sqlite" // 纯Go实现的SQLite驱动，不依赖cgo
+)
+
+// schema 资产表以 ip/port/host 为主键，sightings 表记录每次查询命中的历史
+const schema = `
+CREATE TABLE IF NOT EXISTS assets (
+	ip            TEXT    NOT NULL DEFAULT '',
+	port          INTEGER NOT NULL DEFAULT 0,
+	host          TEXT    NOT NULL DEFAULT '',
+	domain        TEXT    NOT NULL DEFAULT '',
+	url           TEXT    NOT NULL DEFAULT '',
+	title         TEXT    NOT NULL DEFAULT '',
+	server        TEXT    NOT NULL DEFAULT '',
+	protocol      TEXT    NOT NULL DEFAULT '',
+	icp           TEXT    NOT NULL DEFAULT '',
+	org           TEXT    NOT NULL DEFAULT '',
+	isp           TEXT    NOT NULL DEFAULT '',
+	country       TEXT    NOT NULL DEFAULT '',
+	components    TEXT    NOT NULL DEFAULT '',
+	engine        TEXT    NOT NULL DEFAULT '', -- 最近一次返回该资产的引擎
+	query         TEXT    NOT NULL DEFAULT '', -- 最近一次返回该资产的查询语句
+	last_update   TEXT    NOT NULL DEFAULT '', -- 引擎记录的资产更新时间
+	raw           TEXT    NOT NULL DEFAULT '',
+	first_seen_at TEXT    NOT NULL,            -- 第一次入库时间
+	last_seen_at  TEXT    NOT NULL,            -- 最近一次入库时间
+	PRIMARY KEY (ip, port, host)
+);
+CREATE INDEX IF NOT EXISTS idx_assets_domain ON assets(domain);
+CREATE INDEX IF NOT EXISTS idx_assets_last_seen ON assets(last_seen_at);
+
+CREATE TABLE IF NOT EXISTS sightings (
+	ip      TEXT    NOT NULL DEFAULT '',
+	port    INTEGER NOT NULL DEFAULT 0,
+	host    TEXT    NOT NULL DEFAULT '',
+	engine  TEXT    NOT NULL DEFAULT '',
+	query   TEXT    NOT NULL DEFAULT '',
+	seen_at TEXT    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_sightings_asset ON sightings(ip, port, host);
+`
+
+// upsertSQL 已存在的资产只用非空字段覆盖，保留其他引擎补充的信息
+const upsertSQL = `
+INSERT INTO assets (ip, port, host, domain, url, title, server, protocol, icp, org, isp, country,
+	components, engine, query, last_update, raw, first_seen_at, last_seen_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (ip, port, host) DO UPDATE SET
+	domain       = COALESCE(NULLIF(excluded.domain, ''), domain),
+	url          = COALESCE(NULLIF(excluded.url, ''), url),
+	title        = COALESCE(NULLIF(excluded.title, ''), title),
+	server       = COALESCE(NULLIF(excluded.server, ''), server),
+	protocol     = COALESCE(NULLIF(excluded.protocol, ''), protocol),
+	icp          = COALESCE(NULLIF(excluded.icp, ''), icp),
+	org          = COALESCE(NULLIF(excluded.org, ''), org),
+	isp          = COALESCE(NULLIF(excluded.isp, ''), isp),
+	country      = COALESCE(NULLIF(excluded.country, ''), country),
+	components   = COALESCE(NULLIF(excluded.components, ''), components),
+	engine       = excluded.engine,
+	query        = excluded.query,
+	last_update  = COALESCE(NULLIF(excluded.last_update, ''), last_update),
+	raw          = COALESCE(NULLIF(excluded.raw, ''), raw),
+	last_seen_at = excluded.last_seen_at
+`
+
+const sightingSQL = `INSERT INTO sightings (ip, port, host, engine, query, seen_at) VALUES (?, ?, ?, ?, ?, ?)`
+
+// Store SQLite资产库，可被多个协程同时使用
+type Store struct {
+	mu sync.Mutex // SQLite 同一时间只允许一个写事务
+	db *sql.DB
+}
+
+// Open 打开资产库，文件不存在时创建并初始化表结构
+func Open(path string) (*Store, error) {
+	// 参数写在连接串中，连接池中的每个连接都会设置
+	// busy_timeout 使并发写入时等待锁释放而不是直接返回 SQLITE_BUSY
+	db, err := sql.Open("sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("打开数据库失败: %v", err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("初始化数据库失败: %v", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close 关闭资产库
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Upsert 写入一组资产，按 ip/port/host 合并已有记录，并记录本次命中的查询语句
+func (s *Store) Upsert(assets []asset.Asset, query string) error {
+	if len(assets) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %v", err)
+	}
+	defer tx.Rollback()
+
+	upsert, err := tx.Prepare(upsertSQL)
+	if err != nil {
+		return fmt.Errorf("准备写入语句失败: %v", err)
+	}
+	defer upsert.Close()
+
+	sighting, err := tx.Prepare(sightingSQL)
+	if err != nil {
+		return fmt.Errorf("准备写入语句失败: %v", err)
+	}
+	defer sighting.Close()
+
+	now := time.Now().Format(time.DateTime)
+	for _, a := range assets {
+		engine := strings.Join(a.Engines(), "|")
+		var lastUpdate string
+		if a.LastSeen != nil {
+			lastUpdate = a.LastSeen.Format(time.DateTime)
+		}
+
+		if _, err := upsert.Exec(a.IP, a.Port, a.Host, a.Domain, a.URL, a.Title, a.Server, a.Protocol,
+			a.ICP, a.Org, a.ISP, a.Country, strings.Join(a.Components, ","), engine, query,
+			lastUpdate, string(a.Raw), now, now); err != nil {
+			return fmt.Errorf("写入资产失败: %v", err)
+		}
+		if _, err := sighting.Exec(a.IP, a.Port, a.Host, engine, query, now); err != nil {
+			return fmt.Errorf("写入查询记录失败: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交事务失败: %v", err)
+	}
+	return nil
+}
+
+// Result SQL查询结果
+type Result struct {
+	Columns []string
+	Rows    [][]string
+}
+
+// Query 执行任意SQL并以字符串形式返回全部结果，NULL 返回空字符串
+func (s *Store) Query(query string, args ...any) (*Result, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("执行SQL失败: %v", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("读取列信息失败: %v", err)
+	}
+
+	result := &Result{Columns: columns}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("读取结果失败: %v", err)
+		}
+		row := make([]string, len(columns))
+		for i, v := range values {
+			row[i] = v.String
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取结果失败: %v", err)
+	}
+	return result, nil
+}
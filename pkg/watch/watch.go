@@ -0,0 +1,148 @@
+package watch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yaxigin/mto/pkg/asset"
+	"github.com/yaxigin/mto/pkg/fileutil"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Entry 查询文件中的一条查询
+// 指定 Engine 时使用该引擎的原生语法；否则按 FOFA 写法书写，转换后在 Engines（默认全部引擎）上执行
+type Entry struct {
+	Name    string   `yaml:"name"`
+	Query   string   `yaml:"query"`
+	Engine  string   `yaml:"engine"`
+	Engines []string `yaml:"engines"`
+}
+
+// File 监控查询文件
+type File struct {
+	Queries []Entry `yaml:"queries"`
+}
+
+// Load 读取监控查询文件
+// .yml/.yaml 文件按 File 结构解析，其他文件每行一条 FOFA 写法的查询
+func Load(path string) ([]Entry, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".yml" && ext != ".yaml" {
+		queries, err := fileutil.ReadQueries(path)
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]Entry, len(queries))
+		for i, q := range queries {
+			entries[i] = Entry{Query: q}
+		}
+		return entries, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取查询文件失败: %v", err)
+	}
+	var f File
+	if err := yaml.Unmarshal(content, &f); err != nil {
+		return nil, fmt.Errorf("解析查询文件失败: %v", err)
+	}
+
+	var entries []Entry
+	for i, e := range f.Queries {
+		e.Query = strings.TrimSpace(e.Query)
+		if e.Query == "" {
+			return nil, fmt.Errorf("第 %d 条查询的 query 不能为空", i+1)
+		}
+		if e.Engine != "" && len(e.Engines) > 0 {
+			return nil, fmt.Errorf("第 %d 条查询不能同时指定 engine 和 engines", i+1)
+		}
+		entries = append(entries, e)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("查询文件中没有查询")
+	}
+	return entries, nil
+}
+
+// Label 返回查询在日志中显示的名称
+func (e Entry) Label() string {
+	if e.Name != "" {
+		return e.Name
+	}
+	return e.Query
+}
+
+// State 已经发现过的资产，保存在本地状态文件中，重启后继续使用
+type State struct {
+	Seen    map[string]time.Time `json:"seen"` // 资产 Key -> 第一次发现的时间
+	LastRun *time.Time           `json:"last_run,omitempty"`
+
+	path string
+}
+
+// StatePath 返回查询文件默认的状态文件路径
+func StatePath(queriesFile string) string {
+	return queriesFile + ".state.json"
+}
+
+// LoadState 读取状态文件，文件不存在时返回空状态
+func LoadState(path string) (*State, error) {
+	s := &State{Seen: make(map[string]time.Time), path: path}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取状态文件失败: %v", err)
+	}
+	if err := json.Unmarshal(content, s); err != nil {
+		return nil, fmt.Errorf("解析状态文件失败: %v", err)
+	}
+	if s.Seen == nil {
+		s.Seen = make(map[string]time.Time)
+	}
+	return s, nil
+}
+
+// Filter 返回之前没有见过的资产，并将它们标记为已发现
+func (s *State) Filter(assets []asset.Asset) []asset.Asset {
+	now := time.Now()
+	var fresh []asset.Asset
+	for _, a := range assets {
+		key := a.Key()
+		if key == "" {
+			continue
+		}
+		if _, ok := s.Seen[key]; ok {
+			continue
+		}
+		s.Seen[key] = now
+		fresh = append(fresh, a)
+	}
+	return fresh
+}
+
+// Save 先写临时文件再重命名，避免中途退出时留下不完整的状态文件
+func (s *State) Save() error {
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("生成状态文件失败: %v", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0644); err != nil {
+		return fmt.Errorf("写入状态文件失败: %v", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("保存状态文件失败: %v", err)
+	}
+	return nil
+}